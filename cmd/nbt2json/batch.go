@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/midnightfreddie/nbt2json"
+	"github.com/urfave/cli/v2"
+)
+
+// batchFlags are the flags for converting several files at once
+func batchFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "match",
+			Usage: "When --in is a directory or pattern, only convert files whose names match `PATTERN`, like *.dat",
+		},
+		&cli.IntFlag{
+			Name:  "jobs",
+			Usage: "Convert up to `NUM` files at once when --in is a directory or pattern. Defaults to the number of CPUs",
+		},
+	}
+}
+
+// convertFunc converts one input to one output with a converter set up by the flags
+type convertFunc func(c *cli.Context, converter *nbt2json.Converter, in io.Reader, out io.Writer) error
+
+// convertFiles converts --in to --out. If --in is a directory or glob pattern, each file in it is converted to a file
+// of the same relative path in the --out directory, with its name changed by outName.
+func convertFiles(c *cli.Context, convert convertFunc, outName func(string) string) error {
+	converter, err := newConverter(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	compression, err := outputCompression(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if isBatch(c.String("in")) {
+		return convertBatch(c, convert, outName, compression)
+	}
+	in, err := openInput(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer in.Close()
+	out := &output{path: c.String("out"), compression: compression}
+	err = convert(c, converter, in, out)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	err = out.Close()
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
+
+// isBatch reports whether the --in value names several files: a directory or a glob pattern. A file whose name has
+// wildcard characters, like backup[1].dat, is just that file.
+func isBatch(in string) bool {
+	if in == "-" {
+		return false
+	}
+	stat, err := os.Stat(in)
+	if err == nil {
+		return stat.IsDir()
+	}
+	return strings.ContainsAny(in, "*?[")
+}
+
+// batchFiles returns the files a directory or glob pattern names, walking any directories, and the directory their
+// output paths are relative to. Only files whose names match match are returned, unless it's empty. in is only a
+// pattern if no file or directory has its name.
+func batchFiles(in, match string) ([]string, string, error) {
+	base := in
+	matches := []string{in}
+	if _, err := os.Stat(in); err != nil && strings.ContainsAny(in, "*?[") {
+		i := strings.IndexAny(in, "*?[")
+		// the directory of the pattern's first path element with a wildcard
+		base = filepath.Dir(in[:i] + "x")
+		var err error
+		matches, err = filepath.Glob(in)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	var files []string
+	for _, m := range matches {
+		err := filepath.Walk(m, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			if match != "" {
+				if ok, err := filepath.Match(match, info.Name()); err != nil || !ok {
+					return err
+				}
+			}
+			files = append(files, path)
+			return nil
+		})
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return files, base, nil
+}
+
+// batchResult is the outcome of converting one file of a batch, and the encoding it was read with
+type batchResult struct {
+	path     string
+	encoding nbt2json.Encoding
+	err      error
+}
+
+// convertBatch converts each file of a batch with a pool of --jobs workers. A file that fails is reported and its
+// output removed, and the rest are still converted. With --endian auto, the encoding detected for each file is
+// reported with it. A summary is printed at the end.
+func convertBatch(c *cli.Context, convert convertFunc, outName func(string) string, compression nbt2json.Compression) error {
+	if c.String("out") == "-" {
+		return cli.NewExitError("--out must be a directory when --in is a directory or pattern", 1)
+	}
+	files, base, err := batchFiles(c.String("in"), c.String("match"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if len(files) == 0 {
+		return cli.NewExitError(fmt.Sprintf("No files found in %s", c.String("in")), 1)
+	}
+	jobs := c.Int("jobs")
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+
+	// each worker has its own converter, as --endian auto changes its encoding for each file
+	converters := make([]*nbt2json.Converter, jobs)
+	for i := range converters {
+		converters[i], err = newConverter(c)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+	}
+	paths := make(chan string)
+	results := make(chan batchResult)
+	for _, converter := range converters {
+		go func(converter *nbt2json.Converter) {
+			for path := range paths {
+				err := convertBatchFile(c, converter, convert, path, base, outName, compression)
+				results <- batchResult{path, converter.Encoding(), err}
+			}
+		}(converter)
+	}
+	go func() {
+		for _, path := range files {
+			paths <- path
+		}
+		close(paths)
+	}()
+	failed := 0
+	for range files {
+		result := <-results
+		if result.err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "%s: %s\n", result.path, result.err)
+		} else if c.String("endian") == "auto" {
+			fmt.Fprintf(os.Stderr, "%s: detected %s NBT\n", result.path, result.encoding)
+		}
+	}
+	summary := fmt.Sprintf("Converted %d of %d files to %s", len(files)-failed, len(files), c.String("out"))
+	if failed > 0 {
+		return cli.NewExitError(fmt.Sprintf("%s; %d failed", summary, failed), 1)
+	}
+	fmt.Fprintln(os.Stderr, summary)
+	return nil
+}
+
+// convertBatchFile converts the file at path to the same relative path from base in the --out directory
+func convertBatchFile(c *cli.Context, converter *nbt2json.Converter, convert convertFunc, path, base string, outName func(string) string, compression nbt2json.Compression) error {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return err
+	}
+	outPath := filepath.Join(c.String("out"), outName(rel))
+	err = os.MkdirAll(filepath.Dir(outPath), 0755)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out := &output{path: outPath, compression: compression}
+	err = convert(c, converter, in, out)
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		out.remove()
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/midnightfreddie/nbt2json"
+)
+
+// writeNbt writes the NBT for an SNBT value to name
+func writeNbt(t *testing.T, name, snbt string) {
+	t.Helper()
+	nbtData, err := nbt2json.Snbt2Nbt([]byte(snbt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(name, nbtData, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestWildcardFileName checks a file whose name has wildcard characters is converted as one file, not as a pattern
+func TestWildcardFileName(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "backup[1].dat")
+	writeNbt(t, in, "{a:1b}")
+	if isBatch(in) {
+		t.Errorf("%s expected to be one file", in)
+	}
+	out := filepath.Join(dir, "backup.json")
+	if _, _, err := run(t, "decode", "-i", in, "-o", out); err != nil {
+		t.Fatal("Error converting backup[1].dat:", err.Error())
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Error("Expected the JSON in --out:", err.Error())
+	}
+
+	// the same name is a pattern if no such file exists
+	if !isBatch(filepath.Join(dir, "backup[12].dat")) {
+		t.Error("backup[12].dat expected to be a pattern")
+	}
+	files, _, err := batchFiles(filepath.Join(dir, "backup[12].dat"), "")
+	if err != nil || len(files) != 0 {
+		t.Errorf("backup[12].dat expected to match no files, got %q, %v", files, err)
+	}
+}
+
+// TestBatch checks each file of a directory or pattern is converted to the same relative path, files that fail are
+// listed without stopping the rest, and a summary is printed
+func TestBatch(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+	if err := os.MkdirAll(filepath.Join(in, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeNbt(t, filepath.Join(in, "a.nbt"), "{a:1b}")
+	writeNbt(t, filepath.Join(in, "sub", "b.nbt"), "{b:2b}")
+	if err := ioutil.WriteFile(filepath.Join(in, "readme.txt"), []byte("not NBT"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, err := run(t, "decode", "-i", in, "-o", out, "--match", "*.nbt")
+	if err != nil {
+		t.Fatal("Error converting directory:", err.Error())
+	}
+	if !strings.Contains(stderr, "Converted 2 of 2 files to "+out) {
+		t.Errorf("Expected a summary of 2 files, got %q", stderr)
+	}
+	for _, name := range []string{"a.nbt.json", filepath.Join("sub", "b.nbt.json")} {
+		if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+			t.Errorf("Expected %s in --out: %s", name, err.Error())
+		}
+	}
+
+	// readme.txt fails, but the others are still converted and its output is removed
+	_, stderr, err = run(t, "decode", "-i", in, "-o", out, "--jobs", "1")
+	if err == nil || !strings.Contains(err.Error(), "Converted 2 of 3 files to "+out+"; 1 failed") {
+		t.Errorf("Expected a summary with 1 failed file, got %v", err)
+	}
+	if !strings.HasPrefix(stderr, filepath.Join(in, "readme.txt")+": ") || strings.Count(stderr, "\n") != 1 {
+		t.Errorf("Expected only readme.txt to be listed, got %q", stderr)
+	}
+	if _, err := os.Stat(filepath.Join(out, "readme.txt.json")); !os.IsNotExist(err) {
+		t.Error("Output of the failed file expected to be removed")
+	}
+
+	// a pattern's output paths are relative to the directory before the first wildcard
+	nbtOut := filepath.Join(dir, "nbt")
+	_, stderr, err = run(t, "encode", "-i", filepath.Join(out, "*", "*.json"), "-o", nbtOut)
+	if err != nil || !strings.Contains(stderr, "Converted 1 of 1 files") {
+		t.Fatalf("Error converting pattern: %v, %q", err, stderr)
+	}
+	nbtData, err := ioutil.ReadFile(filepath.Join(nbtOut, "sub", "b.nbt"))
+	expected, _ := nbt2json.Snbt2Nbt([]byte("{b:2b}"))
+	if err != nil || string(nbtData) != string(expected) {
+		t.Errorf("sub/b.nbt expected % x, got % x, %v", expected, nbtData, err)
+	}
+
+	// NOTE: Tested commands should throw error to pass
+	if _, _, err := run(t, "decode", "-i", in, "-o", "-"); err == nil {
+		t.Error("Converting a directory to stdout failed to throw error")
+	}
+	if _, _, err := run(t, "decode", "-i", filepath.Join(dir, "*.none"), "-o", out); err == nil {
+		t.Error("Converting a pattern matching no files failed to throw error")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/midnightfreddie/nbt2json"
+	"github.com/urfave/cli/v2"
+)
+
+// convertCommand rewrites NBT in another encoding
+func convertCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "convert",
+		Usage: "Convert NBT from one encoding to another, e.g. Java Edition to Bedrock Edition",
+		Description: "The input's encoding is set with --big-endian, --network or --endian as for decode, and the output's\n" +
+			"   with --to. Compressed input is decompressed; use --compression to compress the output. Bedrock's level.dat\n" +
+			"   header isn't written, and Java Edition's modified UTF-8 strings are converted to and from plain UTF-8.\n\n" +
+			"   If --in is a directory or a quoted glob pattern, every file in it is converted to the same path in the\n" +
+			"   --out directory. Directories are searched recursively. Files that fail are listed and the rest are still\n" +
+			"   converted.",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:     "to",
+				Usage:    "`ENCODING` of the output: big (Java), little (Bedrock) or network (Bedrock network protocol)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "in",
+				Value:   "-",
+				Aliases: []string{"i"},
+				Usage:   "Input `FILE` path, or a directory or glob pattern to convert several files",
+			},
+			&cli.StringFlag{
+				Name:    "out",
+				Value:   "-",
+				Aliases: []string{"o"},
+				Usage:   "Output `FILE` path, or the directory for the converted files when --in names several",
+			},
+			&cli.IntFlag{
+				Name:  "skip",
+				Value: 0,
+				Usage: "Skip `NUM` bytes of NBT input",
+			},
+		}, append(append(compressionFlags(), batchFlags()...), converterFlags()...)...),
+		Action: convert,
+	}
+}
+
+func convert(c *cli.Context) error {
+	var encoding nbt2json.Encoding
+	switch c.String("to") {
+	case "big", "java":
+		encoding = nbt2json.JavaEncoding
+	case "little", "bedrock":
+		encoding = nbt2json.BedrockEncoding
+	case "network":
+		encoding = nbt2json.BedrockNetworkEncoding
+	default:
+		return cli.NewExitError(fmt.Sprintf("--to must be big, little or network, not %q", c.String("to")), 1)
+	}
+	return convertFiles(c, func(c *cli.Context, converter *nbt2json.Converter, in io.Reader, out io.Writer) error {
+		nbtIn, err := nbtReader(c, converter, in, c.Int("skip"))
+		if err != nil {
+			return err
+		}
+		tags, err := converter.ReadNBT(nbtIn)
+		if err != nil {
+			return err
+		}
+		target, _ := newConverter(c)
+		target.UseEncoding(encoding)
+		return target.WriteNBT(out, tags)
+	}, func(name string) string {
+		return name
+	})
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/midnightfreddie/nbt2json"
+	"github.com/midnightfreddie/nbt2json/leveldb"
+	"github.com/urfave/cli/v2"
+)
+
+// dbCommand has subcommands for the keys in Bedrock Edition world databases
+func dbCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "db",
+		Usage: "List, get, put and delete keys in Bedrock Edition world LevelDB databases",
+		Description: "WORLD is the world folder or its db folder. Close Minecraft before writing to a world.\n" +
+			"   KEY is as listed: chunk:X,Z,DIMENSION,TAG[,SUBCHUNK] for chunk records, hex:HEX for binary keys, or the key name",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "List the keys in a world database",
+				ArgsUsage: "WORLD",
+				Action:    dbList,
+			},
+			{
+				Name:      "get",
+				Usage:     "Convert the NBT value of KEY to JSON",
+				ArgsUsage: "WORLD KEY",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "out",
+						Value:   "-",
+						Aliases: []string{"o"},
+						Usage:   "Output `FILE` path",
+					},
+					&cli.StringFlag{
+						Name:    "comment",
+						Aliases: []string{"c"},
+						Usage:   "Add `COMMENT` to json or yaml output, use quotes if contains white space",
+					},
+					&cli.BoolFlag{
+						Name:    "yaml",
+						Aliases: []string{"yml", "y"},
+						Usage:   "Use YAML instead of JSON",
+					},
+					&cli.BoolFlag{
+						Name:  "snbt",
+						Usage: "Use SNBT instead of JSON",
+					},
+					&cli.BoolFlag{
+						Name:    "long-as-string",
+						Aliases: []string{"l"},
+						Usage:   "If set, nbt long values will be a string instead of uint32 pair",
+					},
+				},
+				Action: dbGet,
+			},
+			{
+				Name:      "put",
+				Usage:     "Convert JSON to NBT and write it as the value of KEY",
+				ArgsUsage: "WORLD KEY",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "in",
+						Value:   "-",
+						Aliases: []string{"i"},
+						Usage:   "Input `FILE` path",
+					},
+					&cli.BoolFlag{
+						Name:    "yaml",
+						Aliases: []string{"yml", "y"},
+						Usage:   "Use YAML instead of JSON",
+					},
+					&cli.BoolFlag{
+						Name:  "snbt",
+						Usage: "Use SNBT instead of JSON",
+					},
+				},
+				Action: dbPut,
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete KEY from a world database",
+				ArgsUsage: "WORLD KEY",
+				Action:    dbDelete,
+			},
+		},
+	}
+}
+
+// dbKeyArgs opens the world database and parses the key from the command line
+func dbKeyArgs(c *cli.Context) (*leveldb.DB, []byte, error) {
+	if c.NArg() != 2 {
+		return nil, nil, fmt.Errorf("expected WORLD KEY, got %d arguments", c.NArg())
+	}
+	key, err := leveldb.ParseKeyString(c.Args().Get(1))
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := leveldb.Open(c.Args().Get(0))
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, key, nil
+}
+
+func dbList(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Expected WORLD", 1)
+	}
+	db, err := leveldb.Open(c.Args().Get(0))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer db.Close()
+	keys, err := db.Keys()
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Printf("%8s %-4s %s\n", "BYTES", "NBT", "KEY")
+	for _, raw := range keys {
+		value, err := db.Get(raw)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		key := leveldb.ParseKey(raw)
+		isNbt := "no"
+		if key.NBT() {
+			isNbt = "yes"
+		}
+		fmt.Printf("%8d %-4s %s\n", len(value), isNbt, key)
+	}
+	return nil
+}
+
+func dbGet(c *cli.Context) error {
+	if c.Bool("yaml") && c.Bool("snbt") {
+		return cli.NewExitError("--yaml and --snbt can't be used together", 1)
+	}
+	db, key, err := dbKeyArgs(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer db.Close()
+	converter := nbt2json.NewConverter()
+	if c.Bool("long-as-string") {
+		converter.UseLongAsString()
+	}
+	nbtData, err := db.Get(key)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	var out io.Writer = os.Stdout
+	if c.String("out") != "-" {
+		f, err := os.Create(c.String("out"))
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		defer f.Close()
+		out = f
+	}
+	var outData []byte
+	if c.Bool("snbt") {
+		outData, err = converter.Nbt2Snbt(nbtData)
+	} else if c.Bool("yaml") {
+		outData, err = converter.Nbt2Yaml(nbtData, c.String("comment"))
+	} else {
+		outData, err = converter.Nbt2Json(nbtData, c.String("comment"))
+	}
+	if err != nil {
+		if !leveldb.ParseKey(key).NBT() {
+			return cli.NewExitError(fmt.Sprintf("%s\n%s is not usually NBT", err.Error(), c.Args().Get(1)), 1)
+		}
+		return cli.NewExitError(err, 1)
+	}
+	_, err = out.Write(outData)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
+
+func dbPut(c *cli.Context) error {
+	if c.Bool("yaml") && c.Bool("snbt") {
+		return cli.NewExitError("--yaml and --snbt can't be used together", 1)
+	}
+	converter := nbt2json.NewConverter()
+
+	var in io.Reader = os.Stdin
+	if c.String("in") != "-" {
+		f, err := os.Open(c.String("in"))
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		defer f.Close()
+		in = f
+	}
+	var nbtData []byte
+	if c.Bool("snbt") || c.Bool("yaml") {
+		inData, err := ioutil.ReadAll(in)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		if c.Bool("snbt") {
+			nbtData, err = converter.Snbt2Nbt(inData)
+		} else {
+			nbtData, err = converter.Yaml2Nbt(inData)
+		}
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+	} else {
+		var buf bytes.Buffer
+		err := converter.NewEncoder(&buf).Encode(in)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		nbtData = buf.Bytes()
+	}
+
+	// Convert before opening the database so bad input doesn't leave an empty log behind
+	db, key, err := dbKeyArgs(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	err = db.Put(key, nbtData)
+	if err != nil {
+		db.Close()
+		return cli.NewExitError(err, 1)
+	}
+	err = db.Close()
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
+
+func dbDelete(c *cli.Context) error {
+	db, key, err := dbKeyArgs(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	err = db.Delete(key)
+	if err != nil {
+		db.Close()
+		return cli.NewExitError(err, 1)
+	}
+	err = db.Close()
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+	"github.com/midnightfreddie/nbt2json"
+	"github.com/urfave/cli/v2"
+)
+
+// decodeFlags are the flags of decode, which the main command has too
+func decodeFlags() []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "in",
+			Value:   "-",
+			Aliases: []string{"i"},
+			Usage:   "Input `FILE` path, or a directory or glob pattern to convert several files",
+		},
+		&cli.StringFlag{
+			Name:    "out",
+			Value:   "-",
+			Aliases: []string{"o"},
+			Usage:   "Output `FILE` path, or the directory for the converted files when --in names several",
+		},
+		&cli.StringFlag{
+			Name:    "comment",
+			Aliases: []string{"c"},
+			Usage:   "Add `COMMENT` to json or yaml output, use quotes if contains white space",
+		},
+		&cli.BoolFlag{
+			Name:    "yaml",
+			Aliases: []string{"yml", "y"},
+			Usage:   "Use YAML instead of JSON",
+		},
+		&cli.BoolFlag{
+			Name:  "snbt",
+			Usage: "Use SNBT (stringified NBT as in Java Edition commands) instead of JSON",
+		},
+		&cli.BoolFlag{
+			Name:  "verify",
+			Usage: "Check the NBT converts to JSON and back to the same bytes before converting it, and fail if not",
+		},
+		&cli.IntFlag{
+			Name:  "skip",
+			Value: 0,
+			Usage: "Skip `NUM` bytes of NBT input. Bedrock's level.dat header is detected without this",
+		},
+	}, append(append(compressionFlags(), batchFlags()...), converterFlags()...)...)
+}
+
+// decodeCommand converts NBT to JSON, YAML or SNBT
+func decodeCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "decode",
+		Usage: "Convert NBT to JSON, YAML or SNBT",
+		Description: "Gzip, zlib and LZ4 input is decompressed, and the JSON or YAML records the compression. The output can be edited\n" +
+			"   and converted back to the same NBT with encode, using the same encoding flags.\n\n" +
+			"   If --in is a directory or a quoted glob pattern like 'world/playerdata/*.dat', every file in it is converted\n" +
+			"   to the same path in the --out directory with .json, .yaml or .snbt added to its name. Directories are searched\n" +
+			"   recursively. Files that fail are listed and the rest are still converted.",
+		Flags:  decodeFlags(),
+		Action: decode,
+	}
+}
+
+func decode(c *cli.Context) error {
+	if c.Bool("yaml") && c.Bool("snbt") {
+		return cli.NewExitError("--yaml and --snbt can't be used together", 1)
+	}
+	if c.Bool("verify") && (c.Bool("yaml") || c.Bool("snbt")) {
+		return cli.NewExitError("--verify checks NBT to JSON conversion and can't be used with --yaml or --snbt", 1)
+	}
+	ext := ".json"
+	if c.Bool("yaml") {
+		ext = ".yaml"
+	} else if c.Bool("snbt") {
+		ext = ".snbt"
+	}
+	return convertFiles(c, decodeNbt, func(name string) string {
+		return name + ext
+	})
+}
+
+// decodeOptions are the flags that change how decodeNbt converts NBT, besides the converter's settings
+type decodeOptions struct {
+	skip       int
+	verify     bool
+	yaml, snbt bool
+	comment    string
+}
+
+// decodeNbt converts the NBT in in to JSON, YAML or SNBT as the flags set
+func decodeNbt(c *cli.Context, converter *nbt2json.Converter, in io.Reader, out io.Writer) error {
+	options := decodeOptions{
+		skip:    c.Int("skip"),
+		verify:  c.Bool("verify"),
+		yaml:    c.Bool("yaml"),
+		snbt:    c.Bool("snbt"),
+		comment: c.String("comment"),
+	}
+	return decodeNbtWith(c, converter, options, in, out)
+}
+
+// decodeNbtWith converts the NBT in in to JSON, YAML or SNBT as options set. JSON and YAML record the compression of in.
+func decodeNbtWith(c *cli.Context, converter *nbt2json.Converter, options decodeOptions, in io.Reader, out io.Writer) error {
+	// Decompress here rather than in nbtReader to keep the compression and gzip header for the document
+	in, compression, err := nbt2json.NewDecompressReader(in)
+	if err != nil {
+		return err
+	}
+	var gzipHeader *gzip.Header
+	if zr, ok := in.(*gzip.Reader); ok {
+		gzipHeader = &zr.Header
+	}
+	nbtIn, err := nbtReader(c, converter, in, options.skip)
+	if err != nil {
+		return err
+	}
+	if options.verify {
+		inData, err := ioutil.ReadAll(nbtIn)
+		if err != nil {
+			return err
+		}
+		err = converter.RoundTrip(inData)
+		if err != nil {
+			return err
+		}
+		nbtIn = bytes.NewReader(inData)
+	}
+	if options.snbt {
+		inData, err := ioutil.ReadAll(nbtIn)
+		if err != nil {
+			return err
+		}
+		outData, err := converter.Nbt2Snbt(inData)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(outData)
+		return err
+	}
+	decoder := converter.NewDecoder(nbtIn)
+	decoder.RecordCompression(compression, gzipHeader)
+	if !options.yaml {
+		return decoder.Decode(out, options.comment)
+	}
+	var jsonOut bytes.Buffer
+	err = decoder.Decode(&jsonOut, options.comment)
+	if err != nil {
+		return err
+	}
+	outData, err := yaml.JSONToYAML(jsonOut.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(outData)
+	return err
+}
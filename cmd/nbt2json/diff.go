@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/midnightfreddie/nbt2json"
+	"github.com/urfave/cli/v2"
+)
+
+// diffCommand compares two NBT files
+func diffCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "diff",
+		Usage:     "List the tags added, removed and changed between two NBT files",
+		ArgsUsage: "OLD_FILE NEW_FILE",
+		Description: "Compounds are compared by child name and lists and arrays by index. Paths can be used with get.\n" +
+			"   Exits with 0 if the files have the same tags, 1 if they differ, and 2 on errors.",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "out",
+				Value:   "-",
+				Aliases: []string{"o"},
+				Usage:   "Output `FILE` path",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "text",
+				Usage: "Output `FORMAT`: text, json or unified",
+			},
+			&cli.BoolFlag{
+				Name:  "ignore-order",
+				Usage: "Don't report compounds whose children are in a different order",
+			},
+		}, converterFlags()...),
+		Action: diff,
+	}
+}
+
+// readNbtFile reads a whole NBT file as a tree, decompressing it and detecting the byte order as nbtReader does
+func readNbtFile(c *cli.Context, converter *nbt2json.Converter, name string) ([]nbt2json.NamedTag, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	in, err := nbtReader(c, converter, f, 0)
+	if err != nil {
+		return nil, err
+	}
+	return converter.ReadNBT(in)
+}
+
+func diff(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return cli.NewExitError("Expected OLD_FILE NEW_FILE", 2)
+	}
+	converter, err := newConverter(c)
+	if err != nil {
+		return cli.NewExitError(err, 2)
+	}
+	oldTags, err := readNbtFile(c, converter, c.Args().Get(0))
+	if err != nil {
+		return cli.NewExitError(err, 2)
+	}
+	newTags, err := readNbtFile(c, converter, c.Args().Get(1))
+	if err != nil {
+		return cli.NewExitError(err, 2)
+	}
+	diffs := nbt2json.Diff(oldTags, newTags, c.Bool("ignore-order"))
+
+	var outData []byte
+	switch c.String("format") {
+	case "text":
+		outData = nbt2json.Diff2Text(diffs)
+	case "unified":
+		outData = nbt2json.Diff2Unified(diffs, c.Args().Get(0), c.Args().Get(1))
+	case "json":
+		outData, err = converter.Diff2Json(diffs)
+		if err != nil {
+			return cli.NewExitError(err, 2)
+		}
+	default:
+		return cli.NewExitError("--format must be text, json or unified", 2)
+	}
+
+	var out io.Writer = os.Stdout
+	if c.String("out") != "-" {
+		f, err := os.Create(c.String("out"))
+		if err != nil {
+			return cli.NewExitError(err, 2)
+		}
+		defer f.Close()
+		out = f
+	}
+	_, err = out.Write(outData)
+	if err != nil {
+		return cli.NewExitError(err, 2)
+	}
+	if len(diffs) > 0 {
+		return cli.NewExitError("", 1)
+	}
+	return nil
+}
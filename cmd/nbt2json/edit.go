@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/midnightfreddie/nbt2json"
+	"github.com/urfave/cli/v2"
+)
+
+// editFlags are the flags of the commands that edit NBT files in place
+func editFlags() []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "in",
+			Value:   "-",
+			Aliases: []string{"i"},
+			Usage:   "Input `FILE` path, which is changed in place unless --out is set",
+		},
+		&cli.StringFlag{
+			Name:    "out",
+			Aliases: []string{"o"},
+			Usage:   "Output `FILE` path, or - for stdout. Defaults to the input file",
+		},
+	}, converterFlags()...)
+}
+
+// setCommand replaces the tags an NBT path selects
+func setCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "set",
+		Usage:     "Set the tags selected by an NBT path to an SNBT value, keeping the file's compression and encoding",
+		ArgsUsage: "PATH VALUE",
+		Description: "VALUE is SNBT like 20.0f, \"text\" or {id:\"minecraft:stone\"}. It must be the same tag type as the tags it\n" +
+			"   replaces, except numbers are converted to the tag's number type if they fit. Quote both in the shell.\n" +
+			"   See get for the PATH syntax. The file is written to a temporary file which then replaces it.",
+		Flags:  editFlags(),
+		Action: set,
+	}
+}
+
+// deleteCommand removes the tags an NBT path selects
+func deleteCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "delete",
+		Usage:       "Delete the tags selected by an NBT path, keeping the file's compression and encoding",
+		ArgsUsage:   "PATH",
+		Description: "See get for the PATH syntax. The file is written to a temporary file which then replaces it.",
+		Flags:       editFlags(),
+		Action:      deleteTags,
+	}
+}
+
+func set(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return cli.NewExitError("Expected PATH VALUE", 1)
+	}
+	path, err := nbt2json.ParsePath(c.Args().Get(0))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	values, err := nbt2json.Snbt2Tags([]byte(c.Args().Get(1)))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if len(values) != 1 {
+		return cli.NewExitError("VALUE must be one SNBT value", 1)
+	}
+	return editFile(c, "Set", func(tags []nbt2json.NamedTag) ([]nbt2json.NamedTag, int, error) {
+		count, err := path.Set(tags, values[0].Value)
+		return tags, count, noMatchError(c, count, err)
+	})
+}
+
+func deleteTags(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Expected PATH", 1)
+	}
+	path, err := nbt2json.ParsePath(c.Args().Get(0))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return editFile(c, "Deleted", func(tags []nbt2json.NamedTag) ([]nbt2json.NamedTag, int, error) {
+		count, err := path.Delete(tags)
+		return tags, count, noMatchError(c, count, err)
+	})
+}
+
+// noMatchError returns err, or an error if the command's path found no tags
+func noMatchError(c *cli.Context, count int, err error) error {
+	if err == nil && count == 0 {
+		return fmt.Errorf("Path %s found no tags, so nothing was changed", c.Args().Get(0))
+	}
+	return err
+}
+
+// editFile reads the input NBT, edits it, and writes it back with the same encoding and compression, including the
+// gzip header. edit returns the edited tree and how many tags it changed.
+func editFile(c *cli.Context, verb string, edit func(tags []nbt2json.NamedTag) ([]nbt2json.NamedTag, int, error)) error {
+	converter, err := newConverter(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	inFile, outFile := c.String("in"), c.String("out")
+	if outFile == "" {
+		outFile = inFile
+	}
+
+	var in io.Reader = os.Stdin
+	if inFile != "-" {
+		f, err := os.Open(inFile)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		defer f.Close()
+		in = f
+	}
+	inData, err := ioutil.ReadAll(in)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	// Decompress here rather than in nbtReader to keep the compression and gzip header for writing the file back
+	r, compression, err := nbt2json.NewDecompressReader(bytes.NewReader(inData))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	var gzipHeader *gzip.Header
+	if zr, ok := r.(*gzip.Reader); ok {
+		gzipHeader = &zr.Header
+	}
+	r, err = nbtReader(c, converter, r, 0)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	nbtData, err := ioutil.ReadAll(r)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	var count int
+	outData, err := converter.EditNBT(nbtData, func(tags []nbt2json.NamedTag) ([]nbt2json.NamedTag, error) {
+		tags, count, err = edit(tags)
+		return tags, err
+	})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	var compressed bytes.Buffer
+	w, err := nbt2json.NewHeaderCompressWriter(&compressed, compression, gzipHeader)
+	if err == nil {
+		_, err = w.Write(outData)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	outData = compressed.Bytes()
+
+	if outFile == "-" {
+		_, err = os.Stdout.Write(outData)
+	} else {
+		err = writeFileAtomic(outFile, outData)
+	}
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Fprintf(os.Stderr, "%s %d tags\n", verb, count)
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to name and renames it over name, so name is never left
+// half-written. An existing file's permissions are kept.
+func writeFileAtomic(name string, data []byte) error {
+	perm := os.FileMode(0644)
+	if fi, err := os.Stat(name); err == nil {
+		perm = fi.Mode().Perm()
+	}
+	f, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
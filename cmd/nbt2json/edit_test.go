@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/midnightfreddie/nbt2json"
+)
+
+// TestSetKeepsGzipHeader checks set writes the file back with the same gzip header
+func TestSetKeepsGzipHeader(t *testing.T) {
+	nbtData, err := nbt2json.Snbt2Nbt([]byte("{Health:10f}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Name, zw.Comment, zw.ModTime, zw.OS = "level.dat", "saved", time.Unix(1600000000, 0), 3
+	zw.Write(nbtData)
+	zw.Close()
+	// the header is 10 bytes, then the zero-terminated name and comment
+	headerLen := 10 + len("level.dat") + 1 + len("saved") + 1
+	file := filepath.Join(t.TempDir(), "level.dat")
+	if err := ioutil.WriteFile(file, compressed.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := run(t, "set", "-i", file, "Health", "20f"); err != nil {
+		t.Fatal("Error setting Health:", err.Error())
+	}
+	outData, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(outData[:headerLen], compressed.Bytes()[:headerLen]) {
+		t.Errorf("gzip header expected % x, got % x", compressed.Bytes()[:headerLen], outData[:headerLen])
+	}
+	tags, err := nbt2json.ReadNBT(bytes.NewReader(outData))
+	expected, _ := nbt2json.Snbt2Tags([]byte("{Health:20f}"))
+	if err != nil || !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Set expected %v, got %v, %v", expected, tags, err)
+	}
+}
+
+// TestSetAndDelete checks set and delete replace the file only when the edit succeeds, keeping its permissions and
+// leaving no temporary files
+func TestSetAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "test.nbt")
+	writeNbt(t, file, "{a:1b,b:2b}")
+	if err := os.Chmod(file, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// checkFile checks the file has the NBT for snbt, its permissions and no temporary file beside it
+	checkFile := func(when, snbt string) {
+		t.Helper()
+		expected, _ := nbt2json.Snbt2Nbt([]byte(snbt))
+		nbtData, err := ioutil.ReadFile(file)
+		if err != nil || !bytes.Equal(nbtData, expected) {
+			t.Errorf("%s expected % x, got % x, %v", when, expected, nbtData, err)
+		}
+		if fi, err := os.Stat(file); err != nil || fi.Mode().Perm() != 0600 {
+			t.Errorf("%s expected permissions 0600, got %v", when, fi.Mode())
+		}
+		if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+			t.Errorf("%s expected no temporary files, got %d files", when, len(files))
+		}
+	}
+
+	_, stderr, err := run(t, "delete", "-i", file, "b")
+	if err != nil || stderr != "Deleted 1 tags\n" {
+		t.Errorf("Deleting b expected 1 tag, got %q, %v", stderr, err)
+	}
+	checkFile("After delete", "{a:1b}")
+	_, stderr, err = run(t, "set", "-i", file, "a", "5")
+	if err != nil || stderr != "Set 1 tags\n" {
+		t.Errorf("Setting a expected 1 tag, got %q, %v", stderr, err)
+	}
+	checkFile("After set", "{a:5b}")
+
+	// NOTE: Tested commands should throw error to pass
+	failures := [][]string{
+		{"set", "-i", file, "c", "1b"},
+		{"set", "-i", file, "a", `"x"`},
+		{"set", "-i", file, "a", "300"},
+		{"delete", "-i", file, "c"},
+	}
+	for _, args := range failures {
+		_, _, err = run(t, args...)
+		if err == nil {
+			t.Errorf("%s failed to throw error", strings.Join(args, " "))
+		}
+		checkFile(strings.Join(args, " "), "{a:5b}")
+	}
+
+	// --out leaves the input as it was
+	out := filepath.Join(t.TempDir(), "out.nbt")
+	if _, _, err = run(t, "set", "-i", file, "-o", out, "a", "6"); err != nil {
+		t.Error("Error setting a to --out:", err.Error())
+	}
+	checkFile("After set to --out", "{a:5b}")
+	nbtData, _ := ioutil.ReadFile(out)
+	if expected, _ := nbt2json.Snbt2Nbt([]byte("{a:6b}")); !bytes.Equal(nbtData, expected) {
+		t.Errorf("--out expected % x, got % x", expected, nbtData)
+	}
+}
+
+// TestWriteFileAtomic checks a failed write leaves no temporary file behind
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "test.nbt")
+	if err := writeFileAtomic(name, []byte{1, 2, 3}); err != nil {
+		t.Fatal("Error writing file:", err.Error())
+	}
+	if data, err := ioutil.ReadFile(name); err != nil || !bytes.Equal(data, []byte{1, 2, 3}) {
+		t.Errorf("File expected 01 02 03, got % x, %v", data, err)
+	}
+	// NOTE: Tested function should throw error to pass
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, "sub"), []byte{1}); err == nil {
+		t.Error("Writing over a directory failed to throw error")
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 2 {
+		t.Errorf("Failed write expected to leave no temporary file, got %d files", len(files))
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// editorCommand opens an NBT file as JSON or YAML in a text editor and saves the edited document back as NBT
+func editorCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "edit",
+		Usage:     "Edit an NBT file as JSON or YAML in $EDITOR, keeping the file's compression, encoding and level.dat header",
+		ArgsUsage: "FILE",
+		Description: "The editor is $VISUAL or $EDITOR, or vi (notepad on Windows) if neither is set. The file is saved when the\n" +
+			"   editor exits. If the edited document has problems, the editor opens it again with them listed at the top;\n" +
+			"   exit without changing it to give up. Exiting without changing the document or leaving it empty cancels\n" +
+			"   the edit. The file is written to a temporary file which then replaces it.",
+		Flags: append([]cli.Flag{
+			&cli.BoolFlag{
+				Name:    "yaml",
+				Aliases: []string{"yml", "y"},
+				Usage:   "Edit YAML instead of JSON",
+			},
+		}, converterFlags()...),
+		Action: editInEditor,
+	}
+}
+
+// editorHeader is put at the top of the document being edited, before any problems found saving it
+const editorHeader = "Edit the NBT below and exit the editor to save it. Lines at the top starting with %s are\n" +
+	"removed, and an empty document cancels the edit."
+
+func editInEditor(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Expected FILE", 1)
+	}
+	file := c.Args().Get(0)
+	if file == "-" {
+		return cli.NewExitError("edit needs a file, not stdin", 1)
+	}
+	converter, err := newConverter(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	inData, err := ioutil.ReadFile(file)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	// The options are set here rather than taken from c, whose lineage has the app's --skip, --snbt and --verify flags.
+	// Skipping bytes would drop them from the file, and SNBT would lose the names, compression and header.
+	// decode records the compression and header in the document, and sets the encoding for --endian auto.
+	var original bytes.Buffer
+	err = decodeNbtWith(c, converter, decodeOptions{yaml: c.Bool("yaml")}, bytes.NewReader(inData), &original)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	ext, commentPrefix := ".json", "//"
+	if c.Bool("yaml") {
+		ext, commentPrefix = ".yaml", "#"
+	}
+	tmp, err := ioutil.TempFile("", "nbt2json-*"+ext)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	tmp.Close()
+	keepTmp := false
+	defer func() {
+		if !keepTmp {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	document := original.Bytes()
+	var problems error
+	for {
+		header := commentLines(commentPrefix, fmt.Sprintf(editorHeader, commentPrefix))
+		if problems != nil {
+			header += commentLines(commentPrefix, "\nThe edit couldn't be saved:\n"+problems.Error())
+		}
+		err = ioutil.WriteFile(tmp.Name(), append([]byte(header+"\n"), document...), 0600)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		err = runEditor(tmp.Name())
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		edited, err := ioutil.ReadFile(tmp.Name())
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		edited = stripComments(edited, commentPrefix)
+		if len(bytes.TrimSpace(edited)) == 0 || bytes.Equal(bytes.TrimSpace(edited), bytes.TrimSpace(original.Bytes())) {
+			fmt.Fprintln(os.Stderr, "Edit cancelled, no changes made")
+			return nil
+		}
+		if problems != nil && bytes.Equal(bytes.TrimSpace(edited), bytes.TrimSpace(document)) {
+			keepTmp = true
+			return cli.NewExitError(fmt.Sprintf("Edit cancelled, no valid changes were saved. Your edits are in %s", tmp.Name()), 1)
+		}
+		document = edited
+		var nbtOut bytes.Buffer
+		problems = encodeNbtWith(converter, encodeOptions{yaml: c.Bool("yaml")}, bytes.NewReader(document), &nbtOut)
+		if problems != nil {
+			continue
+		}
+		err = writeFileAtomic(file, nbtOut.Bytes())
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		fmt.Fprintf(os.Stderr, "Saved %s\n", file)
+		return nil
+	}
+}
+
+// runEditor opens name in $VISUAL, $EDITOR or the system's default editor and waits for it to exit. The variables can
+// include arguments, like "code --wait".
+func runEditor(name string) error {
+	editor := strings.TrimSpace(os.Getenv("VISUAL"))
+	if editor == "" {
+		editor = strings.TrimSpace(os.Getenv("EDITOR"))
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], name)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("Running editor %s: %s", editor, err)
+	}
+	return nil
+}
+
+// commentLines returns each line of s as a comment starting with prefix
+func commentLines(prefix, s string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(s, "\n") {
+		b.WriteString(strings.TrimRight(prefix+" "+line, " ") + "\n")
+	}
+	return b.String()
+}
+
+// stripComments removes the comment lines starting with prefix and the blank line after them from the top of b
+func stripComments(b []byte, prefix string) []byte {
+	for {
+		line := b
+		end := bytes.IndexByte(b, '\n')
+		if end >= 0 {
+			line = b[:end+1]
+		}
+		if !bytes.HasPrefix(bytes.TrimSpace(line), []byte(prefix)) {
+			break
+		}
+		b = b[len(line):]
+	}
+	return bytes.TrimPrefix(bytes.TrimPrefix(b, []byte("\r")), []byte("\n"))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/midnightfreddie/nbt2json"
+)
+
+// fakeEditor sets $EDITOR to a shell script running script, with the file to edit as $1
+func fakeEditor(t *testing.T, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("The fake editor is a shell script")
+	}
+	name := filepath.Join(t.TempDir(), "editor.sh")
+	if err := ioutil.WriteFile(name, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", name)
+}
+
+// setValue is a fake editor script changing the JSON value 1 to 2
+const setValue = `sed 's/"value": 1$/"value": 2/' "$1" > "$1.new" && mv "$1.new" "$1"`
+
+// TestEditIgnoresAppFlags checks edit doesn't use the app's --skip and --snbt flags, which would drop bytes from the
+// file or lose its names
+func TestEditIgnoresAppFlags(t *testing.T) {
+	fakeEditor(t, setValue)
+	expected, err := nbt2json.Snbt2Nbt([]byte("{a:2b}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, flag := range [][]string{{"--skip", "3"}, {"--snbt"}, {"--verify"}} {
+		file := filepath.Join(t.TempDir(), "test.nbt")
+		writeNbt(t, file, "{a:1b}")
+		if _, _, err := run(t, append(flag, "edit", file)...); err != nil {
+			t.Errorf("Error editing with %s: %s", flag[0], err.Error())
+			continue
+		}
+		outData, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(outData, expected) {
+			t.Errorf("Editing with %s expected % x, got % x", flag[0], expected, outData)
+		}
+	}
+}
+
+// TestEditReopens checks the editor opens again listing the problems when the edited document can't be saved, and
+// that leaving the document unchanged cancels the edit
+func TestEditReopens(t *testing.T) {
+	tests := []struct {
+		script, expected, stderr, err string
+	}{
+		// no changes
+		{"true", "{a:1b}", "Edit cancelled, no changes made\n", ""},
+		// a bad value, then fixed once the problems are listed
+		{`if grep -q "couldn't be saved" "$1"; then
+	sed 's/"value": "x"$/"value": 2/' "$1" > "$1.new" && mv "$1.new" "$1"
+else
+	sed 's/"value": 1$/"value": "x"/' "$1" > "$1.new" && mv "$1.new" "$1"
+fi`, "{a:2b}", "Saved ", ""},
+		// a bad value, then left as it is
+		{`grep -q "couldn't be saved" "$1" || { sed 's/"value": 1$/"value": "x"/' "$1" > "$1.new" && mv "$1.new" "$1"; }`,
+			"{a:1b}", "", "Edit cancelled, no valid changes were saved. Your edits are in "},
+	}
+	for _, test := range tests {
+		fakeEditor(t, test.script)
+		file := filepath.Join(t.TempDir(), "test.nbt")
+		writeNbt(t, file, "{a:1b}")
+		_, stderr, err := run(t, "edit", file)
+		if test.err == "" && err != nil {
+			t.Errorf("Error editing: %s", err.Error())
+		} else if test.err != "" && (err == nil || !strings.HasPrefix(err.Error(), test.err)) {
+			t.Errorf("Editing expected error %q, got %v", test.err, err)
+		}
+		if err != nil && test.err != "" {
+			// the kept edits
+			os.Remove(strings.TrimPrefix(err.Error(), test.err))
+		}
+		if !strings.HasPrefix(stderr, test.stderr) {
+			t.Errorf("Editing expected %q on stderr, got %q", test.stderr, stderr)
+		}
+		expected, _ := nbt2json.Snbt2Nbt([]byte(test.expected))
+		if nbtData, _ := ioutil.ReadFile(file); !bytes.Equal(nbtData, expected) {
+			t.Errorf("Edited file expected % x, got % x", expected, nbtData)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/midnightfreddie/nbt2json"
+	"github.com/urfave/cli/v2"
+)
+
+// encodeCommand converts JSON, YAML or SNBT to NBT
+func encodeCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "encode",
+		Usage: "Convert JSON, YAML or SNBT to NBT",
+		Description: "JSON and YAML are checked before any NBT is written, and every problem is listed with its path.\n" +
+			"   Use the same encoding flags the input was decoded with. The NBT is compressed the way the JSON or YAML\n" +
+			"   records it was when decoded, unless --compression or --gzip is given; use --compression none for\n" +
+			"   uncompressed NBT.\n\n" +
+			"   If --in is a directory or a quoted glob pattern like 'json/*.json', every file in it is converted to the\n" +
+			"   same path in the --out directory with its .json, .yaml, .yml or .snbt extension removed, or .nbt added if\n" +
+			"   it has none. Directories are searched recursively. Files that fail are listed and the rest are still\n" +
+			"   converted.",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "in",
+				Value:   "-",
+				Aliases: []string{"i"},
+				Usage:   "Input `FILE` path, or a directory or glob pattern to convert several files",
+			},
+			&cli.StringFlag{
+				Name:    "out",
+				Value:   "-",
+				Aliases: []string{"o"},
+				Usage:   "Output `FILE` path, or the directory for the converted files when --in names several",
+			},
+			&cli.BoolFlag{
+				Name:    "yaml",
+				Aliases: []string{"yml", "y"},
+				Usage:   "Read YAML instead of JSON",
+			},
+			&cli.BoolFlag{
+				Name:  "snbt",
+				Usage: "Read SNBT (stringified NBT as in Java Edition commands) instead of JSON",
+			},
+		}, append(append(compressionFlags(), batchFlags()...), converterFlags()...)...),
+		Action: encode,
+	}
+}
+
+func encode(c *cli.Context) error {
+	if c.Bool("yaml") && c.Bool("snbt") {
+		return cli.NewExitError("--yaml and --snbt can't be used together", 1)
+	}
+	if c.String("endian") == "auto" {
+		return cli.NewExitError("--endian auto only works when reading NBT", 1)
+	}
+	if c.Bool("verify") {
+		return cli.NewExitError("--verify checks NBT to JSON conversion and can't be used with encode", 1)
+	}
+	return convertFiles(c, encodeNbt, func(name string) string {
+		switch filepath.Ext(name) {
+		case ".json", ".yaml", ".yml", ".snbt":
+			return strings.TrimSuffix(name, filepath.Ext(name))
+		}
+		return name + ".nbt"
+	})
+}
+
+// encodeOptions are the flags that change how encodeNbt converts to NBT, besides the converter's settings
+type encodeOptions struct {
+	yaml, snbt bool
+	// compressed is whether --compression or --gzip set the output's compression, overriding the document's
+	compressed bool
+}
+
+// encodeNbt converts the JSON, YAML or SNBT in in to NBT as the flags set
+func encodeNbt(c *cli.Context, converter *nbt2json.Converter, in io.Reader, out io.Writer) error {
+	options := encodeOptions{
+		yaml:       c.Bool("yaml"),
+		snbt:       c.Bool("snbt"),
+		compressed: c.IsSet("compression") || c.Bool("gzip"),
+	}
+	return encodeNbtWith(converter, options, in, out)
+}
+
+// encodeNbtWith converts the JSON, YAML or SNBT in in to NBT as options set
+func encodeNbtWith(converter *nbt2json.Converter, options encodeOptions, in io.Reader, out io.Writer) error {
+	if options.compressed {
+		// the flags override the compression the document records, and output compresses with them
+		converter.UseUncompressedNbt()
+	}
+	inData, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	if options.snbt {
+		outData, err := converter.Snbt2Nbt(inData)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(outData)
+		return err
+	}
+	// The converter checks the whole document first, so every problem is reported and no NBT is written if there are any
+	if options.yaml {
+		outData, err := converter.Yaml2Nbt(inData)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(outData)
+		return err
+	}
+	return converter.NewEncoder(out).Encode(bytes.NewReader(inData))
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/midnightfreddie/nbt2json"
+	"github.com/urfave/cli/v2"
+)
+
+// getCommand prints the tags an NBT path selects
+func getCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "get",
+		Usage:     "Print the tags selected by an NBT path like Data.Player.Inventory[{id:\"minecraft:diamond\"}].Count",
+		ArgsUsage: "PATH",
+		Description: "PATH starts inside the top-level compound. Use Name.Child for compound children, * for every child,\n" +
+			"   [3] or [-1] for list and array items, [] for every item, [{id:\"minecraft:stone\"}] for list items containing\n" +
+			"   an SNBT compound, and Name{OnGround:1b} to select a compound only if it contains an SNBT compound.\n" +
+			"   Quote the path in the shell. Each tag found is named with its full path.",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "in",
+				Value:   "-",
+				Aliases: []string{"i"},
+				Usage:   "Input `FILE` path",
+			},
+			&cli.StringFlag{
+				Name:    "out",
+				Value:   "-",
+				Aliases: []string{"o"},
+				Usage:   "Output `FILE` path",
+			},
+			&cli.StringFlag{
+				Name:    "comment",
+				Aliases: []string{"c"},
+				Usage:   "Add `COMMENT` to json or yaml output, use quotes if contains white space",
+			},
+			&cli.BoolFlag{
+				Name:    "yaml",
+				Aliases: []string{"yml", "y"},
+				Usage:   "Use YAML instead of JSON",
+			},
+			&cli.BoolFlag{
+				Name:  "snbt",
+				Usage: "Use SNBT instead of JSON, one tag per line",
+			},
+			&cli.IntFlag{
+				Name:  "skip",
+				Value: 0,
+				Usage: "Skip `NUM` bytes of NBT input",
+			},
+		}, converterFlags()...),
+		Action: get,
+	}
+}
+
+func get(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Expected one PATH", 1)
+	}
+	if c.Bool("yaml") && c.Bool("snbt") {
+		return cli.NewExitError("--yaml and --snbt can't be used together", 1)
+	}
+	converter, err := newConverter(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	var in io.Reader = os.Stdin
+	if c.String("in") != "-" {
+		f, err := os.Open(c.String("in"))
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		defer f.Close()
+		in = f
+	}
+	in, err = nbtReader(c, converter, in, c.Int("skip"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	tags, err := converter.ReadNBT(in)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	found, err := nbt2json.Query(tags, c.Args().Get(0))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if len(found) == 0 {
+		return cli.NewExitError(fmt.Sprintf("Path %s found no tags", c.Args().Get(0)), 1)
+	}
+
+	var out io.Writer = os.Stdout
+	if c.String("out") != "-" {
+		f, err := os.Create(c.String("out"))
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		defer f.Close()
+		out = f
+	}
+	var outData []byte
+	if c.Bool("snbt") {
+		outData = nbt2json.Tags2Snbt(found)
+	} else if c.Bool("yaml") {
+		outData, err = converter.Tags2Yaml(found, c.String("comment"))
+	} else {
+		outData, err = converter.Tags2Json(found, c.String("comment"))
+	}
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	_, err = out.Write(outData)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
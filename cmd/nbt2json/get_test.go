@@ -0,0 +1,39 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGet checks get prints the tags a path selects
+func TestGet(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.nbt")
+	writeNbt(t, file, `{a:1b,l:[1,2],c:{s:"x"}}`)
+	gets := []struct {
+		path, expected string
+	}{
+		{"l[]", "1\n2\n"},
+		{"c.s", "\"x\"\n"},
+		{"l[-1]", "2\n"},
+	}
+	for _, test := range gets {
+		stdout, _, err := run(t, "get", "--snbt", "-i", file, test.path)
+		if err != nil || stdout != test.expected {
+			t.Errorf("Getting %s expected %q, got %q, %v", test.path, test.expected, stdout, err)
+		}
+	}
+	stdout, _, err := run(t, "get", "-i", file, "c")
+	if err != nil || !strings.Contains(stdout, `"name": "c"`) || !strings.Contains(stdout, `"value": "x"`) {
+		t.Errorf("Getting c expected JSON, got %q, %v", stdout, err)
+	}
+
+	// NOTE: Tested commands should throw error to pass
+	_, _, err = run(t, "get", "-i", file, "zz")
+	if err == nil || err.Error() != "Path zz found no tags" {
+		t.Errorf("Getting a missing path expected an error, got %v", err)
+	}
+	if _, _, err = run(t, "get", "-i", file, "l[x"); err == nil {
+		t.Error("Getting a bad path failed to throw error")
+	}
+}
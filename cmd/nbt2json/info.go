@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/midnightfreddie/nbt2json"
+	"github.com/urfave/cli/v2"
+)
+
+// tagTypeNames are the names of the tag types for info, indexed by tag type
+var tagTypeNames = []string{"End", "Byte", "Short", "Int", "Long", "Float", "Double", "ByteArray", "String", "List", "Compound", "IntArray", "LongArray"}
+
+// infoCommand summarizes an NBT file
+func infoCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "info",
+		Usage:       "Show an NBT file's compression, encoding, level.dat header, top-level tags and how many tags of each type it has",
+		Description: "Use --endian auto to detect and show the byte order.",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "in",
+				Value:   "-",
+				Aliases: []string{"i"},
+				Usage:   "Input `FILE` path",
+			},
+			&cli.IntFlag{
+				Name:  "skip",
+				Value: 0,
+				Usage: "Skip `NUM` bytes of NBT input",
+			},
+		}, converterFlags()...),
+		Action: info,
+	}
+}
+
+// countingReader counts the bytes read from r
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// tagStats counts tags by type and finds how deeply they nest
+type tagStats struct {
+	count [13]int
+	depth int
+}
+
+func (s *tagStats) add(tag nbt2json.Tag, depth int) {
+	s.count[tag.TagType()]++
+	if depth > s.depth {
+		s.depth = depth
+	}
+	switch tag := tag.(type) {
+	case nbt2json.List:
+		for _, item := range tag.Items {
+			s.add(item, depth+1)
+		}
+	case nbt2json.Compound:
+		for _, child := range tag {
+			s.add(child.Value, depth+1)
+		}
+	}
+}
+
+// plural adds an s to word unless n is 1
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+func info(c *cli.Context) error {
+	converter, err := newConverter(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	f, err := openInput(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer f.Close()
+	counter := &countingReader{r: f}
+	br := bufio.NewReader(counter)
+	magic, _ := br.Peek(8)
+	compression := nbt2json.DetectCompression(magic)
+	in, err := nbtReader(c, converter, br, c.Int("skip"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	inData, err := ioutil.ReadAll(in)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	// The JSON document has the level.dat header, which ReadNBT skips
+	jsonData, err := converter.Nbt2Json(inData, "")
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	var document nbt2json.NbtJson
+	err = json.Unmarshal(jsonData, &document)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	tags, err := converter.Json2Tags(jsonData)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	if compression != nbt2json.NoCompression {
+		fmt.Printf("Compression: %s, %d bytes (%d uncompressed)\n", compression, counter.n, len(inData))
+	} else {
+		fmt.Printf("Compression: none, %d bytes\n", len(inData))
+	}
+	fmt.Printf("Encoding:    %s\n", converter.Encoding())
+	if document.Header != nil {
+		fmt.Printf("Header:      level.dat storage version %d\n", document.Header.StorageVersion)
+	} else {
+		fmt.Printf("Header:      none\n")
+	}
+	var stats tagStats
+	for _, tag := range tags {
+		if tag.Value.TagType() == 0 {
+			continue
+		}
+		stats.add(tag.Value, 1)
+		summary := fmt.Sprintf("%s %q", tagTypeNames[tag.Value.TagType()], tag.Name)
+		switch value := tag.Value.(type) {
+		case nbt2json.Compound:
+			summary += fmt.Sprintf(" with %d %s", len(value), plural(len(value), "tag"))
+		case nbt2json.List:
+			summary += fmt.Sprintf(" of %d %s %s", len(value.Items), tagTypeNames[value.TagListType], plural(len(value.Items), "tag"))
+		}
+		fmt.Printf("Top level:   %s\n", summary)
+	}
+	total := 0
+	var types []string
+	for tagType, n := range stats.count {
+		total += n
+		if n > 0 {
+			types = append(types, fmt.Sprintf("%s %d", tagTypeNames[tagType], n))
+		}
+	}
+	fmt.Printf("Tags:        %d, nested %d deep\n", total, stats.depth)
+	fmt.Printf("Tag types:   %s\n", strings.Join(types, ", "))
+	return nil
+}
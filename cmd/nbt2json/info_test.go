@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/midnightfreddie/nbt2json"
+)
+
+// TestInfo checks info summarizes a file's compression, encoding, header and tags
+func TestInfo(t *testing.T) {
+	dir := t.TempDir()
+	nbtData, err := nbt2json.Snbt2Nbt([]byte(`{a:1b,l:[1,2],c:{s:"x"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write(nbtData)
+	zw.Close()
+	gzipFile := filepath.Join(dir, "test.nbt")
+	if err := ioutil.WriteFile(gzipFile, compressed.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, err := run(t, "info", "--endian", "auto", "-i", gzipFile)
+	if err != nil {
+		t.Fatal("Error getting info:", err.Error())
+	}
+	expected := "Compression: gzip, " + strconv.Itoa(compressed.Len()) + " bytes (38 uncompressed)\n" +
+		"Encoding:    Bedrock Edition (little endian)\n" +
+		"Header:      none\n" +
+		"Top level:   Compound \"\" with 3 tags\n" +
+		"Tags:        7, nested 3 deep\n" +
+		"Tag types:   Byte 1, Int 2, String 1, List 1, Compound 2\n"
+	if stdout != expected {
+		t.Errorf("Info expected\n%s, got\n%s", expected, stdout)
+	}
+	if !strings.HasPrefix(stderr, "Detected Bedrock Edition (little endian) NBT") {
+		t.Errorf("Expected the detected encoding on stderr, got %q", stderr)
+	}
+
+	// a level.dat header
+	levelDat, err := nbt2json.Json2Nbt([]byte(`{"header":{"storageVersion":9},"nbt":[{"tagType":10,"name":"","value":[]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	levelDatFile := filepath.Join(dir, "level.dat")
+	if err := ioutil.WriteFile(levelDatFile, levelDat, 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, err = run(t, "info", "-i", levelDatFile)
+	if err != nil || !strings.Contains(stdout, "Header:      level.dat storage version 9\n") || !strings.Contains(stdout, "Compression: none, 12 bytes\n") {
+		t.Errorf("Expected the level.dat header, got %q, %v", stdout, err)
+	}
+
+	// NOTE: Tested command should throw error to pass
+	if _, _, err := run(t, "info", "-i", filepath.Join(dir, "missing.nbt")); err == nil {
+		t.Error("Info on a missing file failed to throw error")
+	}
+}
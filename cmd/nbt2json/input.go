@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/midnightfreddie/nbt2json"
+	"github.com/urfave/cli/v2"
+)
+
+// detectSize is how much NBT --endian auto looks at
+const detectSize = 64 * 1024
+
+// converterFlags are the flags newConverter reads, for subcommands
+func converterFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "big-endian",
+			Aliases: []string{"java", "b"},
+			Usage:   "Use for Minecraft Java Edition (like most other NBT tools)",
+		},
+		&cli.BoolFlag{
+			Name:    "network",
+			Aliases: []string{"n"},
+			Usage:   "Use for Minecraft Bedrock Edition network protocol NBT (varint-encoded)",
+		},
+		&cli.StringFlag{
+			Name:  "endian",
+			Usage: "Byte `ORDER` of the NBT: big (Java), little (Bedrock), or auto to detect it when reading NBT",
+		},
+		&cli.BoolFlag{
+			Name:    "long-as-string",
+			Aliases: []string{"l"},
+			Usage:   "If set, nbt long values will be a string instead of uint32 pair",
+		},
+		&cli.BoolFlag{
+			Name:  "raw-strings",
+			Usage: "Keep the raw bytes of Java Edition names and strings that aren't valid modified UTF-8 instead of failing",
+		},
+		&cli.BoolFlag{
+			Name:  "strict",
+			Usage: "When reading JSON or YAML, fail on fractions in integer tags and on fields nbt2json doesn't use",
+		},
+	}
+}
+
+// newConverter returns a converter set up by the encoding, long, string and strict flags
+func newConverter(c *cli.Context) (*nbt2json.Converter, error) {
+	converter := nbt2json.NewConverter()
+	if c.Bool("big-endian") && c.Bool("network") {
+		return nil, fmt.Errorf("--big-endian and --network can't be used together")
+	}
+	if c.Bool("big-endian") {
+		converter.UseJavaEncoding()
+	}
+	if c.Bool("network") {
+		converter.UseBedrockNetworkEncoding()
+	}
+	if c.String("endian") != "" && (c.Bool("big-endian") || c.Bool("network")) {
+		return nil, fmt.Errorf("--endian can't be used with --big-endian or --network")
+	}
+	switch c.String("endian") {
+	case "", "auto":
+	case "big", "java":
+		converter.UseJavaEncoding()
+	case "little", "bedrock":
+		converter.UseBedrockEncoding()
+	default:
+		return nil, fmt.Errorf("--endian must be big, little or auto")
+	}
+	if c.Bool("long-as-string") {
+		converter.UseLongAsString()
+	}
+	if c.Bool("raw-strings") {
+		converter.UseRawStringFallback()
+	}
+	if c.Bool("strict") {
+		converter.UseStrictJson()
+	}
+	return converter, nil
+}
+
+// openInput opens the --in file, or stdin for -
+func openInput(c *cli.Context) (io.ReadCloser, error) {
+	if c.String("in") == "-" {
+		return ioutil.NopCloser(os.Stdin), nil
+	}
+	return os.Open(c.String("in"))
+}
+
+// compressionFlags are the flags of commands that write NBT or text which can be compressed
+func compressionFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "compression",
+			Usage: "Compress output with `TYPE`: none, gzip, zlib or lz4 (lz4-java's framing, as Minecraft uses)",
+		},
+		&cli.BoolFlag{
+			Name:    "gzip",
+			Aliases: []string{"z"},
+			Usage:   "Compress output with gzip, the same as --compression gzip",
+		},
+	}
+}
+
+// outputCompression returns the compression set by --compression or --gzip
+func outputCompression(c *cli.Context) (nbt2json.Compression, error) {
+	if c.Bool("gzip") {
+		if c.String("compression") != "" && c.String("compression") != "gzip" {
+			return 0, fmt.Errorf("--gzip and --compression %s can't be used together", c.String("compression"))
+		}
+		return nbt2json.GzipCompression, nil
+	}
+	if c.String("compression") == "" {
+		return nbt2json.NoCompression, nil
+	}
+	return nbt2json.ParseCompression(c.String("compression"))
+}
+
+// output writes to a file, or stdout for -, compressed with compression. The file is created by the first write, so
+// it isn't created if a conversion fails before writing anything. Close finishes the compressed data and closes the
+// file.
+type output struct {
+	path        string
+	compression nbt2json.Compression
+	w           io.Writer
+	cw          io.WriteCloser
+	f           *os.File
+}
+
+func (o *output) open() error {
+	if o.w != nil {
+		return nil
+	}
+	o.w = os.Stdout
+	if o.path != "-" {
+		f, err := os.Create(o.path)
+		if err != nil {
+			return err
+		}
+		o.w, o.f = f, f
+	}
+	if o.compression != nbt2json.NoCompression {
+		cw, err := nbt2json.NewCompressWriter(o.w, o.compression)
+		if err != nil {
+			return err
+		}
+		o.w, o.cw = cw, cw
+	}
+	return nil
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	if err := o.open(); err != nil {
+		return 0, err
+	}
+	return o.w.Write(p)
+}
+
+func (o *output) Close() error {
+	if err := o.open(); err != nil {
+		return err
+	}
+	if o.cw != nil {
+		if err := o.cw.Close(); err != nil {
+			return err
+		}
+	}
+	if o.f != nil {
+		return o.f.Close()
+	}
+	return nil
+}
+
+// remove closes and deletes a partly written file
+func (o *output) remove() {
+	if o.f != nil {
+		o.f.Close()
+		os.Remove(o.path)
+	}
+}
+
+// nbtReader returns a reader of the uncompressed NBT in in. It decompresses gzip, zlib and LZ4 input, skips skipBytes,
+// and with --endian auto detects the byte order and sets the converter to it. The detection is reported on stderr,
+// except when converting several files, where convertBatch reports it with each file.
+func nbtReader(c *cli.Context, converter *nbt2json.Converter, in io.Reader, skipBytes int) (io.Reader, error) {
+	in, _, err := nbt2json.NewDecompressReader(in)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.CopyN(ioutil.Discard, in, int64(skipBytes))
+	if err != nil {
+		return nil, err
+	}
+	if c.String("endian") == "auto" {
+		br := bufio.NewReaderSize(in, detectSize)
+		in = br
+		sample, _ := br.Peek(detectSize)
+		encoding, confidence := nbt2json.DetectEncoding(sample)
+		if encoding == nbt2json.UnknownEncoding {
+			return nil, fmt.Errorf("Could not detect the byte order; the input doesn't look like NBT")
+		}
+		converter.UseEncoding(encoding)
+		if !isBatch(c.String("in")) {
+			fmt.Fprintf(os.Stderr, "Detected %s NBT with %.0f%% confidence\n", encoding, confidence*100)
+		}
+	}
+	return in, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+// run runs the app with args and returns what it wrote to stdout and stderr, and the error it exited with
+func run(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+	stdout, stderr := os.Stdout, os.Stderr
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+	var outputs [2]bytes.Buffer
+	var writers [2]*os.File
+	done := make(chan struct{})
+	for i := range writers {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		writers[i] = w
+		go func(i int) {
+			io.Copy(&outputs[i], r)
+			r.Close()
+			done <- struct{}{}
+		}(i)
+	}
+	os.Stdout, os.Stderr = writers[0], writers[1]
+	app := newApp()
+	app.ExitErrHandler = func(*cli.Context, error) {}
+	err := app.Run(append([]string{"nbt2json"}, args...))
+	for _, w := range writers {
+		w.Close()
+		<-done
+	}
+	return outputs[0].String(), outputs[1].String(), err
+}
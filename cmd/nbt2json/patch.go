@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/midnightfreddie/nbt2json"
+	"github.com/urfave/cli/v2"
+)
+
+// patchCommand applies a JSON Patch or merge patch to an NBT file
+func patchCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "patch",
+		Usage:     "Apply a JSON Patch (RFC 6902) or merge patch (RFC 7386) file, keeping the file's compression and encoding",
+		ArgsUsage: "PATCH_FILE",
+		Description: "The patch addresses tags by name: /Data/Player/Inventory/3/id is Data.Player.Inventory[3].id.\n" +
+			"   Values keep the tag type of the tag they replace; give {\"tagType\":N,\"value\":...} to choose a type.\n" +
+			"   With --document, the patch addresses the JSON document nbt2json outputs, like /nbt/0/value/2/value.\n" +
+			"   The file is written to a temporary file which then replaces it. Use - to read the patch from stdin.",
+		Flags: append([]cli.Flag{
+			&cli.BoolFlag{
+				Name:  "merge",
+				Usage: "PATCH_FILE is a JSON merge patch",
+			},
+			&cli.BoolFlag{
+				Name:  "document",
+				Usage: "PATCH_FILE addresses the nbt2json JSON document instead of tag names",
+			},
+		}, editFlags()...),
+		Action: patch,
+	}
+}
+
+func patch(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Expected PATCH_FILE", 1)
+	}
+	merge, document := c.Bool("merge"), c.Bool("document")
+	if merge && document {
+		return cli.NewExitError("--merge and --document can't be used together", 1)
+	}
+	var patchData []byte
+	var err error
+	if c.Args().Get(0) == "-" {
+		if c.String("in") == "-" {
+			return cli.NewExitError("The patch and the NBT can't both be read from stdin", 1)
+		}
+		patchData, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		patchData, err = ioutil.ReadFile(c.Args().Get(0))
+	}
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	converter, err := newConverter(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return editFile(c, "Changed", func(tags []nbt2json.NamedTag) ([]nbt2json.NamedTag, int, error) {
+		var patched []nbt2json.NamedTag
+		var err error
+		switch {
+		case merge:
+			patched, err = converter.MergePatch(tags, patchData)
+		case document:
+			patched, err = converter.JsonPatchDocument(tags, patchData)
+		default:
+			patched, err = converter.JsonPatch(tags, patchData)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		return patched, len(nbt2json.Diff(tags, patched, false)), nil
+	})
+}
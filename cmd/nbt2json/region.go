@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/midnightfreddie/nbt2json"
+	"github.com/midnightfreddie/nbt2json/region"
+	"github.com/urfave/cli/v2"
+)
+
+// regionCommand has subcommands for the chunks in Java Edition .mca/.mcr region files
+func regionCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "region",
+		Usage: "List, extract and write chunks in Java Edition .mca/.mcr region files",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "List the chunks in a region file",
+				ArgsUsage: "REGION_FILE",
+				Action:    regionList,
+			},
+			{
+				Name:      "extract",
+				Usage:     "Convert the chunk at X Z to JSON. X and Z can be world chunk coordinates or 0-31 within the region",
+				ArgsUsage: "REGION_FILE X Z",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "out",
+						Value:   "-",
+						Aliases: []string{"o"},
+						Usage:   "Output `FILE` path",
+					},
+					&cli.StringFlag{
+						Name:    "comment",
+						Aliases: []string{"c"},
+						Usage:   "Add `COMMENT` to json or yaml output, use quotes if contains white space",
+					},
+					&cli.BoolFlag{
+						Name:    "yaml",
+						Aliases: []string{"yml", "y"},
+						Usage:   "Use YAML instead of JSON",
+					},
+					&cli.BoolFlag{
+						Name:  "snbt",
+						Usage: "Use SNBT instead of JSON",
+					},
+					&cli.BoolFlag{
+						Name:    "long-as-string",
+						Aliases: []string{"l"},
+						Usage:   "If set, nbt long values will be a string instead of uint32 pair",
+					},
+				},
+				Action: regionExtract,
+			},
+			{
+				Name:      "write",
+				Usage:     "Convert JSON to NBT and write it as the chunk at X Z, creating the region file if needed",
+				ArgsUsage: "REGION_FILE X Z",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "in",
+						Value:   "-",
+						Aliases: []string{"i"},
+						Usage:   "Input `FILE` path",
+					},
+					&cli.BoolFlag{
+						Name:    "yaml",
+						Aliases: []string{"yml", "y"},
+						Usage:   "Use YAML instead of JSON",
+					},
+					&cli.BoolFlag{
+						Name:  "snbt",
+						Usage: "Use SNBT instead of JSON",
+					},
+					&cli.StringFlag{
+						Name:  "compression",
+						Value: "zlib",
+						Usage: "Chunk compression `TYPE`: zlib, gzip, lz4 or none",
+					},
+				},
+				Action: regionWrite,
+			},
+		},
+	}
+}
+
+// regionChunkArgs returns the region file name and chunk coordinates from the command line
+func regionChunkArgs(c *cli.Context) (string, int, int, error) {
+	if c.NArg() != 3 {
+		return "", 0, 0, fmt.Errorf("expected REGION_FILE X Z, got %d arguments", c.NArg())
+	}
+	x, err := strconv.Atoi(c.Args().Get(1))
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("chunk X %q is not a number", c.Args().Get(1))
+	}
+	z, err := strconv.Atoi(c.Args().Get(2))
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("chunk Z %q is not a number", c.Args().Get(2))
+	}
+	return c.Args().Get(0), x, z, nil
+}
+
+func regionList(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Expected REGION_FILE", 1)
+	}
+	r, err := region.Open(c.Args().Get(0))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer r.Close()
+	chunks, err := r.Chunks()
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Printf("%3s %3s %8s %7s %-11s %8s %s\n", "X", "Z", "SECTOR", "SECTORS", "COMPRESSION", "BYTES", "TIMESTAMP")
+	for _, chunk := range chunks {
+		compression := chunk.Compression.String()
+		if chunk.External {
+			compression += "*"
+		}
+		fmt.Printf("%3d %3d %8d %7d %-11s %8d %s\n", chunk.X, chunk.Z, chunk.Sector, chunk.Sectors, compression, chunk.Length, chunk.Timestamp.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func regionExtract(c *cli.Context) error {
+	name, x, z, err := regionChunkArgs(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if c.Bool("yaml") && c.Bool("snbt") {
+		return cli.NewExitError("--yaml and --snbt can't be used together", 1)
+	}
+	converter := nbt2json.NewConverter()
+	converter.UseJavaEncoding()
+	if c.Bool("long-as-string") {
+		converter.UseLongAsString()
+	}
+
+	r, err := region.Open(name)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer r.Close()
+	nbtData, err := r.ReadChunk(x, z)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	var out io.Writer = os.Stdout
+	if c.String("out") != "-" {
+		f, err := os.Create(c.String("out"))
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		defer f.Close()
+		out = f
+	}
+	var outData []byte
+	if c.Bool("snbt") {
+		outData, err = converter.Nbt2Snbt(nbtData)
+	} else if c.Bool("yaml") {
+		outData, err = converter.Nbt2Yaml(nbtData, c.String("comment"))
+	} else {
+		outData, err = converter.Nbt2Json(nbtData, c.String("comment"))
+	}
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	_, err = out.Write(outData)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
+
+func regionWrite(c *cli.Context) error {
+	name, x, z, err := regionChunkArgs(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if c.Bool("yaml") && c.Bool("snbt") {
+		return cli.NewExitError("--yaml and --snbt can't be used together", 1)
+	}
+	compression, err := region.ParseCompression(c.String("compression"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	converter := nbt2json.NewConverter()
+	converter.UseJavaEncoding()
+
+	var in io.Reader = os.Stdin
+	if c.String("in") != "-" {
+		f, err := os.Open(c.String("in"))
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		defer f.Close()
+		in = f
+	}
+	var nbtData []byte
+	if c.Bool("snbt") || c.Bool("yaml") {
+		inData, err := ioutil.ReadAll(in)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		if c.Bool("snbt") {
+			nbtData, err = converter.Snbt2Nbt(inData)
+		} else {
+			nbtData, err = converter.Yaml2Nbt(inData)
+		}
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+	} else {
+		var buf bytes.Buffer
+		err = converter.NewEncoder(&buf).Encode(in)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		nbtData = buf.Bytes()
+	}
+
+	r, err := region.OpenFile(name)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	err = r.WriteChunk(x, z, nbtData, compression)
+	if err != nil {
+		r.Close()
+		return cli.NewExitError(err, 1)
+	}
+	err = r.Close()
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
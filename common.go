@@ -1,69 +1,451 @@
-package nbt2json
-
-import (
-	"encoding/binary"
-	"fmt"
-)
-
-// Version is the json document's nbt2JsonVersion:
-const Version = "0.4.0"
-
-// Nbt2JsonUrl is inserted in the json document as nbt2JsonUrl
-const Nbt2JsonUrl = "https://github.com/midnightfreddie/nbt2json"
-
-// Name is the json document's name:
-var Name = "Named Binary Tag to JSON"
-
-// Used by all converters; change with UseJavaEncoding() or UseBedrockEncoding()
-var byteOrder = binary.ByteOrder(binary.LittleEndian)
-
-// UseJavaEncoding sets the module to decode/encode from/to big endian NBT for Minecraft Java Edition
-func UseJavaEncoding() {
-	byteOrder = binary.BigEndian
-}
-
-// UseBedrockEncoding sets the module to decode/encode from/to little endian NBT for Minecraft Bedrock Edition
-func UseBedrockEncoding() {
-	byteOrder = binary.LittleEndian
-}
-
-// If longAsString is true, nbt long (int64) will be a string of the number instead of a valueLeast/valueMost uint32 pair
-var longAsString = false
-
-// UseLongAsString will make nbt long values as string numbers in the json/yaml
-func UseLongAsString() {
-	longAsString = true
-}
-
-// UseLongAsUint32Pair will make nbt long values as valueLeast/valueMost uint32 pairs in the json
-func UseLongAsUint32Pair() {
-	longAsString = false
-}
-
-// NbtParseError is when the nbt data does not match an expected pattern. Pass it message string and downstream error
-type NbtParseError struct {
-	s string
-	e error
-}
-
-func (e NbtParseError) Error() string {
-	var s string
-	if e.e != nil {
-		s = fmt.Sprintf(": %s", e.e.Error())
-	}
-	return fmt.Sprintf("Error parsing NBT: %s%s", e.s, s)
-}
-
-// JsonParseError is when the json data does not match an expected pattern. Pass it message string and downstream error
-type JsonParseError struct {
-	s string
-	e error
-}
-
-func (e JsonParseError) Error() string {
-	var s string
-	if e.e != nil {
-		s = fmt.Sprintf(": %s", e.e.Error())
-	}
-	return fmt.Sprintf("Error parsing json2nbt: %s%s", e.s, s)
-}
+package nbt2json
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Version is the json document's nbt2JsonVersion:
+const Version = "0.4.0"
+
+// Nbt2JsonUrl is inserted in the json document as nbt2JsonUrl
+const Nbt2JsonUrl = "https://github.com/midnightfreddie/nbt2json"
+
+// Name is the json document's name:
+var Name = "Named Binary Tag to JSON"
+
+// Converter holds the settings used to decode and encode NBT. Each Converter carries its own
+// settings, so differently-configured converters can be used concurrently. Don't change a
+// Converter's settings while it is converting.
+type Converter struct {
+	byteOrder    binary.ByteOrder
+	varint       bool
+	mutf8        bool
+	rawStrings   bool
+	longAsString bool
+	strictJson   bool
+	uncompressed bool
+	limits       Limits
+}
+
+// Limits bound what is accepted when decoding NBT, so corrupt or malicious data can't exhaust memory or the stack.
+// Going over a limit is a LimitError. Zero fields are unlimited.
+type Limits struct {
+	// MaxDepth is how deeply compounds and lists can be nested, counting the top-level tag
+	MaxDepth int
+	// MaxBytes is how many bytes can be read, counting a level.dat header
+	MaxBytes int64
+	// MaxArrayLength is how many items a list, byte array, int array or long array can have
+	MaxArrayLength int
+	// MaxStringLength is how many bytes a name or string can have
+	MaxStringLength int
+}
+
+// DefaultLimits are the limits of a new Converter. The depth limit is Minecraft's; the others are unlimited.
+var DefaultLimits = Limits{MaxDepth: 512}
+
+// NewConverter returns a Converter with the default settings: little endian (Bedrock), nbt long values as valueLeast/valueMost pairs, and DefaultLimits
+func NewConverter() *Converter {
+	return &Converter{
+		byteOrder: binary.LittleEndian,
+		limits:    DefaultLimits,
+	}
+}
+
+// UseJavaEncoding sets the converter to decode/encode from/to big endian NBT for Minecraft Java Edition
+func (c *Converter) UseJavaEncoding() {
+	c.byteOrder = binary.BigEndian
+	c.varint = false
+	c.mutf8 = true
+}
+
+// UseBedrockEncoding sets the converter to decode/encode from/to little endian NBT for Minecraft Bedrock Edition
+func (c *Converter) UseBedrockEncoding() {
+	c.byteOrder = binary.LittleEndian
+	c.varint = false
+	c.mutf8 = false
+}
+
+// UseBedrockNetworkEncoding sets the converter to decode/encode from/to the varint little endian NBT of the Minecraft Bedrock Edition network protocol
+func (c *Converter) UseBedrockNetworkEncoding() {
+	c.byteOrder = binary.LittleEndian
+	c.varint = true
+	c.mutf8 = false
+}
+
+// UseRawStringFallback keeps the raw bytes of Java Edition names and strings that aren't valid modified UTF-8 instead of
+// returning an error. Raw strings are written back unchanged, and in JSON they are their bytes in hex.
+func (c *Converter) UseRawStringFallback() {
+	c.rawStrings = true
+}
+
+// UseStrictStrings makes Java Edition names and strings that aren't valid modified UTF-8 an error. This is the default.
+func (c *Converter) UseStrictStrings() {
+	c.rawStrings = false
+}
+
+// UseStrictJson makes fractions in integer tags and fields nbt2json doesn't use errors when converting JSON or YAML
+func (c *Converter) UseStrictJson() {
+	c.strictJson = true
+}
+
+// UseLenientJson truncates fractions in integer tags and ignores unknown fields when converting JSON or YAML. This is
+// the default.
+func (c *Converter) UseLenientJson() {
+	c.strictJson = false
+}
+
+// UseLongAsString will make nbt long values as string numbers in the json/yaml
+func (c *Converter) UseLongAsString() {
+	c.longAsString = true
+}
+
+// UseLongAsUint32Pair will make nbt long values as valueLeast/valueMost uint32 pairs in the json
+func (c *Converter) UseLongAsUint32Pair() {
+	c.longAsString = false
+}
+
+// UseRecordedCompression compresses NBT converted from a JSON or YAML document the way the document's compression
+// field records the original was. This is the default.
+func (c *Converter) UseRecordedCompression() {
+	c.uncompressed = false
+}
+
+// UseUncompressedNbt ignores the compression recorded in JSON and YAML documents and always writes uncompressed NBT
+func (c *Converter) UseUncompressedNbt() {
+	c.uncompressed = true
+}
+
+// UseLimits sets the limits enforced when decoding NBT
+func (c *Converter) UseLimits(limits Limits) {
+	c.limits = limits
+}
+
+// Used by the package-level functions; change with UseJavaEncoding(), UseBedrockEncoding(), etc.
+var defaultConverter = NewConverter()
+
+// UseJavaEncoding sets the module to decode/encode from/to big endian NBT for Minecraft Java Edition
+func UseJavaEncoding() {
+	defaultConverter.UseJavaEncoding()
+}
+
+// UseBedrockEncoding sets the module to decode/encode from/to little endian NBT for Minecraft Bedrock Edition
+func UseBedrockEncoding() {
+	defaultConverter.UseBedrockEncoding()
+}
+
+// UseBedrockNetworkEncoding sets the module to decode/encode from/to the varint little endian NBT of the Minecraft Bedrock Edition network protocol
+func UseBedrockNetworkEncoding() {
+	defaultConverter.UseBedrockNetworkEncoding()
+}
+
+// UseRawStringFallback keeps the raw bytes of Java Edition names and strings that aren't valid modified UTF-8 instead of
+// returning an error. Raw strings are written back unchanged, and in JSON they are their bytes in hex.
+func UseRawStringFallback() {
+	defaultConverter.UseRawStringFallback()
+}
+
+// UseStrictStrings makes Java Edition names and strings that aren't valid modified UTF-8 an error. This is the default.
+func UseStrictStrings() {
+	defaultConverter.UseStrictStrings()
+}
+
+// UseStrictJson makes fractions in integer tags and fields nbt2json doesn't use errors when converting JSON or YAML
+func UseStrictJson() {
+	defaultConverter.UseStrictJson()
+}
+
+// UseLenientJson truncates fractions in integer tags and ignores unknown fields when converting JSON or YAML. This is
+// the default.
+func UseLenientJson() {
+	defaultConverter.UseLenientJson()
+}
+
+// UseLongAsString will make nbt long values as string numbers in the json/yaml
+func UseLongAsString() {
+	defaultConverter.UseLongAsString()
+}
+
+// UseLongAsUint32Pair will make nbt long values as valueLeast/valueMost uint32 pairs in the json
+func UseLongAsUint32Pair() {
+	defaultConverter.UseLongAsUint32Pair()
+}
+
+// UseRecordedCompression compresses NBT converted from a JSON or YAML document the way the document's compression
+// field records the original was. This is the default.
+func UseRecordedCompression() {
+	defaultConverter.UseRecordedCompression()
+}
+
+// UseUncompressedNbt ignores the compression recorded in JSON and YAML documents and always writes uncompressed NBT
+func UseUncompressedNbt() {
+	defaultConverter.UseUncompressedNbt()
+}
+
+// UseLimits sets the limits the module enforces when decoding NBT
+func UseLimits(limits Limits) {
+	defaultConverter.UseLimits(limits)
+}
+
+// NbtParseError is when the nbt data does not match an expected pattern. Errors in a tag have the tag's location:
+// Offset is the byte offset where the tag starts, counting a level.dat header, Path is its path from inside the
+// top-level tag like /Level/Sections[4]/BlockStates (empty for the top-level tag itself), and TagType is its type.
+// Get them with errors.As.
+type NbtParseError struct {
+	s       string
+	e       error
+	Offset  int64
+	Path    string
+	TagType byte
+	located bool
+}
+
+func (e NbtParseError) Error() string {
+	return fmt.Sprintf("Error parsing NBT%s: %s", e.location(), e.message())
+}
+
+func (e NbtParseError) Unwrap() error {
+	return e.e
+}
+
+// message returns the error's message and those of the errors it wraps, leaving out the repeated prefixes
+func (e NbtParseError) message() string {
+	switch inner := e.e.(type) {
+	case nil:
+		return e.s
+	case NbtParseError:
+		return fmt.Sprintf("%s: %s", e.s, inner.message())
+	}
+	return fmt.Sprintf("%s: %s", e.s, e.e.Error())
+}
+
+func (e NbtParseError) location() string {
+	if !e.located {
+		return ""
+	}
+	return errorLocation(e.Offset, e.Path, e.TagType)
+}
+
+// nbtErrorAt gives an error from reading a tag the tag's location. If the error already has the location of a tag
+// inside this one, the path segment is prepended instead, so the path grows as the error is returned up the tree.
+func nbtErrorAt(err error, offset int64, segment string, tagType byte) error {
+	e, ok := err.(NbtParseError)
+	if !ok {
+		e = NbtParseError{s: "Reading tag", e: err}
+	}
+	if e.located {
+		e.Path = segment + e.Path
+		return e
+	}
+	e.Offset, e.Path, e.TagType, e.located = offset, segment, tagType, true
+	return e
+}
+
+// wrapNbtError returns an NbtParseError with message s that wraps err and has err's location
+func wrapNbtError(s string, err error) NbtParseError {
+	wrapped := NbtParseError{s: s, e: err}
+	if e, ok := err.(NbtParseError); ok {
+		wrapped.Offset, wrapped.Path, wrapped.TagType, wrapped.located = e.Offset, e.Path, e.TagType, e.located
+	}
+	return wrapped
+}
+
+// LimitError is when NBT data goes over one of a converter's Limits. It is wrapped in an NbtParseError which gives
+// the tag's location.
+type LimitError struct {
+	// Limit is the name of the Limits field, e.g. MaxDepth
+	Limit string
+	// Value is the depth, byte count or length that went over Max
+	Value int64
+	Max   int64
+}
+
+func (e LimitError) Error() string {
+	return fmt.Sprintf("%d is over the %s limit of %d", e.Value, e.Limit, e.Max)
+}
+
+// JsonParseError is when the json data does not match an expected pattern, or a tag tree can't be written as NBT.
+// Errors in a tag have the tag's location: Offset is the byte offset in the JSON input where the tag starts (or, for
+// a tag inside a value that was decoded whole, where the closest tag containing it starts), or -1 if it isn't known,
+// as when converting a tag tree. Path is its path from inside the top-level tag like /Level/Sections[4]/BlockStates
+// (empty for the top-level tag itself), and TagType is its type. Get them with errors.As.
+type JsonParseError struct {
+	s       string
+	e       error
+	Offset  int64
+	Path    string
+	TagType byte
+	located bool
+}
+
+func (e JsonParseError) Error() string {
+	return fmt.Sprintf("Error parsing json2nbt%s: %s", e.location(), e.message())
+}
+
+func (e JsonParseError) Unwrap() error {
+	return e.e
+}
+
+// message returns the error's message and those of the errors it wraps, leaving out the repeated prefixes
+func (e JsonParseError) message() string {
+	switch inner := e.e.(type) {
+	case nil:
+		return e.s
+	case JsonParseError:
+		return fmt.Sprintf("%s: %s", e.s, inner.message())
+	}
+	return fmt.Sprintf("%s: %s", e.s, e.e.Error())
+}
+
+func (e JsonParseError) location() string {
+	if !e.located {
+		return ""
+	}
+	return errorLocation(e.Offset, e.Path, e.TagType)
+}
+
+// jsonErrorAt gives an error from converting a tag the tag's location, like nbtErrorAt. An inner tag whose offset
+// isn't known gets this tag's offset, if it is known.
+func jsonErrorAt(err error, offset int64, segment string, tagType byte) error {
+	e, ok := err.(JsonParseError)
+	if !ok {
+		e = JsonParseError{s: "Converting tag", e: err}
+	}
+	if e.located {
+		e.Path = segment + e.Path
+		if e.Offset < 0 {
+			e.Offset = offset
+		}
+		return e
+	}
+	e.Offset, e.Path, e.TagType, e.located = offset, segment, tagType, true
+	return e
+}
+
+// wrapJsonError returns a JsonParseError with message s that wraps err and has err's location
+func wrapJsonError(s string, err error) JsonParseError {
+	wrapped := JsonParseError{s: s, e: err}
+	if e, ok := err.(JsonParseError); ok {
+		wrapped.Offset, wrapped.Path, wrapped.TagType, wrapped.located = e.Offset, e.Path, e.TagType, e.located
+	}
+	return wrapped
+}
+
+// errorLocation describes where a tag error happened for an error message
+func errorLocation(offset int64, path string, tagType byte) string {
+	var s string
+	if offset >= 0 {
+		s = fmt.Sprintf(" at byte %d", offset)
+	}
+	if path != "" {
+		s += " in " + path
+	}
+	return fmt.Sprintf("%s (tag type %d)", s, tagType)
+}
+
+// ValidationError lists every problem ValidateJson found in a JSON document, each a located JsonParseError. It unwraps
+// to the first problem.
+type ValidationError struct {
+	Problems []JsonParseError
+}
+
+func (e ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0].Error()
+	}
+	s := fmt.Sprintf("Found %d problems in the json2nbt input:", len(e.Problems))
+	for _, problem := range e.Problems {
+		s += "\n" + problem.Error()
+	}
+	return s
+}
+
+func (e ValidationError) Unwrap() error {
+	return e.Problems[0]
+}
+
+// RoundTripError is when NBT converted to JSON and back isn't the same bytes. Offset is the first byte that differs,
+// and Path and TagType are those of the innermost tag containing it, as in NbtParseError.
+type RoundTripError struct {
+	Offset  int64
+	Path    string
+	TagType byte
+}
+
+func (e RoundTripError) Error() string {
+	return fmt.Sprintf("Error verifying round trip%s: converting to JSON and back changed the NBT", errorLocation(e.Offset, e.Path, e.TagType))
+}
+
+// MarshalError is when a Go value can't be converted to or from NBT. Pass it message string and downstream error
+type MarshalError struct {
+	s string
+	e error
+}
+
+func (e MarshalError) Error() string {
+	var s string
+	if e.e != nil {
+		s = fmt.Sprintf(": %s", e.e.Error())
+	}
+	return fmt.Sprintf("Error marshalling NBT: %s%s", e.s, s)
+}
+
+// SnbtParseError is when SNBT text does not match an expected pattern. Pass it message string and downstream error
+type SnbtParseError struct {
+	s string
+	e error
+}
+
+func (e SnbtParseError) Error() string {
+	var s string
+	if e.e != nil {
+		s = fmt.Sprintf(": %s", e.e.Error())
+	}
+	return fmt.Sprintf("Error parsing SNBT: %s%s", e.s, s)
+}
+
+func (e SnbtParseError) Unwrap() error {
+	return e.e
+}
+
+// PathError is when an NBT path expression can't be parsed or used. Pass it message string and downstream error
+type PathError struct {
+	s string
+	e error
+}
+
+func (e PathError) Error() string {
+	var s string
+	if e.e != nil {
+		s = fmt.Sprintf(": %s", e.e.Error())
+	}
+	return fmt.Sprintf("Error in NBT path: %s%s", e.s, s)
+}
+
+// PatchError is when a JSON Patch or merge patch can't be applied. Pass it message string and downstream error
+type PatchError struct {
+	s string
+	e error
+}
+
+func (e PatchError) Error() string {
+	var s string
+	if e.e != nil {
+		s = fmt.Sprintf(": %s", e.e.Error())
+	}
+	return fmt.Sprintf("Error applying patch: %s%s", e.s, s)
+}
+
+// CompressionError is when data can't be compressed or decompressed. Pass it message string and downstream error
+type CompressionError struct {
+	s string
+	e error
+}
+
+func (e CompressionError) Error() string {
+	var s string
+	if e.e != nil {
+		s = fmt.Sprintf(": %s", e.e.Error())
+	}
+	return fmt.Sprintf("Compression error: %s%s", e.s, s)
+}
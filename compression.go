@@ -0,0 +1,446 @@
+package nbt2json
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+// Compression is a compression NBT data can be stored with
+type Compression int
+
+// The compressions DetectCompression recognizes. LZ4Compression is the framing of lz4-java's LZ4BlockOutputStream,
+// which Minecraft uses, not the standard LZ4 frame format.
+const (
+	NoCompression Compression = iota
+	GzipCompression
+	ZlibCompression
+	LZ4Compression
+)
+
+func (c Compression) String() string {
+	switch c {
+	case NoCompression:
+		return "none"
+	case GzipCompression:
+		return "gzip"
+	case ZlibCompression:
+		return "zlib"
+	case LZ4Compression:
+		return "lz4"
+	}
+	return fmt.Sprintf("unknown(%d)", int(c))
+}
+
+// ParseCompression returns the compression with the given name: none, gzip, zlib or lz4
+func ParseCompression(s string) (Compression, error) {
+	for _, c := range []Compression{NoCompression, GzipCompression, ZlibCompression, LZ4Compression} {
+		if strings.EqualFold(s, c.String()) {
+			return c, nil
+		}
+	}
+	return 0, CompressionError{fmt.Sprintf("%q not recognized, use none, gzip, zlib or lz4", s), nil}
+}
+
+// DetectCompression returns the compression of data starting with b, from its magic number. 8 bytes are enough to
+// recognize every compression. Uncompressed NBT starts with a tag type or Bedrock's level.dat storage version, which
+// none of the magic numbers can be.
+func DetectCompression(b []byte) Compression {
+	switch {
+	case len(b) >= 2 && b[0] == 0x1f && b[1] == 0x8b:
+		return GzipCompression
+	case len(b) >= len(lz4BlockMagic) && string(b[:len(lz4BlockMagic)]) == lz4BlockMagic:
+		return LZ4Compression
+	case len(b) >= 2 && isZlibHeader(b[0], b[1]):
+		return ZlibCompression
+	}
+	return NoCompression
+}
+
+// isZlibHeader reports whether cmf and flg are a zlib header: deflate with a window of 512 bytes to 32 KiB, no
+// preset dictionary, and the header check. Windows of 256 bytes are left out because 0x08 is the string tag type.
+func isZlibHeader(cmf, flg byte) bool {
+	return cmf&0x0f == 8 && cmf>>4 >= 1 && cmf>>4 <= 7 && flg&0x20 == 0 && (uint16(cmf)<<8|uint16(flg))%31 == 0
+}
+
+// NewDecompressReader detects the compression of r and returns a reader of its decompressed data, and the compression.
+// For gzip the reader is a *gzip.Reader, whose Header has the gzip header fields.
+func NewDecompressReader(r io.Reader) (io.Reader, Compression, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(lz4BlockMagic))
+	compression := DetectCompression(magic)
+	switch compression {
+	case GzipCompression:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, compression, CompressionError{"Reading gzip header", err}
+		}
+		return zr, compression, nil
+	case ZlibCompression:
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return nil, compression, CompressionError{"Reading zlib header", err}
+		}
+		return zr, compression, nil
+	case LZ4Compression:
+		return &lz4BlockReader{r: br}, compression, nil
+	}
+	return br, compression, nil
+}
+
+// NewCompressWriter returns a writer compressing to w. Close it to finish the compressed data; it doesn't close w.
+func NewCompressWriter(w io.Writer, c Compression) (io.WriteCloser, error) {
+	switch c {
+	case NoCompression:
+		return nopWriteCloser{w}, nil
+	case GzipCompression:
+		return gzip.NewWriter(w), nil
+	case ZlibCompression:
+		return zlib.NewWriter(w), nil
+	case LZ4Compression:
+		return &lz4BlockWriter{w: w}, nil
+	}
+	return nil, CompressionError{fmt.Sprintf("Compression %s not supported", c), nil}
+}
+
+// NewHeaderCompressWriter returns a writer compressing to w like NewCompressWriter. For gzip, header's modification
+// time, OS byte, file name and comment are written too, so data read with NewDecompressReader can be compressed again
+// with the same header. header can be nil.
+func NewHeaderCompressWriter(w io.Writer, c Compression, header *gzip.Header) (io.WriteCloser, error) {
+	record := recordCompression(c, header)
+	if record == nil {
+		return NewCompressWriter(w, c)
+	}
+	return record.newWriter(w)
+}
+
+// Decompress detects the compression of b and returns the decompressed data and the compression
+func Decompress(b []byte) ([]byte, Compression, error) {
+	r, compression, err := NewDecompressReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, compression, err
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, compression, CompressionError{"Decompressing " + compression.String(), err}
+	}
+	return data, compression, nil
+}
+
+// Compress returns b compressed with c
+func Compress(b []byte, c Compression) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := NewCompressWriter(&buf, c)
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.Write(b)
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		return nil, CompressionError{"Compressing " + c.String(), err}
+	}
+	return buf.Bytes(), nil
+}
+
+// NbtCompression is the compression of the NBT a json document was converted from; it is exported for reflect, and
+// client code shouldn't use it. Converting the document back compresses the NBT the same way. For gzip, the header's
+// modification time (in Unix seconds), OS byte, file name and comment are kept too.
+type NbtCompression struct {
+	Type    string `json:"type"`
+	ModTime int64  `json:"mtime,omitempty"`
+	OS      *byte  `json:"os,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// recordCompression returns the NbtCompression recording compression and, for gzip, header, or nil if there's no
+// compression
+func recordCompression(compression Compression, header *gzip.Header) *NbtCompression {
+	if compression == NoCompression {
+		return nil
+	}
+	record := &NbtCompression{Type: compression.String()}
+	if compression == GzipCompression && header != nil {
+		if !header.ModTime.IsZero() {
+			record.ModTime = header.ModTime.Unix()
+		}
+		os := header.OS
+		record.OS = &os
+		record.Name = header.Name
+		record.Comment = header.Comment
+	}
+	return record
+}
+
+// compression returns the recorded compression, or an error if it isn't one or a gzip header field can't be written
+func (record *NbtCompression) compression() (Compression, error) {
+	compression, err := ParseCompression(record.Type)
+	if err != nil {
+		return compression, err
+	}
+	// gzip header strings are zero-terminated Latin-1
+	for _, s := range []string{record.Name, record.Comment} {
+		for _, r := range s {
+			if r == 0 || r > 0xff {
+				return compression, CompressionError{fmt.Sprintf("gzip name and comment must be Latin-1 without zero bytes, %q isn't", s), nil}
+			}
+		}
+	}
+	return compression, nil
+}
+
+// newWriter returns a writer compressing to w as recorded, with the recorded gzip header fields
+func (record *NbtCompression) newWriter(w io.Writer) (io.WriteCloser, error) {
+	compression, err := record.compression()
+	if err != nil {
+		return nil, err
+	}
+	cw, err := NewCompressWriter(w, compression)
+	if zw, ok := cw.(*gzip.Writer); ok {
+		if record.ModTime != 0 {
+			zw.ModTime = time.Unix(record.ModTime, 0)
+		}
+		if record.OS != nil {
+			zw.OS = *record.OS
+		}
+		zw.Name = record.Name
+		zw.Comment = record.Comment
+	}
+	return cw, err
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// lz4-java's LZ4BlockOutputStream splits data into blocks of up to 64 KiB. Each block has a 21 byte header: the magic
+// "LZ4Block", a method and level byte, the little endian compressed length, decompressed length and checksum, then the
+// block data. An empty block ends the stream.
+const (
+	lz4BlockMagic      = "LZ4Block"
+	lz4BlockHeaderSize = 21
+	lz4BlockSize       = 1 << 16
+	// lz4-java's level for 64 KiB blocks, stored in the low bits of the method byte
+	lz4BlockLevel = 6
+	lz4MethodRaw  = 0x10
+	lz4MethodLZ4  = 0x20
+	// The checksum is the low 28 bits of xxHash32 of the decompressed block with this seed
+	lz4BlockSeed     = 0x9747b28c
+	lz4ChecksumMask  = 0x0fffffff
+	lz4MaxBlockLevel = 0x0f
+)
+
+// lz4BlockWriter writes an lz4-java block stream, a block at a time
+type lz4BlockWriter struct {
+	w          io.Writer
+	block      []byte
+	compressor lz4.Compressor
+	compressed []byte
+}
+
+func (w *lz4BlockWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		space := lz4BlockSize - len(w.block)
+		if space > len(p) {
+			space = len(p)
+		}
+		w.block = append(w.block, p[:space]...)
+		p = p[space:]
+		if len(w.block) == lz4BlockSize {
+			if err := w.flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// flush writes the buffered data as a block
+func (w *lz4BlockWriter) flush() error {
+	if len(w.block) == 0 {
+		return nil
+	}
+	if w.compressed == nil {
+		w.compressed = make([]byte, lz4.CompressBlockBound(lz4BlockSize))
+	}
+	checksum := xxhash32(w.block, lz4BlockSeed) & lz4ChecksumMask
+	n, err := w.compressor.CompressBlock(w.block, w.compressed)
+	if err != nil {
+		return err
+	}
+	// Like lz4-java, store blocks that don't get smaller uncompressed
+	if n == 0 || n >= len(w.block) {
+		err = w.writeBlock(lz4MethodRaw, w.block, len(w.block), checksum)
+	} else {
+		err = w.writeBlock(lz4MethodLZ4, w.compressed[:n], len(w.block), checksum)
+	}
+	w.block = w.block[:0]
+	return err
+}
+
+func (w *lz4BlockWriter) writeBlock(method byte, data []byte, decompressedLen int, checksum uint32) error {
+	var header [lz4BlockHeaderSize]byte
+	copy(header[:], lz4BlockMagic)
+	header[8] = method | lz4BlockLevel
+	binary.LittleEndian.PutUint32(header[9:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(header[13:], uint32(decompressedLen))
+	binary.LittleEndian.PutUint32(header[17:], checksum)
+	if _, err := w.w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.w.Write(data)
+	return err
+}
+
+// Close writes the last block and the empty block ending the stream
+func (w *lz4BlockWriter) Close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.writeBlock(lz4MethodRaw, nil, 0, 0)
+}
+
+// lz4BlockReader reads an lz4-java block stream, a block at a time
+type lz4BlockReader struct {
+	r     io.Reader
+	block []byte
+	done  bool
+}
+
+func (r *lz4BlockReader) Read(p []byte) (int, error) {
+	for len(r.block) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.block)
+	r.block = r.block[n:]
+	return n, nil
+}
+
+// next reads and decompresses the next block
+func (r *lz4BlockReader) next() error {
+	var header [lz4BlockHeaderSize]byte
+	n, err := io.ReadFull(r.r, header[:])
+	// lz4-java also treats the end of the data as the end of the stream
+	if n == 0 && err == io.EOF {
+		r.done = true
+		return nil
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	if err != nil || string(header[:len(lz4BlockMagic)]) != lz4BlockMagic {
+		return errors.New("LZ4 block header not found")
+	}
+	method := header[8] & 0xf0
+	maxSize := 1 << (10 + uint(header[8]&lz4MaxBlockLevel))
+	compressedLen := int(int32(binary.LittleEndian.Uint32(header[9:])))
+	decompressedLen := int(int32(binary.LittleEndian.Uint32(header[13:])))
+	checksum := binary.LittleEndian.Uint32(header[17:])
+	if decompressedLen == 0 && compressedLen == 0 {
+		r.done = true
+		return nil
+	}
+	if compressedLen < 0 || compressedLen > lz4.CompressBlockBound(maxSize) || decompressedLen < 0 || decompressedLen > maxSize {
+		return fmt.Errorf("LZ4 block lengths %d and %d are not valid", compressedLen, decompressedLen)
+	}
+	data := make([]byte, compressedLen)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return fmt.Errorf("LZ4 block of %d bytes is cut off: %w", compressedLen, err)
+	}
+	switch method {
+	case lz4MethodRaw:
+		if compressedLen != decompressedLen {
+			return fmt.Errorf("LZ4 raw block lengths %d and %d differ", compressedLen, decompressedLen)
+		}
+		r.block = data
+	case lz4MethodLZ4:
+		r.block = make([]byte, decompressedLen)
+		n, err := lz4.UncompressBlock(data, r.block)
+		if err != nil {
+			return err
+		}
+		if n != decompressedLen {
+			return fmt.Errorf("LZ4 block decompressed to %d bytes, expected %d", n, decompressedLen)
+		}
+	default:
+		return fmt.Errorf("LZ4 block method 0x%x not recognized", method)
+	}
+	if xxhash32(r.block, lz4BlockSeed)&lz4ChecksumMask != checksum {
+		return errors.New("LZ4 block checksum does not match")
+	}
+	return nil
+}
+
+const (
+	xxPrime1 uint32 = 2654435761
+	xxPrime2 uint32 = 2246822519
+	xxPrime3 uint32 = 3266489917
+	xxPrime4 uint32 = 668265263
+	xxPrime5 uint32 = 374761393
+)
+
+func xxRotl(x uint32, r uint) uint32 {
+	return x<<r | x>>(32-r)
+}
+
+func xxRound(acc, input uint32) uint32 {
+	return xxRotl(acc+input*xxPrime2, 13) * xxPrime1
+}
+
+// xxhash32 is the 32 bit xxHash of b, used for lz4-java block checksums
+func xxhash32(b []byte, seed uint32) uint32 {
+	n := len(b)
+	var h uint32
+	if n >= 16 {
+		v1 := seed + xxPrime1 + xxPrime2
+		v2 := seed + xxPrime2
+		v3 := seed
+		v4 := seed - xxPrime1
+		for ; len(b) >= 16; b = b[16:] {
+			v1 = xxRound(v1, binary.LittleEndian.Uint32(b[0:]))
+			v2 = xxRound(v2, binary.LittleEndian.Uint32(b[4:]))
+			v3 = xxRound(v3, binary.LittleEndian.Uint32(b[8:]))
+			v4 = xxRound(v4, binary.LittleEndian.Uint32(b[12:]))
+		}
+		h = xxRotl(v1, 1) + xxRotl(v2, 7) + xxRotl(v3, 12) + xxRotl(v4, 18)
+	} else {
+		h = seed + xxPrime5
+	}
+	h += uint32(n)
+	for ; len(b) >= 4; b = b[4:] {
+		h += binary.LittleEndian.Uint32(b) * xxPrime3
+		h = xxRotl(h, 17) * xxPrime4
+	}
+	for _, c := range b {
+		h += uint32(c) * xxPrime5
+		h = xxRotl(h, 11) * xxPrime1
+	}
+	h ^= h >> 15
+	h *= xxPrime2
+	h ^= h >> 13
+	h *= xxPrime3
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,179 @@
+package nbt2json
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// TestXxhash32 checks the lz4-java checksum hash against known xxHash32 values
+func TestXxhash32(t *testing.T) {
+	hashes := []struct {
+		data string
+		hash uint32
+	}{
+		{"", 0x02cc5d05},
+		{"abc", 0x32d153ff},
+		{"Nobody inspects the spammish repetition", 0xe2293b2f},
+	}
+	for _, test := range hashes {
+		if hash := xxhash32([]byte(test.data), 0); hash != test.hash {
+			t.Errorf("xxhash32(%q) expected %08x, got %08x", test.data, test.hash, hash)
+		}
+	}
+}
+
+// TestCompression checks each compression round trips and is detected, including LZ4 data spanning several blocks
+// read a byte at a time
+func TestCompression(t *testing.T) {
+	random := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(random)
+	nbtData, err := Json2Nbt([]byte(testJson))
+	if err != nil {
+		t.Fatal("Error converting test json:", err.Error())
+	}
+	inputs := [][]byte{
+		{},
+		nbtData,
+		bytes.Repeat([]byte("minecraft:stone "), 20000),
+		random,
+	}
+	for _, compression := range []Compression{NoCompression, GzipCompression, ZlibCompression, LZ4Compression} {
+		for _, input := range inputs {
+			data, err := Compress(input, compression)
+			if err != nil {
+				t.Errorf("Error compressing %d bytes with %s: %s", len(input), compression, err.Error())
+				continue
+			}
+			output, detected, err := Decompress(data)
+			if err != nil {
+				t.Errorf("Error decompressing %d bytes with %s: %s", len(input), compression, err.Error())
+			} else if !bytes.Equal(input, output) {
+				t.Errorf("%s round trip of %d bytes returned %d different bytes", compression, len(input), len(output))
+			}
+			if detected != compression && len(input) > 0 {
+				t.Errorf("%s compressed data detected as %s", compression, detected)
+			}
+			r, _, err := NewDecompressReader(iotest.OneByteReader(bytes.NewReader(data)))
+			if err == nil {
+				output, err = ioutil.ReadAll(r)
+			}
+			if err != nil || !bytes.Equal(input, output) {
+				t.Errorf("%s streaming round trip of %d bytes failed: %v", compression, len(input), err)
+			}
+		}
+	}
+
+	// Uncompressed NBT and level.dat headers aren't mistaken for compressed data
+	for tagType := byte(0); tagType <= 12; tagType++ {
+		for second := 0; second < 256; second++ {
+			if compression := DetectCompression([]byte{tagType, byte(second)}); compression != NoCompression {
+				t.Fatalf("% x detected as %s", []byte{tagType, byte(second)}, compression)
+			}
+		}
+	}
+
+	// lz4-java's framing: magic, method and level, lengths, masked checksum, then an empty end block
+	data, err := Compress([]byte("hello"), LZ4Compression)
+	if err != nil {
+		t.Fatal("Error compressing with lz4:", err.Error())
+	}
+	expected := []byte("LZ4Block\x16\x05\x00\x00\x00\x05\x00\x00\x00")
+	if !bytes.HasPrefix(data, expected) || len(data) != 2*lz4BlockHeaderSize+5 {
+		t.Errorf("LZ4 block stream expected to start % x, got % x", expected, data)
+	}
+	data[len(data)-lz4BlockHeaderSize-1] ^= 1
+	if _, _, err := Decompress(data); err == nil {
+		t.Error("Decompressing corrupted lz4 block failed to throw error")
+	}
+	if _, _, err := Decompress(data[:10]); err == nil {
+		t.Error("Decompressing truncated lz4 block failed to throw error")
+	}
+
+	for _, name := range []string{"none", "GZIP", "zlib", "lz4"} {
+		if _, err := ParseCompression(name); err != nil {
+			t.Errorf("Error parsing compression %s: %s", name, err.Error())
+		}
+	}
+	if _, err := ParseCompression("bzip2"); err == nil {
+		t.Error("Parsing compression bzip2 failed to throw error")
+	}
+}
+
+// TestRecordedCompression checks compressed NBT converts to JSON recording its compression and gzip header, which
+// converting back re-applies
+func TestRecordedCompression(t *testing.T) {
+	levelDat, err := ioutil.ReadFile("testdata/bedrock/level.dat")
+	if err != nil {
+		t.Fatal("Error reading level.dat:", err.Error())
+	}
+	modTime := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	zw.ModTime, zw.OS, zw.Name, zw.Comment = modTime, 0, "level.dat", "from the server"
+	zw.Write(levelDat)
+	zw.Close()
+	inputs := map[Compression][]byte{GzipCompression: gzipped.Bytes()}
+	for _, compression := range []Compression{ZlibCompression, LZ4Compression} {
+		inputs[compression], _ = Compress(levelDat, compression)
+	}
+	for compression, input := range inputs {
+		jsonData, err := Nbt2Json(input, "")
+		if err != nil {
+			t.Errorf("Error converting %s NBT: %s", compression, err.Error())
+			continue
+		}
+		var document NbtJson
+		json.Unmarshal(jsonData, &document)
+		if document.Compression == nil || document.Compression.Type != compression.String() || document.Header == nil {
+			t.Errorf("%s NBT expected to record its compression and header, got %s", compression, jsonData)
+			continue
+		}
+		nbtData, err := Json2Nbt(jsonData)
+		if err != nil {
+			t.Errorf("Error converting %s JSON: %s", compression, err.Error())
+			continue
+		}
+		output, detected, err := Decompress(nbtData)
+		if err != nil || detected != compression || !bytes.Equal(output, levelDat) {
+			t.Errorf("%s JSON expected to convert back to %s level.dat, got %s: %v", compression, compression, detected, err)
+		}
+		if compression == GzipCompression {
+			zr, err := gzip.NewReader(bytes.NewReader(nbtData))
+			if err != nil {
+				t.Fatal("Error reading gzip header:", err.Error())
+			}
+			if !zr.ModTime.Equal(modTime) || zr.OS != 0 || zr.Name != "level.dat" || zr.Comment != "from the server" {
+				t.Errorf("gzip header not kept, got %+v", zr.Header)
+			}
+		}
+		uncompressed := NewConverter()
+		uncompressed.UseUncompressedNbt()
+		nbtData, err = uncompressed.Json2Nbt(jsonData)
+		if err != nil || !bytes.Equal(nbtData, levelDat) {
+			t.Errorf("%s JSON expected to convert to uncompressed level.dat with UseUncompressedNbt: %v", compression, err)
+		}
+	}
+
+	badJson := []string{
+		`{"compression":{"type":"bzip2"},"nbt":[{"tagType":1,"name":"","value":1}]}`,
+		`{"compression":{"type":"gzip","name":"\u0100"},"nbt":[{"tagType":1,"name":"","value":1}]}`,
+		`{"compression":{"type":"gzip","os":256},"nbt":[{"tagType":1,"name":"","value":1}]}`,
+	}
+	for _, b := range badJson {
+		if _, err := Json2Nbt([]byte(b)); err == nil {
+			t.Errorf("%s failed to throw error", b)
+		}
+		if err := ValidateJson([]byte(b)); err == nil {
+			t.Errorf("Validating %s failed to throw error", b)
+		}
+	}
+	if _, err := Json2Nbt([]byte(`{"nbt":[{"tagType":1,"name":"","value":1}],"compression":{"type":"zlib"}}`)); err == nil {
+		t.Error("Compression after nbt failed to throw error")
+	}
+}
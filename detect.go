@@ -0,0 +1,262 @@
+package nbt2json
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Encoding is an NBT encoding a Converter can use, as guessed by DetectEncoding
+type Encoding int
+
+// The encodings DetectEncoding can return, plus BedrockNetworkEncoding for UseEncoding
+const (
+	UnknownEncoding Encoding = iota
+	JavaEncoding
+	BedrockEncoding
+	BedrockNetworkEncoding
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case JavaEncoding:
+		return "Java Edition (big endian)"
+	case BedrockEncoding:
+		return "Bedrock Edition (little endian)"
+	case BedrockNetworkEncoding:
+		return "Bedrock Edition network (varint)"
+	}
+	return "unknown"
+}
+
+// UseEncoding sets the converter to the given encoding, e.g. one returned by DetectEncoding. UnknownEncoding leaves the settings unchanged.
+func (c *Converter) UseEncoding(e Encoding) {
+	switch e {
+	case JavaEncoding:
+		c.UseJavaEncoding()
+	case BedrockEncoding:
+		c.UseBedrockEncoding()
+	case BedrockNetworkEncoding:
+		c.UseBedrockNetworkEncoding()
+	}
+}
+
+// Encoding returns the encoding the converter is set to
+func (c *Converter) Encoding() Encoding {
+	switch {
+	case c.varint:
+		return BedrockNetworkEncoding
+	case c.byteOrder == binary.BigEndian:
+		return JavaEncoding
+	}
+	return BedrockEncoding
+}
+
+// UseEncoding sets the module to the given encoding, e.g. one returned by DetectEncoding. UnknownEncoding leaves the settings unchanged.
+func UseEncoding(e Encoding) {
+	defaultConverter.UseEncoding(e)
+}
+
+// DetectEncoding guesses whether uncompressed NBT is Java Edition (big endian) or Bedrock Edition (little endian)
+// by walking the tags with both byte orders and checking the tag types, name lengths and names are plausible.
+// b can be the start of larger data. Confidence is from 0.5, a coin toss, to nearly 1. If b isn't plausible NBT
+// in either byte order, DetectEncoding returns UnknownEncoding and 0. Bedrock's level.dat header is allowed.
+// Network encoding is not detected.
+func DetectEncoding(b []byte) (Encoding, float64) {
+	java := skimNbt(b, binary.BigEndian)
+	bedrockData := b
+	if hasHeader(b) {
+		bedrockData = b[headerSize:]
+	}
+	bedrock := skimNbt(bedrockData, binary.LittleEndian)
+
+	if java.result == skimFailed && bedrock.result == skimFailed {
+		return UnknownEncoding, 0
+	}
+	// Prefer the order that got further without nonsense, then the one that found more tags, then Bedrock like the
+	// module's default. When both orders make sense the data must be nearly symmetric, so there isn't much to lose.
+	winner, loser, encoding := bedrock, java, BedrockEncoding
+	if java.result > bedrock.result || (java.result == bedrock.result && java.tags > bedrock.tags) {
+		winner, loser, encoding = java, bedrock, JavaEncoding
+	}
+	if loser.result == skimFailed {
+		return encoding, 1 - 1/float64(winner.tags+2)
+	}
+	return encoding, 0.5 + 0.5*float64(winner.tags-loser.tags)/float64(winner.tags+loser.tags+1)
+}
+
+type skimResult int
+
+// Ordered from least to most plausible
+const (
+	skimFailed skimResult = iota
+	skimTruncated
+	skimComplete
+)
+
+type skimmer struct {
+	b     []byte
+	order binary.ByteOrder
+	tags  int
+}
+
+var (
+	errSkimImplausible = errors.New("not plausible NBT")
+	errSkimTruncated   = errors.New("data ends mid-tag")
+	errSkimEnough      = errors.New("enough tags checked")
+)
+
+const (
+	maxSkimTags  = 10000
+	maxSkimDepth = 512
+)
+
+// skimStats is how far skimNbt got through the data
+type skimStats struct {
+	result skimResult
+	tags   int
+}
+
+// skimNbt walks NBT without building tags, counting how many tags it gets through before the data stops making sense
+func skimNbt(b []byte, order binary.ByteOrder) skimStats {
+	s := skimmer{b: b, order: order}
+	var err error
+	if len(b) == 0 {
+		err = errSkimImplausible
+	}
+	for err == nil && len(s.b) > 0 {
+		err = s.tag(0)
+	}
+	result := skimComplete
+	switch err {
+	case errSkimImplausible:
+		result = skimFailed
+	case errSkimTruncated, errSkimEnough:
+		result = skimTruncated
+	}
+	return skimStats{result, s.tags}
+}
+
+func (s *skimmer) take(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, errSkimImplausible
+	}
+	if n > len(s.b) {
+		return nil, errSkimTruncated
+	}
+	p := s.b[:n]
+	s.b = s.b[n:]
+	return p, nil
+}
+
+// array walks an array's length and elements of the given size
+func (s *skimmer) array(size int) error {
+	n, err := s.length()
+	if err != nil {
+		return err
+	}
+	_, err = s.take(n * size)
+	return err
+}
+
+func (s *skimmer) length() (int, error) {
+	p, err := s.take(4)
+	if err != nil {
+		return 0, err
+	}
+	return int(int32(s.order.Uint32(p))), nil
+}
+
+// tag walks a tag type, name and payload. Names are nearly always identifiers, so control characters mean the name
+// length was read in the wrong byte order.
+func (s *skimmer) tag(depth int) error {
+	p, err := s.take(1)
+	if err != nil {
+		return err
+	}
+	tagType := p[0]
+	if tagType == 0 || tagType > 12 {
+		return errSkimImplausible
+	}
+	p, err = s.take(2)
+	if err != nil {
+		return err
+	}
+	name, err := s.take(int(s.order.Uint16(p)))
+	if err != nil {
+		return err
+	}
+	for _, c := range name {
+		if c < 0x20 || c == 0x7f {
+			return errSkimImplausible
+		}
+	}
+	return s.payload(tagType, depth)
+}
+
+func (s *skimmer) payload(tagType byte, depth int) error {
+	s.tags++
+	if s.tags > maxSkimTags {
+		return errSkimEnough
+	}
+	if depth > maxSkimDepth {
+		return errSkimImplausible
+	}
+	var err error
+	switch tagType {
+	case 1:
+		_, err = s.take(1)
+	case 2:
+		_, err = s.take(2)
+	case 3, 5:
+		_, err = s.take(4)
+	case 4, 6:
+		_, err = s.take(8)
+	case 7:
+		err = s.array(1)
+	case 11:
+		err = s.array(4)
+	case 12:
+		err = s.array(8)
+	case 8:
+		p, err := s.take(2)
+		if err != nil {
+			return err
+		}
+		_, err = s.take(int(s.order.Uint16(p)))
+		return err
+	case 9:
+		p, err := s.take(1)
+		if err != nil {
+			return err
+		}
+		listType := p[0]
+		n, err := s.length()
+		if err != nil {
+			return err
+		}
+		// Only empty lists can be type 0
+		if listType > 12 || n < 0 || (listType == 0 && n > 0) {
+			return errSkimImplausible
+		}
+		for i := 0; i < n; i++ {
+			err = s.payload(listType, depth+1)
+			if err != nil {
+				return err
+			}
+		}
+	case 10:
+		for {
+			if len(s.b) > 0 && s.b[0] == 0 {
+				s.b = s.b[1:]
+				return nil
+			}
+			err = s.tag(depth + 1)
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return errSkimImplausible
+	}
+	return err
+}
@@ -0,0 +1,285 @@
+package nbt2json
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// DiffKind is the kind of a Difference
+type DiffKind int
+
+// Kinds of differences
+const (
+	// DiffAdded is a tag only in the new tree
+	DiffAdded DiffKind = iota + 1
+	// DiffRemoved is a tag only in the old tree
+	DiffRemoved
+	// DiffChanged is a tag with a different value of the same type
+	DiffChanged
+	// DiffTypeChanged is a tag whose tag type changed
+	DiffTypeChanged
+	// DiffReordered is a compound with the same children in a different order
+	DiffReordered
+)
+
+var diffKindNames = map[DiffKind]string{
+	DiffAdded:       "added",
+	DiffRemoved:     "removed",
+	DiffChanged:     "changed",
+	DiffTypeChanged: "type changed",
+	DiffReordered:   "reordered",
+}
+
+func (k DiffKind) String() string {
+	return diffKindNames[k]
+}
+
+// Difference is one difference between two tag trees. Path is the tag's path as ParsePath reads it. Old is nil for
+// added tags and New is nil for removed tags. For reordered compounds, Old and New are the two compounds.
+type Difference struct {
+	Kind     DiffKind
+	Path     string
+	Old, New Tag
+	// parent and key split the path for unified output
+	parent, key string
+}
+
+// NbtDiffJson is the JSON document of differences; it is exported for reflect, and client code shouldn't use it
+type NbtDiffJson struct {
+	Name           string    `json:"name"`
+	Version        string    `json:"version"`
+	Nbt2JsonUrl    string    `json:"nbt2JsonUrl"`
+	ConversionTime string    `json:"conversionTime,omitempty"`
+	Differences    []NbtDiff `json:"differences"`
+}
+
+// NbtDiff represents one difference in the JSON document; it is exported for reflect, and client code shouldn't use it
+type NbtDiff struct {
+	Change string  `json:"change"`
+	Path   string  `json:"path"`
+	Old    *NbtTag `json:"old,omitempty"`
+	New    *NbtTag `json:"new,omitempty"`
+}
+
+// Diff compares two tag trees and returns their differences in tree order. Compounds are compared by child name and
+// lists and arrays by index. Unless ignoreOrder is set, a compound whose shared children are in a different order is
+// also a difference. Top-level tags are compared by position.
+func Diff(oldTags, newTags []NamedTag, ignoreOrder bool) []Difference {
+	d := differ{ignoreOrder: ignoreOrder}
+	for i := 0; i < len(oldTags) || i < len(newTags); i++ {
+		switch {
+		case i >= len(oldTags):
+			d.add(DiffAdded, "", "", nil, newTags[i].Value)
+		case i >= len(newTags):
+			d.add(DiffRemoved, "", "", oldTags[i].Value, nil)
+		default:
+			d.tag("", "", oldTags[i].Value, newTags[i].Value)
+		}
+	}
+	return d.diffs
+}
+
+type differ struct {
+	ignoreOrder bool
+	diffs       []Difference
+}
+
+func (d *differ) add(kind DiffKind, parent, key string, oldTag, newTag Tag) {
+	d.diffs = append(d.diffs, Difference{kind, joinKey(parent, key), oldTag, newTag, parent, key})
+}
+
+// tag compares two tags found at the same path
+func (d *differ) tag(parent, key string, oldTag, newTag Tag) {
+	if oldTag.TagType() != newTag.TagType() {
+		d.add(DiffTypeChanged, parent, key, oldTag, newTag)
+		return
+	}
+	path := joinKey(parent, key)
+	switch oldTag := oldTag.(type) {
+	case Compound:
+		d.compound(path, oldTag, newTag.(Compound))
+	case List:
+		newList := newTag.(List)
+		if len(oldTag.Items) == 0 && len(newList.Items) == 0 {
+			if oldTag.TagListType != newList.TagListType {
+				d.add(DiffChanged, parent, key, oldTag, newTag)
+			}
+			return
+		}
+		d.items(path, oldTag.Items, newList.Items)
+	case ByteArray, IntArray, LongArray:
+		d.items(path, tagItems(oldTag), tagItems(newTag))
+	default:
+		if !scalarsEqual(oldTag, newTag) {
+			d.add(DiffChanged, parent, key, oldTag, newTag)
+		}
+	}
+}
+
+func (d *differ) compound(path string, oldCompound, newCompound Compound) {
+	if !d.ignoreOrder {
+		var oldOrder, newOrder []string
+		for _, child := range oldCompound {
+			if newCompound.Get(child.Name) != nil {
+				oldOrder = append(oldOrder, child.Name)
+			}
+		}
+		for _, child := range newCompound {
+			if oldCompound.Get(child.Name) != nil {
+				newOrder = append(newOrder, child.Name)
+			}
+		}
+		if strings.Join(oldOrder, "\x00") != strings.Join(newOrder, "\x00") {
+			d.diffs = append(d.diffs, Difference{DiffReordered, path, oldCompound, newCompound, path, ""})
+		}
+	}
+	for _, child := range oldCompound {
+		key := joinPath("", child.Name)
+		if newValue := newCompound.Get(child.Name); newValue != nil {
+			d.tag(path, key, child.Value, newValue)
+		} else {
+			d.add(DiffRemoved, path, key, child.Value, nil)
+		}
+	}
+	for _, child := range newCompound {
+		if oldCompound.Get(child.Name) == nil {
+			d.add(DiffAdded, path, joinPath("", child.Name), nil, child.Value)
+		}
+	}
+}
+
+func (d *differ) items(path string, oldItems, newItems []Tag) {
+	for i := 0; i < len(oldItems) || i < len(newItems); i++ {
+		key := fmt.Sprintf("[%d]", i)
+		switch {
+		case i >= len(oldItems):
+			d.add(DiffAdded, path, key, nil, newItems[i])
+		case i >= len(newItems):
+			d.add(DiffRemoved, path, key, oldItems[i], nil)
+		default:
+			d.tag(path, key, oldItems[i], newItems[i])
+		}
+	}
+}
+
+// joinKey appends a child name or item index made by joinPath or fmt to a path
+func joinKey(path, key string) string {
+	if path == "" || strings.HasPrefix(key, "[") {
+		return path + key
+	}
+	return path + "." + key
+}
+
+// scalarsEqual compares two tags of the same non-container type. Floats are compared by bits, so NaN equals NaN and
+// 0 doesn't equal -0.
+func scalarsEqual(a, b Tag) bool {
+	switch a := a.(type) {
+	case Float:
+		return math.Float32bits(float32(a)) == math.Float32bits(float32(b.(Float)))
+	case Double:
+		return math.Float64bits(float64(a)) == math.Float64bits(float64(b.(Double)))
+	}
+	return a == b
+}
+
+// Diff2Text formats differences one per line: + for added, - for removed, ~ for changed, ! for type changed and ^ for
+// reordered, then the path and the SNBT values
+func Diff2Text(diffs []Difference) []byte {
+	var sb strings.Builder
+	for _, diff := range diffs {
+		path := diff.Path
+		if path == "" {
+			path = "(top level)"
+		}
+		switch diff.Kind {
+		case DiffAdded:
+			fmt.Fprintf(&sb, "+ %s: %s\n", path, Snbt(diff.New))
+		case DiffRemoved:
+			fmt.Fprintf(&sb, "- %s: %s\n", path, Snbt(diff.Old))
+		case DiffChanged:
+			fmt.Fprintf(&sb, "~ %s: %s -> %s\n", path, Snbt(diff.Old), Snbt(diff.New))
+		case DiffTypeChanged:
+			fmt.Fprintf(&sb, "! %s: tag type %d -> %d: %s -> %s\n", path, diff.Old.TagType(), diff.New.TagType(), Snbt(diff.Old), Snbt(diff.New))
+		case DiffReordered:
+			fmt.Fprintf(&sb, "^ %s: order %s -> %s\n", path, childNames(diff.Old), childNames(diff.New))
+		}
+	}
+	return []byte(sb.String())
+}
+
+// Diff2Unified formats differences like a unified diff, with a hunk for each compound, list or array that has changes.
+// Lines start with the child name or item index and have SNBT values.
+func Diff2Unified(diffs []Difference, oldName, newName string) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	hunk := "\x00"
+	for _, diff := range diffs {
+		if diff.parent != hunk {
+			hunk = diff.parent
+			if hunk == "" {
+				sb.WriteString("@@ (top level) @@\n")
+			} else {
+				fmt.Fprintf(&sb, "@@ %s @@\n", hunk)
+			}
+		}
+		key := diff.key
+		if key != "" && !strings.HasPrefix(key, "[") {
+			key += ":"
+		}
+		if key != "" {
+			key += " "
+		}
+		switch diff.Kind {
+		case DiffAdded:
+			fmt.Fprintf(&sb, "+%s%s\n", key, Snbt(diff.New))
+		case DiffRemoved:
+			fmt.Fprintf(&sb, "-%s%s\n", key, Snbt(diff.Old))
+		case DiffChanged, DiffTypeChanged:
+			fmt.Fprintf(&sb, "-%s%s\n+%s%s\n", key, Snbt(diff.Old), key, Snbt(diff.New))
+		case DiffReordered:
+			fmt.Fprintf(&sb, "-order: %s\n+order: %s\n", childNames(diff.Old), childNames(diff.New))
+		}
+	}
+	return []byte(sb.String())
+}
+
+// childNames lists a compound's child names
+func childNames(tag Tag) string {
+	var names []string
+	for _, child := range tag.(Compound) {
+		names = append(names, joinPath("", child.Name))
+	}
+	return strings.Join(names, ", ")
+}
+
+// Diff2Json formats differences as a JSON document using the module's default settings
+func Diff2Json(diffs []Difference) ([]byte, error) {
+	return defaultConverter.Diff2Json(diffs)
+}
+
+// Diff2Json formats differences as a JSON document. Old and new values are unnamed tags in the same form as Nbt2Json's.
+func (c *Converter) Diff2Json(diffs []Difference) ([]byte, error) {
+	diffJson := NbtDiffJson{
+		Name:           Name,
+		Version:        Version,
+		Nbt2JsonUrl:    Nbt2JsonUrl,
+		ConversionTime: time.Now().Format(time.RFC3339),
+		Differences:    []NbtDiff{},
+	}
+	for _, diff := range diffs {
+		element := NbtDiff{Change: diff.Kind.String(), Path: diff.Path}
+		if diff.Old != nil {
+			tag := c.jsonTag(NamedTag{Value: diff.Old})
+			element.Old = &tag
+		}
+		if diff.New != nil {
+			tag := c.jsonTag(NamedTag{Value: diff.New})
+			element.New = &tag
+		}
+		diffJson.Differences = append(diffJson.Differences, element)
+	}
+	return json.MarshalIndent(diffJson, "", "  ")
+}
@@ -0,0 +1,89 @@
+package nbt2json
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// TestDiff checks the differences found between two trees and how they're formatted
+func TestDiff(t *testing.T) {
+	oldTags, err := Snbt2Tags([]byte(`{Data:{LevelName:"Old",Time:100L,Player:{Health:20.0f,Pos:[1.0d,2.0d],Tags:[I;1,2]},Gone:1b,Version:19133}}`))
+	if err != nil {
+		t.Fatal("Error parsing old SNBT:", err.Error())
+	}
+	newTags, err := Snbt2Tags([]byte(`{Data:{LevelName:"New",Time:100L,Player:{Health:18.5f,Pos:[1.0d],Tags:[I;1,2,3]},Version:19133L,Added:{a:1b}}}`))
+	if err != nil {
+		t.Fatal("Error parsing new SNBT:", err.Error())
+	}
+	expected := `~ Data.LevelName: "Old" -> "New"
+~ Data.Player.Health: 20.0f -> 18.5f
+- Data.Player.Pos[1]: 2.0d
++ Data.Player.Tags[2]: 3
+- Data.Gone: 1b
+! Data.Version: tag type 3 -> 4: 19133 -> 19133L
++ Data.Added: {a:1b}
+`
+	diffs := Diff(oldTags, newTags, false)
+	if text := string(Diff2Text(diffs)); text != expected {
+		t.Errorf("Diff expected\n%s\n, got\n%s", expected, text)
+	}
+	for _, diff := range diffs {
+		if found, err := Query(newTags, diff.Path); diff.Kind != DiffRemoved && (err != nil || len(found) != 1) {
+			t.Errorf("Difference path %s doesn't find the new tag", diff.Path)
+		}
+	}
+
+	expected = `--- a
++++ b
+@@ Data @@
+-LevelName: "Old"
++LevelName: "New"
+@@ Data.Player @@
+-Health: 20.0f
++Health: 18.5f
+@@ Data.Player.Pos @@
+-[1] 2.0d
+@@ Data.Player.Tags @@
++[2] 3
+@@ Data @@
+-Gone: 1b
+-Version: 19133
++Version: 19133L
++Added: {a:1b}
+`
+	if unified := string(Diff2Unified(diffs, "a", "b")); unified != expected {
+		t.Errorf("Unified diff expected\n%s\n, got\n%s", expected, unified)
+	}
+
+	jsonOut, err := Diff2Json(diffs)
+	if err != nil {
+		t.Fatal("Error converting diff to JSON:", err.Error())
+	}
+	var diffJson NbtDiffJson
+	err = json.Unmarshal(jsonOut, &diffJson)
+	if err != nil {
+		t.Fatal("Error reading diff JSON:", err.Error())
+	}
+	if len(diffJson.Differences) != len(diffs) || diffJson.Differences[5].Change != "type changed" || diffJson.Differences[5].Old.TagType != 3 || diffJson.Differences[4].New != nil {
+		t.Errorf("Unexpected diff JSON:\n%s", jsonOut)
+	}
+
+	reordered, _ := Snbt2Tags([]byte(`{b:2b,a:1b}`))
+	original, _ := Snbt2Tags([]byte(`{a:1b,b:2b}`))
+	if diffs := Diff(original, reordered, false); len(diffs) != 1 || diffs[0].Kind != DiffReordered {
+		t.Errorf("Reordered compound expected one reordered difference, got %s", Diff2Text(diffs))
+	}
+	if diffs := Diff(original, reordered, true); len(diffs) != 0 {
+		t.Errorf("Reordered compound ignoring order expected no differences, got %s", Diff2Text(diffs))
+	}
+
+	nan := []NamedTag{{"", Compound{{"f", Double(math.NaN())}, {"z", Float(0)}}}}
+	negative := []NamedTag{{"", Compound{{"f", Double(math.NaN())}, {"z", Float(math.Copysign(0, -1))}}}}
+	if diffs := Diff(nan, negative, false); len(diffs) != 1 || diffs[0].Path != "z" {
+		t.Errorf("Float comparison expected only z to differ, got %s", Diff2Text(diffs))
+	}
+	if diffs := Diff(oldTags, oldTags, false); len(diffs) != 0 {
+		t.Errorf("Identical trees expected no differences, got %s", Diff2Text(diffs))
+	}
+}
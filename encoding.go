@@ -0,0 +1,135 @@
+package nbt2json
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Bedrock's network protocol uses little endian NBT where ints and longs, including array and list lengths, are
+// zigzag-encoded varints and name and string lengths are unsigned varints. Everything else is the same as Bedrock's
+// little endian NBT. These helpers read and write the values that differ so the rest of the code doesn't have to care.
+
+var errVarintTooLong = errors.New("varint is too long")
+
+// readByte reads one byte, returning io.EOF only if there was nothing to read
+func readByte(r io.Reader) (byte, error) {
+	if br, ok := r.(io.ByteReader); ok {
+		return br.ReadByte()
+	}
+	var b [1]byte
+	_, err := io.ReadFull(r, b[:])
+	return b[0], err
+}
+
+// readVarUint reads an unsigned varint of at most maxBytes bytes
+func readVarUint(r io.Reader, maxBytes int) (uint64, error) {
+	var x uint64
+	for i := 0; i < maxBytes; i++ {
+		b, err := readByte(r)
+		if err != nil {
+			if err == io.EOF && i > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		x |= uint64(b&0x7f) << (7 * uint(i))
+		if b < 0x80 {
+			return x, nil
+		}
+	}
+	return 0, errVarintTooLong
+}
+
+func writeVarUint(w io.Writer, x uint64) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], x)
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+// readInt reads an int, which is a zigzag varint in Bedrock network encoding
+func (c *Converter) readInt(r io.Reader) (int32, error) {
+	if !c.varint {
+		var i int32
+		err := binary.Read(r, c.byteOrder, &i)
+		return i, err
+	}
+	u, err := readVarUint(r, 5)
+	if err != nil {
+		return 0, err
+	}
+	if u > 0xffffffff {
+		return 0, errVarintTooLong
+	}
+	return int32(uint32(u)>>1) ^ -int32(u&1), nil
+}
+
+// readLong reads a long, which is a zigzag varint in Bedrock network encoding
+func (c *Converter) readLong(r io.Reader) (int64, error) {
+	if !c.varint {
+		var i int64
+		err := binary.Read(r, c.byteOrder, &i)
+		return i, err
+	}
+	u, err := readVarUint(r, 10)
+	if err != nil {
+		return 0, err
+	}
+	return int64(u>>1) ^ -int64(u&1), nil
+}
+
+// maxStringLength is the most bytes a name or string can have, the most an unsigned short length can give
+const maxStringLength = 0xffff
+
+// readStringLength reads the length of a name or string, an unsigned short like Java's writeUTF, or an unsigned
+// varint in Bedrock network encoding
+func (c *Converter) readStringLength(r io.Reader) (int, error) {
+	if !c.varint {
+		var i uint16
+		err := binary.Read(r, c.byteOrder, &i)
+		return int(i), err
+	}
+	u, err := readVarUint(r, 5)
+	if err != nil {
+		return 0, err
+	}
+	if u > maxStringLength {
+		return 0, errors.New("string length is too long")
+	}
+	return int(u), nil
+}
+
+// writeInt writes an int, which is a zigzag varint in Bedrock network encoding
+func (c *Converter) writeInt(w io.Writer, i int32) error {
+	if !c.varint {
+		return binary.Write(w, c.byteOrder, i)
+	}
+	return writeVarUint(w, uint64(uint32(i<<1)^uint32(i>>31)))
+}
+
+// writeLong writes a long, which is a zigzag varint in Bedrock network encoding
+func (c *Converter) writeLong(w io.Writer, i int64) error {
+	if !c.varint {
+		return binary.Write(w, c.byteOrder, i)
+	}
+	return writeVarUint(w, uint64(i<<1)^uint64(i>>63))
+}
+
+// stringLengthError is the error for a name or string of n bytes, more than its length can give
+func stringLengthError(n int) error {
+	return fmt.Errorf("%d bytes is over the %d byte limit for names and strings", n, maxStringLength)
+}
+
+// writeStringLength writes the length of a name or string, an unsigned short or an unsigned varint in Bedrock network
+// encoding. Lengths over maxStringLength are an error.
+func (c *Converter) writeStringLength(w io.Writer, n int) error {
+	if n > maxStringLength {
+		return stringLengthError(n)
+	}
+	if !c.varint {
+		return binary.Write(w, c.byteOrder, uint16(n))
+	}
+	return writeVarUint(w, uint64(n))
+}
@@ -0,0 +1,93 @@
+//go:build go1.18
+// +build go1.18
+
+package nbt2json
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Run with e.g. go test -fuzz FuzzReadNBT. Limits keep the fuzzer from spending its time on huge allocations.
+var fuzzLimits = Limits{MaxDepth: 512, MaxBytes: 1 << 20, MaxArrayLength: 1 << 16, MaxStringLength: 1 << 12}
+
+// fuzzConverters returns a converter for each encoding
+func fuzzConverters() []*Converter {
+	java, bedrock, network := NewConverter(), NewConverter(), NewConverter()
+	java.UseJavaEncoding()
+	network.UseBedrockNetworkEncoding()
+	converters := []*Converter{java, bedrock, network}
+	for _, c := range converters {
+		c.UseLimits(fuzzLimits)
+	}
+	return converters
+}
+
+// FuzzReadNBT checks decoding any input returns tags or an error without panicking, that the tree and streaming
+// decoders agree, and that decoded tags encode to NBT that decodes and encodes to the same NBT
+func FuzzReadNBT(f *testing.F) {
+	for _, c := range fuzzConverters() {
+		nbtData, err := c.Json2Nbt([]byte(testJson))
+		if err != nil {
+			f.Fatal("Error converting test json:", err.Error())
+		}
+		f.Add(nbtData)
+	}
+	f.Add([]byte{10, 0xff, 0xff, 0})
+	f.Add([]byte{9, 0, 0, 0, 0xff, 0xff, 0xff, 0x7f})
+	f.Fuzz(func(t *testing.T, nbtData []byte) {
+		for _, c := range fuzzConverters() {
+			tags, treeErr := c.ReadNBT(bytes.NewReader(nbtData))
+			_, streamErr := c.Nbt2Json(nbtData, "")
+			if (treeErr == nil) != (streamErr == nil) {
+				t.Fatalf("ReadNBT error %v but Nbt2Json error %v", treeErr, streamErr)
+			}
+			if treeErr != nil {
+				continue
+			}
+			var nbtOut bytes.Buffer
+			err := c.WriteNBT(&nbtOut, tags)
+			if err != nil {
+				t.Fatal("Error writing decoded tags:", err.Error())
+			}
+			// Compare NBT rather than using Diff, since compounds can have duplicate names
+			again, err := c.ReadNBT(bytes.NewReader(nbtOut.Bytes()))
+			if err != nil {
+				t.Fatal("Error reading written tags:", err.Error())
+			}
+			var againOut bytes.Buffer
+			err = c.WriteNBT(&againOut, again)
+			if err != nil || !bytes.Equal(nbtOut.Bytes(), againOut.Bytes()) {
+				t.Fatalf("Tags changed writing and reading them: %v\n%x\n%x", err, nbtOut.Bytes(), againOut.Bytes())
+			}
+		}
+	})
+}
+
+// FuzzJson2Nbt checks encoding any JSON returns NBT or an error without panicking, that ValidateJson finds a problem
+// exactly when the tree encoder does, and that the streaming and tree encoders agree
+func FuzzJson2Nbt(f *testing.F) {
+	f.Add([]byte(testJson))
+	f.Add([]byte(`{"nbt":[{"tagType":9,"name":"","value":{"list":[1],"tagListType":1}}]}`))
+	f.Add([]byte(`{"nbt":[{"tagType":"1","name":null,"value":1}]}`))
+	f.Fuzz(func(t *testing.T, jsonData []byte) {
+		for _, c := range fuzzConverters() {
+			nbtData, streamErr := c.Json2Nbt(jsonData)
+			tags, treeErr := c.Json2Tags(jsonData)
+			if validateErr := c.ValidateJson(jsonData); (validateErr == nil) != (treeErr == nil) {
+				t.Fatalf("ValidateJson error %v but Json2Tags error %v", validateErr, treeErr)
+			}
+			if streamErr != nil || treeErr != nil {
+				continue
+			}
+			var nbtOut bytes.Buffer
+			err := c.WriteNBT(&nbtOut, tags)
+			if err != nil {
+				continue
+			}
+			if _, err := c.ReadNBT(bytes.NewReader(nbtData)); err != nil {
+				t.Fatal("Error reading encoded NBT:", err.Error())
+			}
+		}
+	})
+}
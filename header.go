@@ -0,0 +1,58 @@
+package nbt2json
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// Bedrock's level.dat starts with an 8-byte header before its NBT: the little endian int32 storage version and the
+// int32 length of the NBT after the header. The game won't read a level.dat whose length doesn't match.
+
+// NbtHeader is the level.dat header recorded in the json document; it is exported for reflect, and client code shouldn't use it.
+// The length isn't recorded because it is recalculated when converting back to NBT.
+type NbtHeader struct {
+	StorageVersion int32 `json:"storageVersion"`
+}
+
+const headerSize = 8
+
+// peekHeader returns the level.dat header at the start of r, or nil if there isn't one. The header is consumed only
+// if found.
+func (c *Converter) peekHeader(r *bufio.Reader) (*NbtHeader, error) {
+	if c.byteOrder != binary.LittleEndian || c.varint {
+		return nil, nil
+	}
+	b, err := r.Peek(headerSize + 1)
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !hasHeader(b) {
+		return nil, nil
+	}
+	_, err = r.Discard(headerSize)
+	return &NbtHeader{StorageVersion: int32(binary.LittleEndian.Uint32(b))}, err
+}
+
+// hasHeader reports whether b starts with a plausible level.dat header. Storage versions are small and the NBT must
+// start with a compound, which plain NBT can't look like unless it begins with an empty compound followed by more tags.
+func hasHeader(b []byte) bool {
+	if len(b) < headerSize+1 {
+		return false
+	}
+	version := int32(binary.LittleEndian.Uint32(b))
+	length := binary.LittleEndian.Uint32(b[4:])
+	return version > 0 && version <= 0xffff && length != 0 && b[headerSize] == 10
+}
+
+// writeHeader writes a level.dat header for NBT of the given length. The header is always little endian.
+func writeHeader(w io.Writer, header *NbtHeader, length int) error {
+	var b [headerSize]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(header.StorageVersion))
+	binary.LittleEndian.PutUint32(b[4:], uint32(length))
+	_, err := w.Write(b[:])
+	return err
+}
@@ -1,363 +1,873 @@
 package nbt2json
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/ghodss/yaml"
 )
 
-// Yaml2Nbt converts JSON byte array to uncompressed NBT byte array
+// Yaml2Nbt converts YAML byte array to NBT byte array using the module's default settings. The NBT is compressed as
+// the document's compression field records, if it has one.
 func Yaml2Nbt(b []byte) ([]byte, error) {
+	return defaultConverter.Yaml2Nbt(b)
+}
+
+// Json2Nbt converts JSON byte array to NBT byte array using the module's default settings. The NBT is compressed as
+// the document's compression field records, if it has one.
+func Json2Nbt(b []byte) ([]byte, error) {
+	return defaultConverter.Json2Nbt(b)
+}
+
+// Yaml2Nbt converts YAML byte array to NBT byte array, compressed as the document's compression field records
+func (c *Converter) Yaml2Nbt(b []byte) ([]byte, error) {
 	myJson, err := yaml.YAMLToJSON(b)
 	if err != nil {
-		return nil, JsonParseError{"Error converting YAML to JSON", err}
+		return nil, JsonParseError{s: "Error converting YAML to JSON", e: err}
 	}
-	nbtOut, err := Json2Nbt(myJson)
+	nbtOut, err := c.Json2Nbt(myJson)
 	if err != nil {
 		return nbtOut, err
 	}
 	return nbtOut, nil
 }
 
-// Json2Nbt converts JSON byte array to uncompressed NBT byte array
-func Json2Nbt(b []byte) ([]byte, error) {
+// Json2Nbt converts JSON byte array to NBT byte array, compressed as the document's compression field records
+func (c *Converter) Json2Nbt(b []byte) ([]byte, error) {
 	nbtOut := new(bytes.Buffer)
-	var nbtJsonData NbtJson
-	var nbtTag interface{}
-	var nbtArray []interface{}
-	var err error
-	err = json.Unmarshal(b, &nbtJsonData)
+	err := c.NewEncoder(nbtOut).Encode(bytes.NewReader(b))
 	if err != nil {
-		return nil, JsonParseError{"Error parsing JSON input. Is input JSON-formatted?", err}
+		return nil, err
 	}
-	temp, err := json.Marshal(nbtJsonData.Nbt)
+	return nbtOut.Bytes(), nil
+}
+
+// Encoder reads a JSON document from an input stream and writes it out as NBT. The JSON is decoded one tag at a time,
+// but the NBT is buffered until the whole document has converted so nothing is written if it has problems.
+type Encoder struct {
+	c *Converter
+	w *bufio.Writer
+}
+
+// NewEncoder returns an Encoder that writes NBT to w using the module's default settings
+func NewEncoder(w io.Writer) *Encoder {
+	return defaultConverter.NewEncoder(w)
+}
+
+// NewEncoder returns an Encoder that writes NBT to w using the converter's settings
+func (c *Converter) NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		c: c,
+		w: bufio.NewWriter(w),
+	}
+}
+
+// Encode reads a JSON document like Json2Nbt's input from r and writes its tags as NBT. If the document records a
+// compression, which must come before nbt, the NBT is compressed the same way unless the converter uses
+// UseUncompressedNbt. A level.dat header must come before nbt too.
+//
+// Nothing is written unless the whole document converts. If it doesn't, the error is a ValidationError from
+// ValidateJson listing every problem in the document, with the offset of the first one that stopped the conversion.
+func (e *Encoder) Encode(r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
 	if err != nil {
-		return nil, JsonParseError{"Error marshalling nbt: json.RawMessage", err}
+		return JsonParseError{s: "Error reading JSON input", e: err}
 	}
-	err = json.Unmarshal(temp, &nbtArray)
+	var nbtOut bytes.Buffer
+	err = e.encode(bytes.NewReader(b), &nbtOut)
 	if err != nil {
-		return nil, JsonParseError{"Error unmarshalling nbt: value", err}
+		return e.c.allProblems(b, err)
 	}
-	if len(nbtArray) == 0 {
-		return nil, JsonParseError{"JSON input has no top-level value named nbt. JSON-encoded nbt data should be in an array { \"nbt\": [ <HERE> ] }", nil}
+	_, err = nbtOut.WriteTo(e.w)
+	if err != nil {
+		return JsonParseError{s: "Error writing nbt", e: err}
 	}
-	for _, nbtTag = range nbtArray {
-		err = writeTag(nbtOut, nbtTag)
-		if err != nil {
-			return nil, err
+	return e.w.Flush()
+}
+
+// allProblems returns the ValidationError for a document whose conversion failed with err, giving the offset from err
+// to the problem it matches. If the validator doesn't find a problem, err is returned as is.
+func (c *Converter) allProblems(b []byte, err error) error {
+	var validationErr ValidationError
+	if !errors.As(c.ValidateJson(b), &validationErr) {
+		return err
+	}
+	var jsonErr JsonParseError
+	if errors.As(err, &jsonErr) && jsonErr.Offset >= 0 {
+		for i, problem := range validationErr.Problems {
+			if problem.located && problem.Path == jsonErr.Path && problem.TagType == jsonErr.TagType {
+				validationErr.Problems[i].Offset = jsonErr.Offset
+				break
+			}
 		}
 	}
-
-	return nbtOut.Bytes(), nil
+	return validationErr
 }
 
-func writeTag(w io.Writer, myMap interface{}) error {
-	var err error
-	// TODO: This is panic-exiting when passed a string or null tagType instead of returning error
-	if m, ok := myMap.(map[string]interface{}); ok {
-		if tagType, ok := m["tagType"].(float64); ok {
-			if tagType == 0 {
-				// not expecting a 0 tag, but if it occurs just ignore it
-				return nil
+// encode converts the JSON document in r, writing the NBT to w. If the document has a level.dat header the tags are
+// buffered so the header can be written with their length.
+func (e *Encoder) encode(r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return JsonParseError{s: "Error parsing JSON input. Is input JSON-formatted?", e: err}
+	}
+	if tok != json.Delim('{') {
+		return JsonParseError{s: "Error parsing JSON input. Is input JSON-formatted?"}
+	}
+	numTags := 0
+	var header *NbtHeader
+	var compression *NbtCompression
+	var nbtOut io.WriteCloser = nopWriteCloser{w}
+	var out io.Writer = nbtOut
+	var body bytes.Buffer
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return JsonParseError{s: "Error parsing JSON input. Is input JSON-formatted?", e: err}
+		}
+		if tok == "header" {
+			err = dec.Decode(&header)
+			if err != nil {
+				return JsonParseError{s: "Error parsing top-level value header", e: err}
+			}
+			if header != nil && numTags > 0 {
+				return JsonParseError{s: "header must come before nbt in the JSON input"}
+			}
+			if header != nil {
+				out = &body
 			}
-			err = binary.Write(w, byteOrder, byte(tagType))
+			continue
+		}
+		if tok == "compression" {
+			err = dec.Decode(&compression)
+			if err != nil {
+				return JsonParseError{s: "Error parsing top-level value compression", e: err}
+			}
+			if compression == nil {
+				continue
+			}
+			if e.c.uncompressed {
+				_, err = compression.compression()
+			} else if numTags > 0 {
+				return JsonParseError{s: "compression must come before nbt in the JSON input"}
+			} else {
+				nbtOut, err = compression.newWriter(w)
+				if header == nil {
+					out = nbtOut
+				}
+			}
+			if err != nil {
+				return JsonParseError{s: "Error in top-level value compression", e: err}
+			}
+			continue
+		}
+		if tok != "nbt" {
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+			if err != nil {
+				return JsonParseError{s: fmt.Sprintf("Error parsing top-level value %v", tok), e: err}
+			}
+			key, _ := tok.(string)
+			err = e.c.checkJsonFields(map[string]interface{}{key: skip}, documentFields...)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		tok, err = dec.Token()
+		if err != nil {
+			return JsonParseError{s: "Error unmarshalling nbt: value", e: err}
+		}
+		if tok == nil {
+			continue
+		}
+		if tok != json.Delim('[') {
+			return JsonParseError{s: fmt.Sprintf("nbt: value '%v' is not an array", tok)}
+		}
+		for dec.More() {
+			err = e.encodeTag(dec, out, false)
 			if err != nil {
-				return JsonParseError{"Error writing tagType" + string(byte(tagType)), err}
+				return err
 			}
-			if name, ok := m["name"].(string); ok {
-				err = binary.Write(w, byteOrder, int16(len(name)))
+			numTags++
+		}
+		// closing ]
+		_, err = dec.Token()
+		if err != nil {
+			return JsonParseError{s: "Error unmarshalling nbt: value", e: err}
+		}
+	}
+	// closing }, which must be the end of the input
+	_, err = dec.Token()
+	if err == nil {
+		_, err = dec.Token()
+		if err == io.EOF {
+			err = nil
+		} else if err == nil {
+			err = errors.New("unexpected data after the JSON document")
+		}
+	}
+	if err != nil {
+		return JsonParseError{s: "Error parsing JSON input. Is input JSON-formatted?", e: err}
+	}
+	if numTags == 0 {
+		return JsonParseError{s: "JSON input has no top-level value named nbt. JSON-encoded nbt data should be in an array { \"nbt\": [ <HERE> ] }"}
+	}
+	if header != nil {
+		err = writeHeader(nbtOut, header, body.Len())
+		if err != nil {
+			return JsonParseError{s: "Error writing level.dat header", e: err}
+		}
+		_, err = body.WriteTo(nbtOut)
+		if err != nil {
+			return JsonParseError{s: "Error writing nbt", e: err}
+		}
+	}
+	err = nbtOut.Close()
+	if err != nil {
+		return JsonParseError{s: "Error compressing nbt", e: err}
+	}
+	return nil
+}
+
+// encodeTag reads one tag object. Compound and list values are streamed if the tagType and name come before
+// the value, as they do in nbt2json output; otherwise the tag is collected and converted by tagFromJson. Errors get
+// the tag's location, and child tags add their name to the path.
+func (e *Encoder) encodeTag(dec *json.Decoder, w io.Writer, child bool) (err error) {
+	offset := dec.InputOffset()
+	m := make(map[string]interface{})
+	defer func() {
+		if err != nil {
+			var segment string
+			if child {
+				name, _ := stringFromJson(m["name"])
+				segment = "/" + name
+			}
+			tagType, _ := m["tagType"].(float64)
+			err = jsonErrorAt(err, offset, segment, byte(tagType))
+		}
+	}()
+	tok, err := dec.Token()
+	if err != nil {
+		return JsonParseError{s: "Error reading tag", e: err}
+	}
+	if tok != json.Delim('{') {
+		return JsonParseError{s: fmt.Sprintf("tag '%v' is not an object", tok)}
+	}
+	offset = dec.InputOffset() - 1
+	streamed := false
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return JsonParseError{s: "Error reading tag field name", e: err}
+		}
+		key, _ := tok.(string)
+		if key == "value" && !streamed {
+			tagType, typeOk := m["tagType"].(float64)
+			name, nameOk := stringFromJson(m["name"])
+			if typeOk && nameOk && (tagType == 9 || tagType == 10) {
+				err = e.c.writeTagHeader(w, byte(tagType), name)
 				if err != nil {
-					return JsonParseError{"Error writing name length", err}
+					return err
+				}
+				if tagType == 9 {
+					err = e.encodeList(dec, w)
+				} else {
+					err = e.encodeCompound(dec, w)
 				}
-				err = binary.Write(w, byteOrder, []byte(name))
 				if err != nil {
-					return JsonParseError{"Error converting name", err}
+					return err
 				}
-			} else {
-				return JsonParseError{fmt.Sprintf("name field '%v' not a string", m["name"]), err}
+				streamed = true
+				continue
+			}
+		}
+		var value interface{}
+		err = dec.Decode(&value)
+		if err != nil {
+			return JsonParseError{s: fmt.Sprintf("Error reading tag field %s", key), e: err}
+		}
+		m[key] = value
+	}
+	// closing }
+	_, err = dec.Token()
+	if err != nil {
+		return JsonParseError{s: "Error reading tag", e: err}
+	}
+	if streamed {
+		return e.c.checkJsonFields(m, tagFields...)
+	}
+	tag, err := e.c.tagFromJson(m)
+	if err != nil {
+		return err
+	}
+	if _, ok := tag.Value.(End); ok {
+		// not expecting a 0 tag, but if it occurs just ignore it
+		return nil
+	}
+	return e.c.writeTag(w, tag)
+}
+
+// encodeCompound streams a compound's child tags and writes the end tag
+func (e *Encoder) encodeCompound(dec *json.Decoder, w io.Writer) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return JsonParseError{s: "Error reading Compound tags", e: err}
+	}
+	if tok != json.Delim('[') {
+		return JsonParseError{s: fmt.Sprintf("Tag 10 Compound value field '%v' not an array", tok)}
+	}
+	for dec.More() {
+		err = e.encodeTag(dec, w, true)
+		if err != nil {
+			return wrapJsonError("While writing Compound tags", err)
+		}
+	}
+	// closing ]
+	_, err = dec.Token()
+	if err != nil {
+		return JsonParseError{s: "Error reading Compound tags", e: err}
+	}
+	// write the end tag which is just a single byte 0
+	err = binary.Write(w, e.c.byteOrder, byte(0))
+	if err != nil {
+		return JsonParseError{s: "Writing End tag", e: err}
+	}
+	return nil
+}
+
+// encodeList reads a list's tagListType/list object. Items are buffered as NBT because the list length
+// is written before them. If the list comes before its tagListType, the list is collected and converted by payloadFromJson.
+func (e *Encoder) encodeList(dec *json.Decoder, w io.Writer) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return JsonParseError{s: "Error reading tag 9 list", e: err}
+	}
+	if tok != json.Delim('{') {
+		return JsonParseError{s: fmt.Sprintf("Tag 9 List value field '%v' not an object", tok)}
+	}
+	listMap := make(map[string]interface{})
+	var items bytes.Buffer
+	var numItems int32
+	var tagListType byte
+	streamed := false
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return JsonParseError{s: "Error reading tag 9 list field name", e: err}
+		}
+		key, _ := tok.(string)
+		if _, ok := listMap["tagListType"].(float64); ok && key == "list" && !streamed {
+			tagListType, err = jsonTagType(listMap["tagListType"])
+			if err != nil {
+				return JsonParseError{s: "Tag 9 List's tagListType", e: err}
 			}
-			err = writePayload(w, m, tagType)
+			numItems, err = e.encodeListItems(dec, &items, tagListType)
 			if err != nil {
 				return err
 			}
+			streamed = true
+			continue
+		}
+		var value interface{}
+		err = dec.Decode(&value)
+		if err != nil {
+			return JsonParseError{s: fmt.Sprintf("Error reading tag 9 list field %s", key), e: err}
+		}
+		listMap[key] = value
+	}
+	// closing }
+	_, err = dec.Token()
+	if err != nil {
+		return JsonParseError{s: "Error reading tag 9 list", e: err}
+	}
+	if !streamed {
+		tagList, err := e.c.payloadFromJson(listMap, 9)
+		if err != nil {
+			return err
+		}
+		return e.c.writePayload(w, tagList)
+	}
+	err = e.c.checkJsonFields(listMap, listFields...)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(w, e.c.byteOrder, tagListType)
+	if err != nil {
+		return JsonParseError{s: "While writing tag 9 list type", e: err}
+	}
+	err = e.c.writeInt(w, numItems)
+	if err != nil {
+		return JsonParseError{s: "While writing tag 9 list size", e: err}
+	}
+	_, err = items.WriteTo(w)
+	if err != nil {
+		return JsonParseError{s: "While writing tag 9 list items", e: err}
+	}
+	return nil
+}
+
+// encodeListItems writes the items of a list's array to w and returns how many there were
+func (e *Encoder) encodeListItems(dec *json.Decoder, w io.Writer, tagListType byte) (int32, error) {
+	var numItems int32
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, JsonParseError{s: "Error reading tag 9 list", e: err}
+	}
+	if tok == nil {
+		// NBT lists can be null / nil and therefore aren't represented as an array in JSON
+		return 0, nil
+	}
+	if tok != json.Delim('[') {
+		return 0, JsonParseError{s: fmt.Sprintf("Tag 9 List's value field '%v' not an array or null", tok)}
+	}
+	for dec.More() {
+		if tagListType == 0 {
+			return 0, JsonParseError{s: "Tag 9 List of tag type 0 can't have items"}
+		}
+		start := dec.InputOffset()
+		switch tagListType {
+		case 9:
+			err = e.encodeList(dec, w)
+		case 10:
+			err = e.encodeCompound(dec, w)
+		default:
+			var value interface{}
+			err = dec.Decode(&value)
+			if err != nil {
+				return 0, JsonParseError{s: "Error reading tag 9 list item", e: err}
+			}
+			var item Tag
+			item, err = e.c.payloadFromJson(value, float64(tagListType))
+			if err == nil {
+				err = e.c.writePayload(w, item)
+			}
+		}
+		if err != nil {
+			err = jsonErrorAt(err, start, fmt.Sprintf("[%d]", numItems), tagListType)
+			return 0, wrapJsonError("While writing tag 9 list of type "+strconv.Itoa(int(tagListType)), err)
+		}
+		numItems++
+	}
+	// closing ]
+	_, err = dec.Token()
+	if err != nil {
+		return 0, JsonParseError{s: "Error reading tag 9 list", e: err}
+	}
+	return numItems, nil
+}
+
+// Yaml2Tags converts YAML byte array to a tag tree using the module's default settings
+func Yaml2Tags(b []byte) ([]NamedTag, error) {
+	return defaultConverter.Yaml2Tags(b)
+}
+
+// Json2Tags converts JSON byte array to a tag tree using the module's default settings
+func Json2Tags(b []byte) ([]NamedTag, error) {
+	return defaultConverter.Json2Tags(b)
+}
+
+// Yaml2Tags converts YAML byte array to a tag tree
+func (c *Converter) Yaml2Tags(b []byte) ([]NamedTag, error) {
+	myJson, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return nil, JsonParseError{s: "Error converting YAML to JSON", e: err}
+	}
+	return c.Json2Tags(myJson)
+}
 
-		} else {
-			return JsonParseError{fmt.Sprintf("tagType '%v' is not an integer", m["tagType"]), err}
+// Json2Tags converts JSON byte array to a tag tree
+func (c *Converter) Json2Tags(b []byte) ([]NamedTag, error) {
+	var nbtJsonData NbtJson
+	var nbtArray []interface{}
+	var tags []NamedTag
+	err := json.Unmarshal(b, &nbtJsonData)
+	if err != nil {
+		return nil, JsonParseError{s: "Error parsing JSON input. Is input JSON-formatted?", e: err}
+	}
+	if c.strictJson {
+		var fields map[string]interface{}
+		json.Unmarshal(b, &fields)
+		err = c.checkJsonFields(fields, documentFields...)
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		return JsonParseError{"writeTag: myMap is not map[string]interface{}", err}
 	}
-	return err
+	if nbtJsonData.Compression != nil {
+		_, err = nbtJsonData.Compression.compression()
+		if err != nil {
+			return nil, JsonParseError{s: "Error in top-level value compression", e: err}
+		}
+	}
+	temp, err := json.Marshal(nbtJsonData.Nbt)
+	if err != nil {
+		return nil, JsonParseError{s: "Error marshalling nbt: json.RawMessage", e: err}
+	}
+	err = json.Unmarshal(temp, &nbtArray)
+	if err != nil {
+		return nil, JsonParseError{s: "Error unmarshalling nbt: value", e: err}
+	}
+	if len(nbtArray) == 0 {
+		return nil, JsonParseError{s: "JSON input has no top-level value named nbt. JSON-encoded nbt data should be in an array { \"nbt\": [ <HERE> ] }"}
+	}
+	for _, nbtTag := range nbtArray {
+		tag, err := c.tagFromJson(nbtTag)
+		if err != nil {
+			return nil, jsonErrorAt(err, -1, "", 0)
+		}
+		if _, ok := tag.Value.(End); ok {
+			// not expecting a 0 tag, but if it occurs just ignore it
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
 }
 
-func writePayload(w io.Writer, m map[string]interface{}, tagType float64) error {
-	var err error
+// tagFromJson converts one tagType/name/value object to a tag. Tag type 0 gives an End tag which callers ignore.
+func (c *Converter) tagFromJson(myMap interface{}) (NamedTag, error) {
+	var tag NamedTag
+	m, ok := myMap.(map[string]interface{})
+	if !ok {
+		return tag, JsonParseError{s: fmt.Sprintf("tag '%v' is not an object", myMap)}
+	}
+	tagType, err := jsonTagType(m["tagType"])
+	if err != nil {
+		return tag, err
+	}
+	if tagType == 0 {
+		tag.Value = End{}
+		return tag, nil
+	}
+	if tag.Name, ok = stringFromJson(m["name"]); !ok {
+		return tag, jsonErrorAt(JsonParseError{s: fmt.Sprintf("name field '%v' not a string", m["name"])}, -1, "", tagType)
+	}
+	err = c.checkStringLength(tag.Name)
+	if err != nil {
+		err = JsonParseError{s: "name is too long", e: err}
+	}
+	if err == nil {
+		err = c.checkJsonFields(m, tagFields...)
+	}
+	if err == nil {
+		tag.Value, err = c.payloadFromJson(m["value"], float64(tagType))
+	}
+	if err != nil {
+		return tag, jsonErrorAt(err, -1, "", tagType)
+	}
+	return tag, nil
+}
+
+// Fields of the objects in the JSON document, for UseStrictJson
+var (
+	documentFields = []string{"name", "version", "nbt2JsonUrl", "conversionTime", "compression", "comment", "header", "nbt"}
+	tagFields      = []string{"tagType", "name", "value"}
+	listFields     = []string{"tagListType", "list"}
+	longFields     = []string{"valueLeast", "valueMost"}
+)
+
+// checkJsonFields returns an error naming any fields of m that aren't known, if UseStrictJson is set
+func (c *Converter) checkJsonFields(m map[string]interface{}, known ...string) error {
+	if !c.strictJson {
+		return nil
+	}
+	var unknown []string
+	for key := range m {
+		isKnown := false
+		for _, k := range known {
+			isKnown = isKnown || key == k
+		}
+		if !isKnown {
+			unknown = append(unknown, strconv.Quote(key))
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return JsonParseError{s: fmt.Sprintf("Unknown fields %s; expected %s", strings.Join(unknown, ", "), strings.Join(known, ", "))}
+}
+
+// stringFromJson returns the name or string a JSON value holds, either a string or the {"raw": hex} form of an
+// NbtRawString
+func stringFromJson(value interface{}) (string, bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case map[string]interface{}:
+		raw, ok := value["raw"].(string)
+		if !ok || len(value) != 1 {
+			return "", false
+		}
+		b, err := hex.DecodeString(raw)
+		if err != nil {
+			return "", false
+		}
+		return string(b), true
+	}
+	return "", false
+}
 
+// jsonTagType checks a tagType or tagListType value is a tag type
+func jsonTagType(value interface{}) (byte, error) {
+	f, ok := value.(float64)
+	if !ok {
+		return 0, JsonParseError{s: fmt.Sprintf("tagType '%v' is not an integer", value)}
+	}
+	if f != math.Trunc(f) || f < 0 || f > 12 {
+		return 0, JsonParseError{s: fmt.Sprintf("tagType '%v' is not recognized", value)}
+	}
+	return byte(f), nil
+}
+
+// jsonListType checks a list's tagListType. Like in Minecraft, only empty lists can leave it out or be of type 0.
+func jsonListType(value interface{}, numItems int) (byte, error) {
+	if value == nil {
+		if numItems > 0 {
+			return 0, JsonParseError{s: "Tag 9 List has items but no tagListType"}
+		}
+		return 0, nil
+	}
+	tagListType, err := jsonTagType(value)
+	if err != nil {
+		return 0, JsonParseError{s: "Tag 9 List's tagListType", e: err}
+	}
+	if tagListType == 0 && numItems > 0 {
+		return 0, JsonParseError{s: "Tag 9 List of tag type 0 can't have items"}
+	}
+	return tagListType, nil
+}
+
+// jsonList checks a list's tagListType/list object and returns its list type and items. If the error is only about
+// unknown fields, the type and items are still returned.
+func (c *Converter) jsonList(value interface{}) (byte, []interface{}, error) {
+	listMap, ok := value.(map[string]interface{})
+	if !ok {
+		return 0, nil, JsonParseError{s: fmt.Sprintf("Tag 9 List value field '%v' not an object", value)}
+	}
+	var values []interface{}
+	if listMap["list"] != nil {
+		// NBT lists can be null / nil and therefore aren't represented as an array in JSON
+		if values, ok = listMap["list"].([]interface{}); !ok {
+			return 0, nil, JsonParseError{s: fmt.Sprintf("Tag 9 List's value field '%v' not an array or null", listMap["list"])}
+		}
+	}
+	tagListType, err := jsonListType(listMap["tagListType"], len(values))
+	if err != nil {
+		return 0, nil, err
+	}
+	return tagListType, values, c.checkJsonFields(listMap, listFields...)
+}
+
+// jsonInteger checks a number fits in an integer tag described by what. With UseStrictJson, fractions are an error;
+// otherwise they are truncated.
+func (c *Converter) jsonInteger(f float64, min float64, max float64, what string) error {
+	if f < min || f > max {
+		return JsonParseError{s: fmt.Sprintf("%v is out of range for %s", f, what)}
+	}
+	if c.strictJson && f != math.Trunc(f) {
+		return JsonParseError{s: fmt.Sprintf("%v is not an integer for %s", f, what)}
+	}
+	return nil
+}
+
+// jsonLong converts a valueLeast/valueMost object or number string to an int64. The halves can be written as signed
+// or unsigned 32-bit integers.
+func (c *Converter) jsonLong(value interface{}) (int64, error) {
+	if int64Map, ok := value.(map[string]interface{}); ok {
+		var nbtLong NbtLong
+		var vl, vm float64
+		if vl, ok = int64Map["valueLeast"].(float64); !ok {
+			return 0, JsonParseError{s: fmt.Sprintf("Error reading valueLeast of '%v'", int64Map["valueLeast"])}
+		}
+		if vm, ok = int64Map["valueMost"].(float64); !ok {
+			return 0, JsonParseError{s: fmt.Sprintf("Error reading valueMost of '%v'", int64Map["valueMost"])}
+		}
+		err := c.jsonInteger(vl, math.MinInt32, math.MaxUint32, "valueLeast")
+		if err == nil {
+			err = c.jsonInteger(vm, math.MinInt32, math.MaxUint32, "valueMost")
+		}
+		if err == nil {
+			err = c.checkJsonFields(int64Map, longFields...)
+		}
+		if err != nil {
+			return 0, err
+		}
+		nbtLong.ValueLeast = uint32(int64(vl))
+		nbtLong.ValueMost = uint32(int64(vm))
+		return intPairToLong(nbtLong), nil
+	} else if int64String, ok := value.(string); ok {
+		i, err := strconv.ParseInt(int64String, 10, 64)
+		if err != nil {
+			return 0, JsonParseError{s: fmt.Sprintf("Long value string field '%s' not an integer", int64String), e: err}
+		}
+		return i, nil
+	}
+	return 0, JsonParseError{s: fmt.Sprintf("Long value field '%v' not an object or string", value)}
+}
+
+// payloadFromJson converts a json value to a tag payload of the given type
+func (c *Converter) payloadFromJson(value interface{}, tagType float64) (Tag, error) {
 	switch tagType {
 	case 1:
-		if i, ok := m["value"].(float64); ok {
-			if i < math.MinInt8 || i > math.MaxInt8 {
-				return JsonParseError{fmt.Sprintf("%v is out of range for tag 1 - Byte", i), nil}
+		if i, ok := value.(float64); ok {
+			if err := c.jsonInteger(i, math.MinInt8, math.MaxInt8, "tag 1 - Byte"); err != nil {
+				return nil, err
 			}
-			err = binary.Write(w, byteOrder, int8(i))
-			if err != nil {
-				return JsonParseError{"Error writing byte payload", err}
-			}
-		} else {
-			return JsonParseError{fmt.Sprintf("Tag 1 Byte value field '%v' not an integer", m["value"]), err}
+			return Byte(i), nil
 		}
+		return nil, JsonParseError{s: fmt.Sprintf("Tag 1 Byte value field '%v' not an integer", value)}
 	case 2:
-		if i, ok := m["value"].(float64); ok {
-			if i < math.MinInt16 || i > math.MaxInt16 {
-				return JsonParseError{fmt.Sprintf("%v is out of range for tag 2 - Short", i), nil}
-			}
-			err = binary.Write(w, byteOrder, int16(i))
-			if err != nil {
-				return JsonParseError{"Error writing short payload", err}
+		if i, ok := value.(float64); ok {
+			if err := c.jsonInteger(i, math.MinInt16, math.MaxInt16, "tag 2 - Short"); err != nil {
+				return nil, err
 			}
-		} else {
-			return JsonParseError{fmt.Sprintf("Tag 2 Short value field '%v' not an integer", m["value"]), err}
+			return Short(i), nil
 		}
+		return nil, JsonParseError{s: fmt.Sprintf("Tag 2 Short value field '%v' not an integer", value)}
 	case 3:
-		if i, ok := m["value"].(float64); ok {
-			if i < math.MinInt32 || i > math.MaxInt32 {
-				return JsonParseError{fmt.Sprintf("%v is out of range for tag 3 - Int", i), nil}
-			}
-			err = binary.Write(w, byteOrder, int32(i))
-			if err != nil {
-				return JsonParseError{"Error writing int32 payload", err}
+		if i, ok := value.(float64); ok {
+			if err := c.jsonInteger(i, math.MinInt32, math.MaxInt32, "tag 3 - Int"); err != nil {
+				return nil, err
 			}
-		} else {
-			return JsonParseError{fmt.Sprintf("Tag 3 Int value field '%v' not an integer", m["value"]), err}
+			return Int(i), nil
 		}
+		return nil, JsonParseError{s: fmt.Sprintf("Tag 3 Int value field '%v' not an integer", value)}
 	case 4:
-		if int64Map, ok := m["value"].(map[string]interface{}); ok {
-			var nbtLong NbtLong
-			var vl, vm float64
-			if vl, ok = int64Map["valueLeast"].(float64); !ok {
-				return JsonParseError{fmt.Sprintf("Error reading valueLeast of '%v'", int64Map["valueLeast"]), nil}
-			}
-			nbtLong.ValueLeast = uint32(vl)
-			if vm, ok = int64Map["valueMost"].(float64); !ok {
-				return JsonParseError{fmt.Sprintf("Error reading valueMost of '%v'", int64Map["valueMost"]), nil}
-			}
-			nbtLong.ValueMost = uint32(vm)
-			err = binary.Write(w, byteOrder, int64(intPairToLong(nbtLong)))
-			if err != nil {
-				return JsonParseError{"Error writing int64 (from uint32 pair) payload:", err}
-			}
-		} else if int64String, ok := m["value"].(string); ok {
-			i, err := strconv.ParseInt(int64String, 10, 64)
-			if err != nil {
-				return JsonParseError{"Error converting long as string payload:", err}
-			}
-			err = binary.Write(w, byteOrder, i)
-			if err != nil {
-				return JsonParseError{"Error writing int64 (from string) payload:", err}
-			}
-			if err != nil {
-				return JsonParseError{fmt.Sprintf("Tag 4 Long value string field '%s' not an integer", int64String), err}
-			}
-		} else {
-			return JsonParseError{fmt.Sprintf("Tag 4 Long value field '%v' not an object", m["value"]), err}
+		i, err := c.jsonLong(value)
+		if err != nil {
+			return nil, JsonParseError{s: "Error converting tag 4 Long payload", e: err}
 		}
+		return Long(i), nil
 	case 5:
-		if f, ok := m["value"].(float64); ok {
+		if f, ok := value.(float64); ok {
 			// Comparing to smallest/max values is causing false errors as the nbt value comes out right even if this comparison doesn't
 			// Instead, will check for positive/negative infinity. Not sure what happens if f is too small for float32, but is likely edge case
 			// if f != 0 && (math.Abs(f) < math.SmallestNonzeroFloat32 || math.Abs(f) > math.MaxFloat32) {
 			if math.IsInf(float64(float32(f)), 0) {
-				return JsonParseError{fmt.Sprintf("%g is out of range for tag 5 - Float", f), nil}
+				return nil, JsonParseError{s: fmt.Sprintf("%g is out of range for tag 5 - Float", f)}
 			}
-			err = binary.Write(w, byteOrder, float32(f))
-			if err != nil {
-				return JsonParseError{"Error writing float32 payload", err}
-			}
-		} else {
-			// If NaN is valid for double, maybe it's valid for float?
-			// return JsonParseError{fmt.Sprintf("Tag 5 Float value field '%v' not a number", m["value"]), err}
-			f = math.NaN()
-			err = binary.Write(w, byteOrder, float32(f))
-			if err != nil {
-				return JsonParseError{"Error writing float64 payload", err}
+			return Float(f), nil
+		}
+		if s, ok := value.(string); ok {
+			if bits, ok := specialFloatBits(s, 32); ok {
+				return Float(math.Float32frombits(uint32(bits))), nil
 			}
 		}
+		return nil, JsonParseError{s: fmt.Sprintf("Tag 5 Float value field '%v' not a number, Infinity, -Infinity, NaN or -0", value)}
 	case 6:
-		if f, ok := m["value"].(float64); ok {
-			err = binary.Write(w, byteOrder, f)
-			if err != nil {
-				return JsonParseError{"Error writing float64 payload", err}
-			}
-		} else {
-			// Apparently NaN is a valid value in Minecraft for double?
-			// return JsonParseError{fmt.Sprintf("Tag 6 Double value field '%v' not a number", m["value"]), err}
-			f = math.NaN()
-			err = binary.Write(w, byteOrder, f)
-			if err != nil {
-				return JsonParseError{"Error writing float64 payload", err}
+		if f, ok := value.(float64); ok {
+			return Double(f), nil
+		}
+		if s, ok := value.(string); ok {
+			if bits, ok := specialFloatBits(s, 64); ok {
+				return Double(math.Float64frombits(bits)), nil
 			}
 		}
+		return nil, JsonParseError{s: fmt.Sprintf("Tag 6 Double value field '%v' not a number, Infinity, -Infinity, NaN or -0", value)}
 	case 7:
-		if values, ok := m["value"].([]interface{}); ok {
-			err = binary.Write(w, byteOrder, int32(len(values)))
-			if err != nil {
-				return JsonParseError{"Error writing byte array length", err}
-			}
-			for _, value := range values {
-				if i, ok := value.(float64); ok {
-					if i < math.MinInt8 || i > math.MaxInt8 {
-						return JsonParseError{fmt.Sprintf("%v is out of range for Byte in tag 7 - Byte Array", i), nil}
-					}
-					err = binary.Write(w, byteOrder, int8(i))
-					if err != nil {
-						return JsonParseError{"Error writing element of byte array", err}
-					}
-				} else {
-					return JsonParseError{fmt.Sprintf("Tag 7 Byte Array element value field '%v' not an integer", m["value"]), err}
+		// Arrays used to be written as null when empty
+		values, ok := value.([]interface{})
+		if !ok && value != nil {
+			return nil, JsonParseError{s: fmt.Sprintf("Tag 7 Byte Array value field '%v' not an array", value)}
+		}
+		byteArray := make(ByteArray, 0, len(values))
+		for _, value := range values {
+			if i, ok := value.(float64); ok {
+				if err := c.jsonInteger(i, math.MinInt8, math.MaxInt8, "Byte in tag 7 - Byte Array"); err != nil {
+					return nil, err
 				}
+				byteArray = append(byteArray, int8(i))
+			} else {
+				return nil, JsonParseError{s: fmt.Sprintf("Tag 7 Byte Array element value field '%v' not an integer", value)}
 			}
-		} else {
-			return JsonParseError{fmt.Sprintf("Tag 7 Byte Array value field '%v' not an array", m["value"]), err}
 		}
+		return byteArray, nil
 	case 8:
-		if s, ok := m["value"].(string); ok {
-			err = binary.Write(w, byteOrder, int16(len([]byte(s))))
-			if err != nil {
-				return JsonParseError{"Error writing string length", err}
+		if s, ok := stringFromJson(value); ok {
+			if err := c.checkStringLength(s); err != nil {
+				return nil, JsonParseError{s: "Tag 8 String value is too long", e: err}
 			}
-			err = binary.Write(w, byteOrder, []byte(s))
-			if err != nil {
-				return JsonParseError{"Error writing string payload", err}
-			}
-		} else {
-			return JsonParseError{fmt.Sprintf("Tag 8 String value field '%v' not a string", m["value"]), err}
+			return String(s), nil
 		}
+		return nil, JsonParseError{s: fmt.Sprintf("Tag 8 String value field '%v' not a string", value)}
 	case 9:
-		// important: tagListType needs to be in scope to be passed to writePayload
-		// := were keeping it in a lower scope and zeroing it out.
-		var tagListType float64
-		if listMap, ok := m["value"].(map[string]interface{}); ok {
-			if tagListType, ok = listMap["tagListType"].(float64); ok {
-				err = binary.Write(w, byteOrder, byte(tagListType))
-				if err != nil {
-					return JsonParseError{"While writing tag 9 list type", err}
-				}
-			}
-			if values, ok := listMap["list"].([]interface{}); ok {
-				err = binary.Write(w, byteOrder, int32(len(values)))
-				if err != nil {
-					return JsonParseError{"While writing tag 9 list size", err}
-				}
-				for _, value := range values {
-					fakeTag := make(map[string]interface{})
-					fakeTag["value"] = value
-					err = writePayload(w, fakeTag, tagListType)
-					if err != nil {
-						return JsonParseError{"While writing tag 9 list of type " + strconv.Itoa(int(tagListType)), err}
-					}
-				}
-			} else if listMap["list"] == nil {
-				// NBT lists can be null / nil and therefore aren't represented as an array in JSON
-				err = binary.Write(w, byteOrder, int32(0))
-				if err != nil {
-					return JsonParseError{"While writing tag 9 list null size", err}
-				}
-				return nil
-			} else {
-				return JsonParseError{fmt.Sprintf("Tag 9 List's value field '%v' not an array or null", listMap["list"]), err}
+		tagListType, values, err := c.jsonList(value)
+		if err != nil {
+			return nil, err
+		}
+		tagList := List{TagListType: tagListType}
+		for i, value := range values {
+			item, err := c.payloadFromJson(value, float64(tagListType))
+			if err != nil {
+				err = jsonErrorAt(err, -1, fmt.Sprintf("[%d]", i), tagListType)
+				return nil, wrapJsonError("While writing tag 9 list of type "+strconv.Itoa(int(tagListType)), err)
 			}
-
-		} else {
-			return JsonParseError{fmt.Sprintf("Tag 9 List value field '%v' not an object", m["value"]), err}
+			tagList.Items = append(tagList.Items, item)
 		}
+		return tagList, nil
 	case 10:
-		if values, ok := m["value"].([]interface{}); ok {
-			for _, value := range values {
-				err = writeTag(w, value)
-				if err != nil {
-					return JsonParseError{"While writing Compound tags", err}
-				}
-			}
-			// write the end tag which is just a single byte 0
-			err = binary.Write(w, byteOrder, byte(0))
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil, JsonParseError{s: fmt.Sprintf("Tag 10 Compound value field '%v' not an array", value)}
+		}
+		compound := Compound{}
+		for _, value := range values {
+			tag, err := c.tagFromJson(value)
 			if err != nil {
-				return JsonParseError{"Writing End tag", err}
+				return nil, wrapJsonError("While writing Compound tags", jsonErrorAt(err, -1, "/"+tag.Name, 0))
+			}
+			if _, ok := tag.Value.(End); ok {
+				// not expecting a 0 tag, but if it occurs just ignore it
+				continue
 			}
-		} else {
-			return JsonParseError{fmt.Sprintf("Tag 10 Compound value field '%v' not an array", m["value"]), err}
+			compound = append(compound, tag)
 		}
+		return compound, nil
 	case 11:
-		if values, ok := m["value"].([]interface{}); ok {
-			err = binary.Write(w, byteOrder, int32(len(values)))
-			if err != nil {
-				return JsonParseError{"Error writing int32 array length", err}
-			}
-			for _, value := range values {
-				if i, ok := value.(float64); ok {
-					if i < math.MinInt32 || i > math.MaxInt32 {
-						return JsonParseError{fmt.Sprintf("%v is out of range for Int in tag 11 - Int Array", i), nil}
-					}
-					err = binary.Write(w, byteOrder, int32(i))
-					if err != nil {
-						return JsonParseError{"Error writing element of int32 array", err}
-					}
-				} else {
-					return JsonParseError{fmt.Sprintf("Tag 11 Int Array element value field '%v' not an integer", value), err}
+		values, ok := value.([]interface{})
+		if !ok && value != nil {
+			return nil, JsonParseError{s: fmt.Sprintf("Tag Int Array value field '%v' not an array", value)}
+		}
+		intArray := make(IntArray, 0, len(values))
+		for _, value := range values {
+			if i, ok := value.(float64); ok {
+				if err := c.jsonInteger(i, math.MinInt32, math.MaxInt32, "Int in tag 11 - Int Array"); err != nil {
+					return nil, err
 				}
+				intArray = append(intArray, int32(i))
+			} else {
+				return nil, JsonParseError{s: fmt.Sprintf("Tag 11 Int Array element value field '%v' not an integer", value)}
 			}
-		} else {
-			return JsonParseError{fmt.Sprintf("Tag Int Array value field '%v' not an array", m["value"]), err}
 		}
+		return intArray, nil
 	case 12:
-		if values, ok := m["value"].([]interface{}); ok {
-			err = binary.Write(w, byteOrder, int64(len(values)))
+		values, ok := value.([]interface{})
+		if !ok && value != nil {
+			return nil, JsonParseError{s: fmt.Sprintf("Tag 12 Long Array element value field '%v' not an array", value)}
+		}
+		longArray := make(LongArray, 0, len(values))
+		for _, value := range values {
+			i, err := c.jsonLong(value)
 			if err != nil {
-				return JsonParseError{"Error writing int64 array length", err}
-			}
-			for _, value := range values {
-				if int64Map, ok := value.(map[string]interface{}); ok {
-					var nbtLong NbtLong
-					var vl, vm float64
-					if vl, ok = int64Map["valueLeast"].(float64); !ok {
-						return JsonParseError{fmt.Sprintf("Error reading valueLeast of '%v'", int64Map["valueLeast"]), nil}
-					}
-					nbtLong.ValueLeast = uint32(vl)
-					if vm, ok = int64Map["valueMost"].(float64); !ok {
-						return JsonParseError{fmt.Sprintf("Error reading valueMost of '%v'", int64Map["valueMost"]), nil}
-					}
-					nbtLong.ValueMost = uint32(vm)
-					// if i, ok := value.(float64); ok {
-					err = binary.Write(w, byteOrder, int64(intPairToLong(nbtLong)))
-					if err != nil {
-						return JsonParseError{"Error writing element of int64 array", err}
-					}
-				} else if int64String, ok := value.(string); ok {
-					i, err := strconv.ParseInt(int64String, 10, 64)
-					if err != nil {
-						return JsonParseError{"Error converting long array element as string payload:", err}
-					}
-					err = binary.Write(w, byteOrder, i)
-					if err != nil {
-						return JsonParseError{"Error writing int64 array element (from string) payload", err}
-					}
-					if err != nil {
-						return JsonParseError{fmt.Sprintf("Tag 4 Long Array element value string field '%s' not an integer", int64String), err}
-					}
-				} else {
-					return JsonParseError{fmt.Sprintf("Tag Long Array element value field '%v' not an object", value), err}
-				}
+				return nil, JsonParseError{s: "Error converting element of tag 12 Long Array", e: err}
 			}
-		} else {
-			return JsonParseError{fmt.Sprintf("Tag 12 Long Array element value field '%v' not an array", m["value"]), err}
+			longArray = append(longArray, i)
 		}
+		return longArray, nil
 	default:
-		return JsonParseError{fmt.Sprintf("tagType '%v' is not recognized", tagType), err}
+		return nil, JsonParseError{s: fmt.Sprintf("tagType '%v' is not recognized", tagType)}
 	}
-	return err
 }
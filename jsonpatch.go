@@ -0,0 +1,458 @@
+package nbt2json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Patches are applied to generic JSON values: *jsonObject, []interface{}, json.Number, string, bool and nil, plus the
+// number and string tags of tagView
+
+// jsonObject is a JSON object that keeps its key order, since compound children have an order
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newJsonObject() *jsonObject {
+	return &jsonObject{values: make(map[string]interface{})}
+}
+
+func (o *jsonObject) get(key string) (interface{}, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// set replaces the value of key, or appends it if it's new
+func (o *jsonObject) set(key string, v interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+func (o *jsonObject) remove(key string) bool {
+	if _, ok := o.values[key]; !ok {
+		return false
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i:i], o.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// MarshalJSON writes the object's keys in order
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeJson decodes JSON keeping object key order and exact numbers
+func decodeJson(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	v, err := decodeJsonValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+	return v, nil
+}
+
+func decodeJsonValue(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		o := newJsonObject()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeJsonValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			o.set(key.(string), v)
+		}
+		_, err = dec.Token()
+		return o, err
+	case json.Delim('['):
+		a := []interface{}{}
+		for dec.More() {
+			v, err := decodeJsonValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		_, err = dec.Token()
+		return a, err
+	}
+	return token, nil
+}
+
+// copyJson returns a deep copy of a JSON value
+func copyJson(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *jsonObject:
+		o := newJsonObject()
+		for _, key := range v.keys {
+			o.set(key, copyJson(v.values[key]))
+		}
+		return o
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i := range v {
+			a[i] = copyJson(v[i])
+		}
+		return a
+	}
+	return v
+}
+
+// jsonEqual compares JSON values as JSON Patch's test operation does. Object key order doesn't matter, and numbers
+// are equal if their values are.
+func jsonEqual(a, b interface{}) bool {
+	a, b = plainView(a), plainView(b)
+	switch a := a.(type) {
+	case *jsonObject:
+		b, ok := b.(*jsonObject)
+		if !ok || len(a.keys) != len(b.keys) {
+			return false
+		}
+		for _, key := range a.keys {
+			bv, ok := b.get(key)
+			if !ok || !jsonEqual(a.values[key], bv) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if a == b {
+			return true
+		}
+		af, errA := a.Float64()
+		bf, errB := b.Float64()
+		return errA == nil && errB == nil && af == bf
+	}
+	return a == b
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped reference tokens
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("JSON pointer %q doesn't start with /", s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(tokens[i], "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses an array index reference token. With end set, "-" is the index after the last item.
+func arrayIndex(token string, length int, end bool) (int, error) {
+	if token == "-" && end {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) || strings.HasPrefix(token, "+") {
+		return 0, fmt.Errorf("%q is not an array index", token)
+	}
+	max := length - 1
+	if end {
+		max = length
+	}
+	if i > max {
+		return 0, fmt.Errorf("array index %d is out of range", i)
+	}
+	return i, nil
+}
+
+// jsonGet returns the value a pointer refers to
+func jsonGet(doc interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch container := doc.(type) {
+		case *jsonObject:
+			v, ok := container.get(token)
+			if !ok {
+				return nil, fmt.Errorf("%q not found", token)
+			}
+			doc = v
+		case []interface{}:
+			i, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = container[i]
+		default:
+			return nil, fmt.Errorf("%q can't be found in a value that isn't an object or array", token)
+		}
+	}
+	return doc, nil
+}
+
+// jsonModify calls op on the object or array containing the last token of a pointer and returns the document with
+// op's returned container in its place
+func jsonModify(doc interface{}, tokens []string, op func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return op(doc, tokens[0])
+	}
+	child, err := jsonGet(doc, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = jsonModify(child, tokens[1:], op)
+	if err != nil {
+		return nil, err
+	}
+	switch container := doc.(type) {
+	case *jsonObject:
+		container.set(tokens[0], child)
+	case []interface{}:
+		i, _ := arrayIndex(tokens[0], len(container), false)
+		container[i] = child
+	}
+	return doc, nil
+}
+
+func jsonAdd(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return jsonModify(doc, tokens, func(container interface{}, token string) (interface{}, error) {
+		switch container := container.(type) {
+		case *jsonObject:
+			container.set(token, value)
+			return container, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(container), true)
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[i+1:], container[i:])
+			container[i] = value
+			return container, nil
+		}
+		return nil, fmt.Errorf("can't add %q to a value that isn't an object or array", token)
+	})
+}
+
+func jsonRemove(doc interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("the whole document can't be removed")
+	}
+	return jsonModify(doc, tokens, func(container interface{}, token string) (interface{}, error) {
+		switch container := container.(type) {
+		case *jsonObject:
+			if !container.remove(token) {
+				return nil, fmt.Errorf("%q not found", token)
+			}
+			return container, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(container[:i:i], container[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("%q can't be found in a value that isn't an object or array", token)
+	})
+}
+
+func jsonReplace(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if _, err := jsonGet(doc, tokens); err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return jsonModify(doc, tokens, func(container interface{}, token string) (interface{}, error) {
+		switch container := container.(type) {
+		case *jsonObject:
+			container.set(token, value)
+		case []interface{}:
+			i, _ := arrayIndex(token, len(container), false)
+			container[i] = value
+		}
+		return container, nil
+	})
+}
+
+// applyJsonPatch applies an RFC 6902 JSON Patch document to doc. doc is changed, so pass a copy if the original is
+// needed when the patch fails.
+func applyJsonPatch(doc interface{}, patch []byte) (interface{}, error) {
+	decoded, err := decodeJson(patch)
+	if err != nil {
+		return nil, PatchError{"Reading JSON Patch", err}
+	}
+	ops, ok := decoded.([]interface{})
+	if !ok {
+		return nil, PatchError{"A JSON Patch must be an array of operations", nil}
+	}
+	for n, op := range ops {
+		doc, err = applyJsonPatchOp(doc, op)
+		if err != nil {
+			return nil, PatchError{fmt.Sprintf("Operation %d", n), err}
+		}
+	}
+	return doc, nil
+}
+
+func applyJsonPatchOp(doc interface{}, op interface{}) (interface{}, error) {
+	o, ok := op.(*jsonObject)
+	if !ok {
+		return nil, fmt.Errorf("operation is not an object")
+	}
+	member := func(name string) (string, error) {
+		v, ok := o.get(name)
+		s, isString := v.(string)
+		if !ok || !isString {
+			return "", fmt.Errorf("operation needs a string %q member", name)
+		}
+		return s, nil
+	}
+	opName, err := member("op")
+	if err != nil {
+		return nil, err
+	}
+	path, err := member("path")
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	value, hasValue := o.get("value")
+	switch opName {
+	case "add", "replace", "test":
+		if !hasValue {
+			return nil, fmt.Errorf("%s operation needs a value", opName)
+		}
+	}
+	var fromTokens []string
+	switch opName {
+	case "move", "copy":
+		from, err := member("from")
+		if err != nil {
+			return nil, err
+		}
+		fromTokens, err = parsePointer(from)
+		if err != nil {
+			return nil, err
+		}
+		value, err = jsonGet(doc, fromTokens)
+		if err != nil {
+			return nil, fmt.Errorf("from %s: %s", from, err.Error())
+		}
+	}
+
+	switch opName {
+	case "add":
+		return jsonAdd(doc, tokens, value)
+	case "remove":
+		return jsonRemove(doc, tokens)
+	case "replace":
+		return jsonReplace(doc, tokens, value)
+	case "move":
+		if len(tokens) > len(fromTokens) {
+			inside := true
+			for i := range fromTokens {
+				inside = inside && tokens[i] == fromTokens[i]
+			}
+			if inside {
+				return nil, fmt.Errorf("a value can't be moved into itself")
+			}
+		}
+		doc, err = jsonRemove(doc, fromTokens)
+		if err != nil {
+			return nil, err
+		}
+		return jsonAdd(doc, tokens, value)
+	case "copy":
+		return jsonAdd(doc, tokens, copyJson(value))
+	case "test":
+		current, err := jsonGet(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(current, value) {
+			return nil, fmt.Errorf("test failed: %s is not the expected value", path)
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("operation %q not recognized", opName)
+}
+
+// applyMergePatch applies an RFC 7386 JSON merge patch to target, which is changed
+func applyMergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(*jsonObject)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(*jsonObject)
+	if !ok {
+		t = newJsonObject()
+	}
+	for _, key := range p.keys {
+		v := p.values[key]
+		if v == nil {
+			t.remove(key)
+			continue
+		}
+		current, _ := t.get(key)
+		t.set(key, applyMergePatch(current, v))
+	}
+	return t
+}
@@ -0,0 +1,457 @@
+// Package leveldb reads and writes the LevelDB databases of Minecraft Bedrock Edition worlds, which keep chunks,
+// actors, players, villages and more in the world's db folder. Most values are little endian NBT; convert them with
+// nbt2json's default (Bedrock) settings.
+//
+// It reads Mojang's LevelDB variant, whose tables use zlib compression, as well as standard LevelDB. Writes are appended
+// to a new log file, which Minecraft and other LevelDB implementations replay the next time they open the database.
+// Don't use a database that Minecraft or another program has open.
+package leveldb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound is returned when a key isn't in the database
+var ErrNotFound = errors.New("key not found in database")
+
+// DbError is when the database can't be read or written. Pass it message string and downstream error
+type DbError struct {
+	s string
+	e error
+}
+
+func (e DbError) Error() string {
+	var s string
+	if e.e != nil {
+		s = fmt.Sprintf(": %s", e.e.Error())
+	}
+	return fmt.Sprintf("Error in LevelDB database: %s%s", e.s, s)
+}
+
+const numLevels = 7
+
+// Internal keys are the user key followed by a little endian uint64 of the sequence number << 8 | the value type
+const (
+	typeDeletion = 0
+	typeValue    = 1
+	maxSequence  = 1<<56 - 1
+)
+
+func userKey(ikey []byte) []byte {
+	if len(ikey) < 8 {
+		return ikey
+	}
+	return ikey[:len(ikey)-8]
+}
+
+func keyTrailer(ikey []byte) uint64 {
+	if len(ikey) < 8 {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(ikey[len(ikey)-8:])
+}
+
+func internalKey(key []byte, seq uint64, t byte) []byte {
+	ikey := make([]byte, len(key)+8)
+	copy(ikey, key)
+	binary.LittleEndian.PutUint64(ikey[len(key):], seq<<8|uint64(t))
+	return ikey
+}
+
+// compareInternalKeys orders by user key, then newest sequence number first
+func compareInternalKeys(a, b []byte) int {
+	if c := bytes.Compare(userKey(a), userKey(b)); c != 0 {
+		return c
+	}
+	ta, tb := keyTrailer(a), keyTrailer(b)
+	switch {
+	case ta > tb:
+		return -1
+	case ta < tb:
+		return 1
+	}
+	return 0
+}
+
+type fileMeta struct {
+	level             int
+	number            uint64
+	smallest, largest []byte
+}
+
+type memEntry struct {
+	seq     uint64
+	deleted bool
+	value   []byte
+}
+
+// DB is an open database. A DB is not safe for concurrent use.
+type DB struct {
+	dir          string
+	files        map[uint64]fileMeta
+	tables       map[uint64]*table
+	mem          map[string]memEntry
+	lastSequence uint64
+	nextFile     uint64
+	log          *logWriter
+	logFile      *os.File
+}
+
+var dbFileRegexp = regexp.MustCompile(`^(\d+)\.(log|ldb|sst)$|^MANIFEST-(\d+)$`)
+
+// Open opens the database in dir, which can be a world folder or its db folder
+func Open(dir string) (*DB, error) {
+	if _, err := os.Stat(filepath.Join(dir, "db", "CURRENT")); err == nil {
+		dir = filepath.Join(dir, "db")
+	}
+	db := &DB{
+		dir:    dir,
+		files:  make(map[uint64]fileMeta),
+		tables: make(map[uint64]*table),
+		mem:    make(map[string]memEntry),
+	}
+	current, err := ioutil.ReadFile(filepath.Join(dir, "CURRENT"))
+	if err != nil {
+		return nil, DbError{"Reading CURRENT", err}
+	}
+	manifest := strings.TrimSpace(string(current))
+	logNumber, prevLogNumber, err := db.readManifest(filepath.Join(dir, manifest))
+	if err != nil {
+		return nil, DbError{"Reading " + manifest, err}
+	}
+
+	// Replay logs that haven't been compacted into tables yet, oldest first
+	names, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, DbError{"Listing database files", err}
+	}
+	var logs []uint64
+	for _, fi := range names {
+		match := dbFileRegexp.FindStringSubmatch(fi.Name())
+		if match == nil {
+			continue
+		}
+		number, _ := strconv.ParseUint(match[1]+match[3], 10, 64)
+		if number >= db.nextFile {
+			db.nextFile = number + 1
+		}
+		if match[2] == "log" && (number >= logNumber || number == prevLogNumber) {
+			logs = append(logs, number)
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i] < logs[j] })
+	for _, number := range logs {
+		err = db.replayLog(filepath.Join(dir, fmt.Sprintf("%06d.log", number)))
+		if err != nil {
+			return nil, DbError{fmt.Sprintf("Replaying log %06d.log", number), err}
+		}
+	}
+	return db, nil
+}
+
+// readManifest applies the version edits in the MANIFEST to find the live tables
+func (db *DB) readManifest(name string) (logNumber, prevLogNumber uint64, err error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return 0, 0, err
+	}
+	records, err := readLogRecords(data)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, record := range records {
+		r := &editReader{b: record}
+		for len(r.b) > 0 && r.err == nil {
+			switch tag := r.uvarint(); tag {
+			case 1:
+				if comparator := string(r.bytes()); r.err == nil && comparator != "leveldb.BytewiseComparator" {
+					return 0, 0, fmt.Errorf("comparator %s not supported", comparator)
+				}
+			case 2:
+				logNumber = r.uvarint()
+			case 3:
+				if next := r.uvarint(); next > db.nextFile {
+					db.nextFile = next
+				}
+			case 4:
+				db.lastSequence = r.uvarint()
+			case 5:
+				// compaction pointer
+				r.uvarint()
+				r.bytes()
+			case 6:
+				r.uvarint()
+				delete(db.files, r.uvarint())
+			case 7:
+				var f fileMeta
+				f.level = int(r.uvarint())
+				f.number = r.uvarint()
+				r.uvarint()
+				f.smallest = r.bytes()
+				f.largest = r.bytes()
+				if f.level >= numLevels {
+					return 0, 0, fmt.Errorf("table level %d is too high", f.level)
+				}
+				db.files[f.number] = f
+			case 9:
+				prevLogNumber = r.uvarint()
+			default:
+				return 0, 0, fmt.Errorf("version edit field %d not recognized", tag)
+			}
+		}
+		if r.err != nil {
+			return 0, 0, r.err
+		}
+	}
+	return logNumber, prevLogNumber, nil
+}
+
+// editReader reads the varints and length-prefixed byte strings of manifest records and write batches
+type editReader struct {
+	b   []byte
+	err error
+}
+
+func (r *editReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.err = errors.New("bad varint")
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *editReader) bytes() []byte {
+	n := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.b)) {
+		r.err = errors.New("bad length")
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+// replayLog applies the write batches in a log. Each batch is a little endian uint64 sequence number and uint32
+// count, then that many puts (type 1, key, value) and deletes (type 0, key).
+func (db *DB) replayLog(name string) error {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	records, err := readLogRecords(data)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if len(record) < 12 {
+			return errors.New("write batch is too short")
+		}
+		seq := binary.LittleEndian.Uint64(record)
+		count := binary.LittleEndian.Uint32(record[8:])
+		r := &editReader{b: record[12:]}
+		for i := uint32(0); i < count; i++ {
+			if len(r.b) == 0 {
+				return errors.New("write batch is too short")
+			}
+			t := r.b[0]
+			r.b = r.b[1:]
+			entry := memEntry{seq: seq + uint64(i)}
+			key := r.bytes()
+			switch t {
+			case typeValue:
+				entry.value = r.bytes()
+			case typeDeletion:
+				entry.deleted = true
+			default:
+				return fmt.Errorf("write batch type %d not recognized", t)
+			}
+			if r.err != nil {
+				return r.err
+			}
+			if old, ok := db.mem[string(key)]; !ok || old.seq < entry.seq {
+				db.mem[string(key)] = entry
+			}
+		}
+		if last := seq + uint64(count) - 1; count > 0 && last > db.lastSequence {
+			db.lastSequence = last
+		}
+	}
+	return nil
+}
+
+// table returns an open table, opening it if needed
+func (db *DB) table(number uint64) (*table, error) {
+	if t, ok := db.tables[number]; ok {
+		return t, nil
+	}
+	name := filepath.Join(db.dir, fmt.Sprintf("%06d.ldb", number))
+	if _, err := os.Stat(name); os.IsNotExist(err) {
+		name = filepath.Join(db.dir, fmt.Sprintf("%06d.sst", number))
+	}
+	t, err := openTable(name)
+	if err != nil {
+		return nil, err
+	}
+	db.tables[number] = t
+	return t, nil
+}
+
+// Get returns the value of key, or ErrNotFound if the key isn't in the database
+func (db *DB) Get(key []byte) ([]byte, error) {
+	if entry, ok := db.mem[string(key)]; ok {
+		if entry.deleted {
+			return nil, ErrNotFound
+		}
+		return entry.value, nil
+	}
+	// Level 0 tables can overlap, so check the newest first. Tables in other levels don't overlap.
+	var candidates []fileMeta
+	for _, f := range db.files {
+		if bytes.Compare(key, userKey(f.smallest)) >= 0 && bytes.Compare(key, userKey(f.largest)) <= 0 {
+			candidates = append(candidates, f)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].level != candidates[j].level {
+			return candidates[i].level < candidates[j].level
+		}
+		return candidates[i].number > candidates[j].number
+	})
+	ikey := internalKey(key, maxSequence, typeValue)
+	for _, f := range candidates {
+		t, err := db.table(f.number)
+		if err != nil {
+			return nil, DbError{"Opening table", err}
+		}
+		entry, err := t.get(ikey)
+		if err != nil {
+			return nil, DbError{fmt.Sprintf("Reading table %06d", f.number), err}
+		}
+		if entry != nil {
+			if keyTrailer(entry.key)&0xff == typeDeletion {
+				return nil, ErrNotFound
+			}
+			return entry.value, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Keys returns every key in the database in order
+func (db *DB) Keys() ([][]byte, error) {
+	latest := make(map[string]memEntry)
+	for number := range db.files {
+		t, err := db.table(number)
+		if err != nil {
+			return nil, DbError{"Opening table", err}
+		}
+		err = t.entries(func(entry blockEntry) {
+			trailer := keyTrailer(entry.key)
+			key := string(userKey(entry.key))
+			if old, ok := latest[key]; !ok || old.seq < trailer>>8 {
+				latest[key] = memEntry{seq: trailer >> 8, deleted: trailer&0xff == typeDeletion}
+			}
+		})
+		if err != nil {
+			return nil, DbError{fmt.Sprintf("Reading table %06d", number), err}
+		}
+	}
+	for key, entry := range db.mem {
+		latest[key] = entry
+	}
+	var keys [][]byte
+	for key, entry := range latest {
+		if !entry.deleted {
+			keys = append(keys, []byte(key))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	return keys, nil
+}
+
+// Put sets the value of key
+func (db *DB) Put(key, value []byte) error {
+	return db.write(key, value, typeValue)
+}
+
+// Delete removes key from the database. Deleting a key that isn't there is not an error.
+func (db *DB) Delete(key []byte) error {
+	return db.write(key, nil, typeDeletion)
+}
+
+// write appends a one-entry write batch to the log, creating a new log file numbered after every existing file so it
+// is replayed last
+func (db *DB) write(key, value []byte, t byte) error {
+	if db.log == nil {
+		name := filepath.Join(db.dir, fmt.Sprintf("%06d.log", db.nextFile))
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+		if err != nil {
+			return DbError{"Creating log", err}
+		}
+		db.nextFile++
+		db.logFile = f
+		db.log = &logWriter{w: f}
+	}
+	seq := db.lastSequence + 1
+	batch := make([]byte, 12, 12+1+2*binary.MaxVarintLen64+len(key)+len(value))
+	binary.LittleEndian.PutUint64(batch, seq)
+	binary.LittleEndian.PutUint32(batch[8:], 1)
+	batch = append(batch, t)
+	batch = appendBytes(batch, key)
+	if t == typeValue {
+		batch = appendBytes(batch, value)
+	}
+	err := db.log.addRecord(batch)
+	if err == nil {
+		err = db.logFile.Sync()
+	}
+	if err != nil {
+		return DbError{"Writing log", err}
+	}
+	db.lastSequence = seq
+	db.mem[string(key)] = memEntry{seq: seq, deleted: t == typeDeletion, value: append([]byte(nil), value...)}
+	return nil
+}
+
+func appendBytes(b, data []byte) []byte {
+	var n [binary.MaxVarintLen64]byte
+	b = append(b, n[:binary.PutUvarint(n[:], uint64(len(data)))]...)
+	return append(b, data...)
+}
+
+// Close closes the database's files
+func (db *DB) Close() error {
+	var err error
+	for _, t := range db.tables {
+		if e := t.f.Close(); e != nil {
+			err = e
+		}
+	}
+	db.tables = make(map[uint64]*table)
+	if db.logFile != nil {
+		if e := db.logFile.Close(); e != nil {
+			err = e
+		}
+		db.logFile = nil
+		db.log = nil
+	}
+	return err
+}
@@ -0,0 +1,163 @@
+package leveldb
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	goleveldb "github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// TestDB reads a database written by goleveldb, writes to it, and checks goleveldb sees the writes
+func TestDB(t *testing.T) {
+	for _, compression := range []opt.Compression{opt.NoCompression, opt.SnappyCompression} {
+		dir := t.TempDir()
+		expected := make(map[string][]byte)
+
+		gdb, err := goleveldb.OpenFile(dir, &opt.Options{Compression: compression, BlockSize: 1024})
+		if err != nil {
+			t.Fatal("Error creating database:", err.Error())
+		}
+		// Enough keys for several blocks, compacted into tables, then more left in the log
+		for i := 0; i < 500; i++ {
+			key := ChunkKey(int32(i), int32(-i), Overworld, BlockEntity, 0)
+			value := bytes.Repeat([]byte{byte(i)}, i%50)
+			expected[string(key)] = value
+			if err := gdb.Put(key, value, nil); err != nil {
+				t.Fatal("Error writing database:", err.Error())
+			}
+		}
+		if err := gdb.CompactRange(util.Range{}); err != nil {
+			t.Fatal("Error compacting database:", err.Error())
+		}
+		for i := 0; i < 10; i++ {
+			key := []byte(fmt.Sprintf("player_%d", i))
+			expected[string(key)] = key
+			if err := gdb.Put(key, key, nil); err != nil {
+				t.Fatal("Error writing database:", err.Error())
+			}
+		}
+		deleted := ChunkKey(3, -3, Overworld, BlockEntity, 0)
+		if err := gdb.Delete(deleted, nil); err != nil {
+			t.Fatal("Error deleting from database:", err.Error())
+		}
+		delete(expected, string(deleted))
+		if err := gdb.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		db, err := Open(dir)
+		if err != nil {
+			t.Fatal("Error opening database:", err.Error())
+		}
+		checkDB(t, db, expected)
+
+		// Writes go to a new log that goleveldb replays
+		edited := ChunkKey(7, -7, Overworld, BlockEntity, 0)
+		expected[string(edited)] = []byte("edited")
+		added := ChunkKey(1, 2, Nether, Entity, 0)
+		expected[string(added)] = []byte("added")
+		if err := db.Put(edited, []byte("edited")); err != nil {
+			t.Fatal("Error writing:", err.Error())
+		}
+		if err := db.Put(added, []byte("added")); err != nil {
+			t.Fatal("Error writing:", err.Error())
+		}
+		if err := db.Delete([]byte("player_1")); err != nil {
+			t.Fatal("Error deleting:", err.Error())
+		}
+		delete(expected, "player_1")
+		checkDB(t, db, expected)
+		if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		gdb, err = goleveldb.OpenFile(dir, nil)
+		if err != nil {
+			t.Fatal("Error reopening database:", err.Error())
+		}
+		n := 0
+		iter := gdb.NewIterator(nil, nil)
+		for iter.Next() {
+			n++
+			if value, ok := expected[string(iter.Key())]; !ok || !bytes.Equal(value, iter.Value()) {
+				t.Errorf("goleveldb found %s = %q, expected %q", ParseKey(iter.Key()), iter.Value(), value)
+			}
+		}
+		iter.Release()
+		if n != len(expected) {
+			t.Errorf("goleveldb found %d keys, expected %d", n, len(expected))
+		}
+		gdb.Close()
+
+		db, err = Open(dir)
+		if err != nil {
+			t.Fatal("Error opening database after goleveldb:", err.Error())
+		}
+		checkDB(t, db, expected)
+		db.Close()
+	}
+}
+
+func checkDB(t *testing.T, db *DB, expected map[string][]byte) {
+	keys, err := db.Keys()
+	if err != nil {
+		t.Fatal("Error listing keys:", err.Error())
+	}
+	if len(keys) != len(expected) {
+		t.Errorf("Found %d keys, expected %d", len(keys), len(expected))
+	}
+	for _, key := range keys {
+		if _, ok := expected[string(key)]; !ok {
+			t.Errorf("Found unexpected key %s", ParseKey(key))
+		}
+	}
+	for key, value := range expected {
+		got, err := db.Get([]byte(key))
+		if err != nil {
+			t.Errorf("Error getting %s: %s", ParseKey([]byte(key)), err.Error())
+		} else if !bytes.Equal(got, value) {
+			t.Errorf("Getting %s expected %q, got %q", ParseKey([]byte(key)), value, got)
+		}
+	}
+	if _, err := db.Get([]byte("missing")); err != ErrNotFound {
+		t.Errorf("Getting missing key expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestKey checks keys are decoded and written as strings that parse back to the same key
+func TestKey(t *testing.T) {
+	keys := []struct {
+		raw    []byte
+		string string
+	}{
+		{ChunkKey(1, -2, Overworld, BlockEntity, 0), "chunk:1,-2,overworld,BlockEntity"},
+		{ChunkKey(-100, 5, Nether, SubChunkPrefix, -4), "chunk:-100,5,nether,SubChunkPrefix,-4"},
+		{ChunkKey(0, 0, TheEnd, Version, 0), "chunk:0,0,end,Version"},
+		{[]byte("~local_player"), "~local_player"},
+		{[]byte("BiomeData"), "BiomeData"},
+		{[]byte("actorprefix\x00\x01"), "hex:6163746f72707265666978" + "0001"},
+		{[]byte("chunk:x"), "hex:6368756e6b3a78"},
+	}
+	for _, test := range keys {
+		if s := ParseKey(test.raw).String(); s != test.string {
+			t.Errorf("Key % x expected %s, got %s", test.raw, test.string, s)
+		}
+		raw, err := ParseKeyString(test.string)
+		if err != nil {
+			t.Errorf("Error parsing %s: %s", test.string, err.Error())
+		} else if !bytes.Equal(raw, test.raw) {
+			t.Errorf("Parsing %s expected % x, got % x", test.string, test.raw, raw)
+		}
+	}
+	if raw, err := ParseKeyString("chunk:1,2,1,49"); err != nil || !bytes.Equal(raw, ChunkKey(1, 2, Nether, BlockEntity, 0)) {
+		t.Errorf("Parsing numeric chunk key expected % x, got % x %v", ChunkKey(1, 2, Nether, BlockEntity, 0), raw, err)
+	}
+	for _, bad := range []string{"chunk:1,2", "chunk:1,2,overworld,Nope", "chunk:1,2,overworld,SubChunkPrefix", "chunk:1,2,overworld,Entity,3", "hex:zz"} {
+		if _, err := ParseKeyString(bad); err == nil {
+			t.Errorf("Parsing %s failed to throw error", bad)
+		}
+	}
+}
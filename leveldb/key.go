@@ -0,0 +1,262 @@
+package leveldb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Bedrock chunk keys are the chunk's little endian int32 x and z, then the int32 dimension unless it's the
+// overworld, then a record tag byte, then for SubChunkPrefix records the subchunk's y index. Other keys are names
+// like ~local_player, or names followed by binary ids like actorprefix keys.
+
+// Dimension is a Bedrock world dimension
+type Dimension int32
+
+// Bedrock dimensions
+const (
+	Overworld Dimension = 0
+	Nether    Dimension = 1
+	TheEnd    Dimension = 2
+)
+
+var dimensionNames = map[Dimension]string{Overworld: "overworld", Nether: "nether", TheEnd: "end"}
+
+func (d Dimension) String() string {
+	if name, ok := dimensionNames[d]; ok {
+		return name
+	}
+	return strconv.Itoa(int(d))
+}
+
+// RecordTag is the kind of chunk data a chunk key holds
+type RecordTag byte
+
+// Bedrock chunk record tags
+const (
+	Data3D                             RecordTag = 43
+	Version                            RecordTag = 44
+	Data2D                             RecordTag = 45
+	Data2DLegacy                       RecordTag = 46
+	SubChunkPrefix                     RecordTag = 47
+	LegacyTerrain                      RecordTag = 48
+	BlockEntity                        RecordTag = 49
+	Entity                             RecordTag = 50
+	PendingTicks                       RecordTag = 51
+	LegacyBlockExtraData               RecordTag = 52
+	BiomeState                         RecordTag = 53
+	FinalizedState                     RecordTag = 54
+	ConversionData                     RecordTag = 55
+	BorderBlocks                       RecordTag = 56
+	HardcodedSpawners                  RecordTag = 57
+	RandomTicks                        RecordTag = 58
+	Checksums                          RecordTag = 59
+	GenerationSeed                     RecordTag = 60
+	GeneratedPreCavesAndCliffsBlending RecordTag = 61
+	BlendingBiomeHeight                RecordTag = 62
+	MetaDataHash                       RecordTag = 63
+	BlendingData                       RecordTag = 64
+	ActorDigestVersion                 RecordTag = 65
+	LegacyVersion                      RecordTag = 118
+)
+
+var recordTagNames = map[RecordTag]string{
+	Data3D:                             "Data3D",
+	Version:                            "Version",
+	Data2D:                             "Data2D",
+	Data2DLegacy:                       "Data2DLegacy",
+	SubChunkPrefix:                     "SubChunkPrefix",
+	LegacyTerrain:                      "LegacyTerrain",
+	BlockEntity:                        "BlockEntity",
+	Entity:                             "Entity",
+	PendingTicks:                       "PendingTicks",
+	LegacyBlockExtraData:               "LegacyBlockExtraData",
+	BiomeState:                         "BiomeState",
+	FinalizedState:                     "FinalizedState",
+	ConversionData:                     "ConversionData",
+	BorderBlocks:                       "BorderBlocks",
+	HardcodedSpawners:                  "HardcodedSpawners",
+	RandomTicks:                        "RandomTicks",
+	Checksums:                          "Checksums",
+	GenerationSeed:                     "GenerationSeed",
+	GeneratedPreCavesAndCliffsBlending: "GeneratedPreCavesAndCliffsBlending",
+	BlendingBiomeHeight:                "BlendingBiomeHeight",
+	MetaDataHash:                       "MetaDataHash",
+	BlendingData:                       "BlendingData",
+	ActorDigestVersion:                 "ActorDigestVersion",
+	LegacyVersion:                      "LegacyVersion",
+}
+
+func (t RecordTag) String() string {
+	if name, ok := recordTagNames[t]; ok {
+		return name
+	}
+	return strconv.Itoa(int(t))
+}
+
+// Key is a decoded database key
+type Key struct {
+	Raw []byte
+	// IsChunk is true for chunk keys, which have the other fields set
+	IsChunk   bool
+	X, Z      int32
+	Dimension Dimension
+	Tag       RecordTag
+	// SubChunk is the subchunk's y index for SubChunkPrefix records
+	SubChunk int8
+}
+
+// ParseKey decodes a database key. Keys that aren't chunk keys only have Raw set.
+func ParseKey(raw []byte) Key {
+	k := Key{Raw: raw}
+	var rest []byte
+	switch len(raw) {
+	case 9, 10:
+		rest = raw[8:]
+	case 13, 14:
+		k.Dimension = Dimension(binary.LittleEndian.Uint32(raw[8:]))
+		if k.Dimension != Nether && k.Dimension != TheEnd {
+			return Key{Raw: raw}
+		}
+		rest = raw[12:]
+	default:
+		return k
+	}
+	k.Tag = RecordTag(rest[0])
+	if _, ok := recordTagNames[k.Tag]; !ok {
+		return Key{Raw: raw}
+	}
+	if len(rest) == 2 {
+		if k.Tag != SubChunkPrefix {
+			return Key{Raw: raw}
+		}
+		k.SubChunk = int8(rest[1])
+	} else if k.Tag == SubChunkPrefix {
+		return Key{Raw: raw}
+	}
+	k.IsChunk = true
+	k.X = int32(binary.LittleEndian.Uint32(raw))
+	k.Z = int32(binary.LittleEndian.Uint32(raw[4:]))
+	return k
+}
+
+// ChunkKey returns the key of a chunk record. subChunk is only used for SubChunkPrefix records.
+func ChunkKey(x, z int32, dimension Dimension, tag RecordTag, subChunk int8) []byte {
+	key := make([]byte, 8, 14)
+	binary.LittleEndian.PutUint32(key, uint32(x))
+	binary.LittleEndian.PutUint32(key[4:], uint32(z))
+	if dimension != Overworld {
+		key = append(key, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(key[8:], uint32(dimension))
+	}
+	key = append(key, byte(tag))
+	if tag == SubChunkPrefix {
+		key = append(key, byte(subChunk))
+	}
+	return key
+}
+
+// String returns the key in the form ParseKeyString reads: chunk:X,Z,DIMENSION,TAG[,SUBCHUNK] for chunk keys, the
+// name for printable keys, and hex:HEX for the rest
+func (k Key) String() string {
+	if k.IsChunk {
+		s := fmt.Sprintf("chunk:%d,%d,%s,%s", k.X, k.Z, k.Dimension, k.Tag)
+		if k.Tag == SubChunkPrefix {
+			s += fmt.Sprintf(",%d", k.SubChunk)
+		}
+		return s
+	}
+	printable := len(k.Raw) > 0 && !bytes.HasPrefix(k.Raw, []byte("chunk:")) && !bytes.HasPrefix(k.Raw, []byte("hex:"))
+	for _, c := range k.Raw {
+		if c < 0x20 || c > 0x7e {
+			printable = false
+		}
+	}
+	if printable {
+		return string(k.Raw)
+	}
+	return "hex:" + hex.EncodeToString(k.Raw)
+}
+
+// NBT reports whether the key's value is expected to be NBT. Other values are binary or text formats, though some,
+// like SubChunkPrefix, contain NBT after a binary header.
+func (k Key) NBT() bool {
+	if k.IsChunk {
+		switch k.Tag {
+		case BlockEntity, Entity, PendingTicks, RandomTicks:
+			return true
+		}
+		return false
+	}
+	for _, prefix := range []string{"digp", "LevelChunkMetaDataDictionary", "game_flatworldlayers"} {
+		if bytes.HasPrefix(k.Raw, []byte(prefix)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseKeyString returns the raw key for a key written as Key.String writes it. Dimensions and record tags can be
+// names or numbers.
+func ParseKeyString(s string) ([]byte, error) {
+	if strings.HasPrefix(s, "hex:") {
+		return hex.DecodeString(s[len("hex:"):])
+	}
+	if !strings.HasPrefix(s, "chunk:") {
+		return []byte(s), nil
+	}
+	fields := strings.Split(s[len("chunk:"):], ",")
+	if len(fields) != 4 && len(fields) != 5 {
+		return nil, fmt.Errorf("chunk key %q should be chunk:X,Z,DIMENSION,TAG or chunk:X,Z,DIMENSION,SubChunkPrefix,Y", s)
+	}
+	x, err := strconv.ParseInt(fields[0], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("chunk key %q X: %s", s, err.Error())
+	}
+	z, err := strconv.ParseInt(fields[1], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("chunk key %q Z: %s", s, err.Error())
+	}
+	dimension, ok := Dimension(0), false
+	for d, name := range dimensionNames {
+		if strings.EqualFold(fields[2], name) {
+			dimension, ok = d, true
+		}
+	}
+	if !ok {
+		n, err := strconv.ParseInt(fields[2], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("chunk key %q dimension %q not recognized", s, fields[2])
+		}
+		dimension = Dimension(n)
+	}
+	tag, ok := RecordTag(0), false
+	for t, name := range recordTagNames {
+		if strings.EqualFold(fields[3], name) {
+			tag, ok = t, true
+		}
+	}
+	if !ok {
+		n, err := strconv.ParseUint(fields[3], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("chunk key %q record tag %q not recognized", s, fields[3])
+		}
+		tag = RecordTag(n)
+	}
+	var subChunk int64
+	if tag == SubChunkPrefix {
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("chunk key %q needs a subchunk index", s)
+		}
+		subChunk, err = strconv.ParseInt(fields[4], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("chunk key %q subchunk: %s", s, err.Error())
+		}
+	} else if len(fields) == 5 {
+		return nil, fmt.Errorf("chunk key %q only SubChunkPrefix records have a subchunk index", s)
+	}
+	return ChunkKey(int32(x), int32(z), dimension, tag, int8(subChunk)), nil
+}
@@ -0,0 +1,140 @@
+package leveldb
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+// LevelDB's log files (the write-ahead log and the MANIFEST) are a series of 32 KiB blocks. Each record is split into
+// fragments that don't cross a block, and each fragment has a 7 byte header: a masked CRC-32C of the fragment type
+// and data, the little endian data length and the fragment type.
+
+const (
+	logBlockSize  = 32 * 1024
+	logHeaderSize = 7
+
+	logFull   = 1
+	logFirst  = 2
+	logMiddle = 3
+	logLast   = 4
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// maskedCrc is LevelDB's CRC-32C of a type byte and data, masked because CRCs of data containing CRCs are weak
+func maskedCrc(t byte, data []byte) uint32 {
+	c := crc32.Update(crc32.Checksum([]byte{t}, crcTable), crcTable, data)
+	return maskCrc(c)
+}
+
+func maskCrc(c uint32) uint32 {
+	return (c>>15 | c<<17) + 0xa282ead8
+}
+
+// readLogRecords returns the records in a log file. Like LevelDB, it stops at a truncated record, which is what a
+// crash while writing leaves behind, and skips the rest of a block with a bad checksum.
+func readLogRecords(data []byte) ([][]byte, error) {
+	var records [][]byte
+	var record []byte
+	inRecord := false
+	for off := 0; off < len(data); {
+		blockLeft := logBlockSize - off%logBlockSize
+		if blockLeft < logHeaderSize {
+			// Block trailer too small for a header
+			off += blockLeft
+			continue
+		}
+		if off+logHeaderSize > len(data) {
+			break
+		}
+		header := data[off : off+logHeaderSize]
+		length := int(binary.LittleEndian.Uint16(header[4:]))
+		t := header[6]
+		if t == 0 && length == 0 {
+			// Zero padding from a preallocated file
+			off += blockLeft
+			continue
+		}
+		if logHeaderSize+length > blockLeft || off+logHeaderSize+length > len(data) {
+			break
+		}
+		fragment := data[off+logHeaderSize : off+logHeaderSize+length]
+		if maskedCrc(t, fragment) != binary.LittleEndian.Uint32(header) {
+			inRecord = false
+			off += blockLeft
+			continue
+		}
+		off += logHeaderSize + length
+		switch t {
+		case logFull:
+			records = append(records, append([]byte(nil), fragment...))
+			inRecord = false
+		case logFirst:
+			record = append(record[:0:0], fragment...)
+			inRecord = true
+		case logMiddle, logLast:
+			if !inRecord {
+				continue
+			}
+			record = append(record, fragment...)
+			if t == logLast {
+				records = append(records, record)
+				inRecord = false
+			}
+		default:
+			return records, errors.New("log record type not recognized")
+		}
+	}
+	return records, nil
+}
+
+// logWriter appends records to a new log file
+type logWriter struct {
+	w           io.Writer
+	blockOffset int
+}
+
+func (l *logWriter) addRecord(data []byte) error {
+	var buf []byte
+	first := true
+	for {
+		left := logBlockSize - l.blockOffset
+		if left < logHeaderSize {
+			buf = append(buf, make([]byte, left)...)
+			l.blockOffset = 0
+			left = logBlockSize
+		}
+		n := len(data)
+		if n > left-logHeaderSize {
+			n = left - logHeaderSize
+		}
+		last := n == len(data)
+		var t byte
+		switch {
+		case first && last:
+			t = logFull
+		case first:
+			t = logFirst
+		case last:
+			t = logLast
+		default:
+			t = logMiddle
+		}
+		var header [logHeaderSize]byte
+		binary.LittleEndian.PutUint32(header[:], maskedCrc(t, data[:n]))
+		binary.LittleEndian.PutUint16(header[4:], uint16(n))
+		header[6] = t
+		buf = append(buf, header[:]...)
+		buf = append(buf, data[:n]...)
+		l.blockOffset += logHeaderSize + n
+		data = data[n:]
+		first = false
+		if last {
+			break
+		}
+	}
+	_, err := l.w.Write(buf)
+	return err
+}
@@ -0,0 +1,216 @@
+package leveldb
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/golang/snappy"
+)
+
+// A table (.ldb, or .sst in older databases) is a sorted, immutable file of blocks. Its 48 byte footer points to an
+// index block, whose entries point to the data blocks. Every block is followed by a compression type byte and a
+// masked CRC-32C. Mojang's LevelDB adds zlib (2) and raw deflate (4) compression to the usual none (0) and snappy (1).
+
+const (
+	tableFooterSize = 48
+	tableMagic      = 0xdb4775248b80fb57
+	blockTrailer    = 5
+
+	noCompression      = 0
+	snappyCompression  = 1
+	zlibCompression    = 2
+	zlibRawCompression = 4
+)
+
+type blockHandle struct {
+	offset, size uint64
+}
+
+type blockEntry struct {
+	key, value []byte
+}
+
+type table struct {
+	f     *os.File
+	index []blockEntry
+}
+
+func decodeBlockHandle(b []byte) (blockHandle, int, error) {
+	offset, n := binary.Uvarint(b)
+	if n <= 0 {
+		return blockHandle{}, 0, errors.New("bad block handle")
+	}
+	size, m := binary.Uvarint(b[n:])
+	if m <= 0 {
+		return blockHandle{}, 0, errors.New("bad block handle")
+	}
+	return blockHandle{offset, size}, n + m, nil
+}
+
+func openTable(name string) (*table, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	t := &table{f: f}
+	err = t.readIndex()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("table %s: %s", name, err.Error())
+	}
+	return t, nil
+}
+
+func (t *table) readIndex() error {
+	fi, err := t.f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() < tableFooterSize {
+		return errors.New("too short to be a table")
+	}
+	footer := make([]byte, tableFooterSize)
+	_, err = t.f.ReadAt(footer, fi.Size()-tableFooterSize)
+	if err != nil {
+		return err
+	}
+	if binary.LittleEndian.Uint64(footer[tableFooterSize-8:]) != tableMagic {
+		return errors.New("table magic number not found")
+	}
+	// The metaindex handle comes first; only the index is needed
+	_, n, err := decodeBlockHandle(footer)
+	if err != nil {
+		return err
+	}
+	indexHandle, _, err := decodeBlockHandle(footer[n:])
+	if err != nil {
+		return err
+	}
+	t.index, err = t.readBlock(indexHandle)
+	return err
+}
+
+// readBlock reads, checks, decompresses and decodes a block
+func (t *table) readBlock(h blockHandle) ([]blockEntry, error) {
+	if h.size > 1<<30 {
+		return nil, errors.New("block is too large")
+	}
+	b := make([]byte, h.size+blockTrailer)
+	_, err := t.f.ReadAt(b, int64(h.offset))
+	if err != nil {
+		return nil, err
+	}
+	data, compression := b[:h.size], b[h.size]
+	// Unlike log records, the block's checksum covers the data before the type byte
+	if maskCrc(crc32.Checksum(b[:h.size+1], crcTable)) != binary.LittleEndian.Uint32(b[h.size+1:]) {
+		return nil, errors.New("block checksum does not match")
+	}
+	switch compression {
+	case noCompression:
+	case snappyCompression:
+		data, err = snappy.Decode(nil, data)
+	case zlibCompression:
+		var zr io.ReadCloser
+		zr, err = zlib.NewReader(bytes.NewReader(data))
+		if err == nil {
+			data, err = ioutil.ReadAll(zr)
+		}
+	case zlibRawCompression:
+		data, err = ioutil.ReadAll(flate.NewReader(bytes.NewReader(data)))
+	default:
+		return nil, fmt.Errorf("block compression type %d not supported", compression)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeBlock(data)
+}
+
+// decodeBlock splits a block into its entries. Keys share a prefix with the previous key, and the block ends with a
+// list of restart points where the prefix is reset, which aren't needed when reading the whole block.
+func decodeBlock(b []byte) ([]blockEntry, error) {
+	if len(b) < 4 {
+		return nil, errors.New("block is too short")
+	}
+	numRestarts := int(binary.LittleEndian.Uint32(b[len(b)-4:]))
+	end := len(b) - 4 - 4*numRestarts
+	if numRestarts < 0 || end < 0 {
+		return nil, errors.New("block restart count is too large")
+	}
+	data := b[:end]
+	var entries []blockEntry
+	var lastKey []byte
+	for len(data) > 0 {
+		var lengths [3]uint64
+		for i := range lengths {
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return nil, errors.New("bad block entry")
+			}
+			lengths[i] = v
+			data = data[n:]
+		}
+		shared, nonShared, valueLen := lengths[0], lengths[1], lengths[2]
+		if shared > uint64(len(lastKey)) || nonShared+valueLen > uint64(len(data)) {
+			return nil, errors.New("bad block entry lengths")
+		}
+		key := make([]byte, 0, shared+nonShared)
+		key = append(append(key, lastKey[:shared]...), data[:nonShared]...)
+		entries = append(entries, blockEntry{key, data[nonShared : nonShared+valueLen]})
+		data = data[nonShared+valueLen:]
+		lastKey = key
+	}
+	return entries, nil
+}
+
+// get returns the first entry at or after the internal key ikey, if it has the same user key
+func (t *table) get(ikey []byte) (*blockEntry, error) {
+	for _, index := range t.index {
+		if compareInternalKeys(index.key, ikey) < 0 {
+			continue
+		}
+		h, _, err := decodeBlockHandle(index.value)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := t.readBlock(h)
+		if err != nil {
+			return nil, err
+		}
+		for i := range entries {
+			if compareInternalKeys(entries[i].key, ikey) >= 0 {
+				if bytes.Equal(userKey(entries[i].key), userKey(ikey)) {
+					return &entries[i], nil
+				}
+				return nil, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// entries calls f for every entry in the table
+func (t *table) entries(f func(blockEntry)) error {
+	for _, index := range t.index {
+		h, _, err := decodeBlockHandle(index.value)
+		if err != nil {
+			return err
+		}
+		entries, err := t.readBlock(h)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			f(entry)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,609 @@
+package nbt2json
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Tag type names for the `nbt:"Name,hint"` struct tag option which forces a field's tag type
+var tagHints = map[string]byte{
+	"byte":      1,
+	"short":     2,
+	"int":       3,
+	"long":      4,
+	"float":     5,
+	"double":    6,
+	"bytearray": 7,
+	"string":    8,
+	"list":      9,
+	"compound":  10,
+	"intarray":  11,
+	"longarray": 12,
+}
+
+var tagInterface = reflect.TypeOf((*Tag)(nil)).Elem()
+
+// Marshal returns the uncompressed NBT of v as an unnamed root tag using the module's default settings. See MarshalTag for how Go values become tags.
+func Marshal(v interface{}) ([]byte, error) {
+	return defaultConverter.Marshal(v)
+}
+
+// Unmarshal reads the first tag of uncompressed NBT data into v using the module's default settings. See UnmarshalTag for how tags become Go values.
+func Unmarshal(data []byte, v interface{}) error {
+	return defaultConverter.Unmarshal(data, v)
+}
+
+// Marshal returns the uncompressed NBT of v as an unnamed root tag. See MarshalTag for how Go values become tags.
+func (c *Converter) Marshal(v interface{}) ([]byte, error) {
+	tag, err := MarshalTag(v)
+	if err != nil {
+		return nil, err
+	}
+	var nbtOut bytes.Buffer
+	err = c.WriteNBT(&nbtOut, []NamedTag{{Name: "", Value: tag}})
+	if err != nil {
+		return nil, err
+	}
+	return nbtOut.Bytes(), nil
+}
+
+// Unmarshal reads the first tag of uncompressed NBT data into v, which must be a non-nil pointer. See UnmarshalTag for how tags become Go values.
+func (c *Converter) Unmarshal(data []byte, v interface{}) error {
+	tags, err := c.ReadNBT(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return MarshalError{"NBT data has no tags", nil}
+	}
+	return UnmarshalTag(tags[0].Value, v)
+}
+
+// MarshalTag converts a Go value to a tag.
+//
+// Structs and maps with string keys become compounds. Struct fields are named by the `nbt:"Name"` struct tag or else the
+// field name; `nbt:"-"` skips the field, and `nbt:",omitempty"` skips zero values. Nil pointers and interfaces are skipped.
+//
+// int8/uint8/bool become Byte, int16/uint16 Short, int32/uint32/int/uint Int, int64/uint64 Long, float32 Float, float64 Double
+// and string String. Unsigned values keep their bits, so uint8(255) is Byte -1. Slices of 8, 32 and 64-bit integers become
+// ByteArray, IntArray and LongArray, and other slices and arrays become lists. Tag values are used as they are.
+//
+// A tag type name after the field name forces the field's tag type, e.g. `nbt:"Positions,list"` makes a []int32 a List of Int
+// instead of an IntArray and `nbt:"Age,short"` makes an int a Short. The names are byte, short, int, long, float, double,
+// bytearray, string, list, compound, intarray and longarray.
+func MarshalTag(v interface{}) (Tag, error) {
+	return marshalValue(reflect.ValueOf(v), 0)
+}
+
+// UnmarshalTag stores a tag in the Go value v points to. Compounds fill structs by the same field names MarshalTag uses,
+// or maps with string keys; unknown names are ignored. Numeric tags fill any numeric type they fit in, and lists and arrays
+// fill slices and arrays. Interface and Tag-typed values get the tag itself.
+func UnmarshalTag(tag Tag, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return MarshalError{fmt.Sprintf("Unmarshal needs a non-nil pointer, not %T", v), nil}
+	}
+	return unmarshalValue(tag, rv.Elem())
+}
+
+// structField is a struct field to be marshalled, found by structFields
+type structField struct {
+	name      string
+	index     []int
+	hint      byte
+	omitEmpty bool
+}
+
+// structFields lists the fields of a struct type in order, including those of embedded structs without an nbt name
+func structFields(t reflect.Type) ([]structField, error) {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		nbtTag := f.Tag.Get("nbt")
+		if nbtTag == "-" {
+			continue
+		}
+		options := strings.Split(nbtTag, ",")
+		name := options[0]
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				// like encoding/json, a pointer to an unexported struct type is skipped as Unmarshal couldn't set it
+				if f.PkgPath != "" {
+					continue
+				}
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded, err := structFields(ft)
+				if err != nil {
+					return nil, err
+				}
+				for _, e := range embedded {
+					e.index = append([]int{i}, e.index...)
+					fields = append(fields, e)
+				}
+				continue
+			}
+		}
+		// unexported
+		if f.PkgPath != "" {
+			continue
+		}
+		field := structField{name: name, index: []int{i}}
+		if field.name == "" {
+			field.name = f.Name
+		}
+		for _, option := range options[1:] {
+			if option == "omitempty" {
+				field.omitEmpty = true
+			} else if hint, ok := tagHints[option]; ok {
+				field.hint = hint
+			} else {
+				return nil, MarshalError{fmt.Sprintf("Unknown nbt struct tag option '%s' on field %s", option, f.Name), nil}
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex but reports false instead of panicking on nil embedded pointers
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// isEmptyValue reports whether v is its type's zero value or an empty slice or map, for omitempty
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// defaultTagType is the tag type a Go type marshals to, or 0 if it can't be known from the type alone
+func defaultTagType(t reflect.Type, hint byte) byte {
+	if hint != 0 {
+		return hint
+	}
+	// pointers to tags are handled below, since calling TagType through a nil pointer panics
+	if t.Implements(tagInterface) && t.Kind() != reflect.Interface && t.Kind() != reflect.Ptr {
+		return reflect.Zero(t).Interface().(Tag).TagType()
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		return 1
+	case reflect.Int16, reflect.Uint16:
+		return 2
+	case reflect.Int, reflect.Uint, reflect.Int32, reflect.Uint32:
+		return 3
+	case reflect.Int64, reflect.Uint64:
+		return 4
+	case reflect.Float32:
+		return 5
+	case reflect.Float64:
+		return 6
+	case reflect.String:
+		return 8
+	case reflect.Slice, reflect.Array:
+		switch t.Elem().Kind() {
+		case reflect.Int8, reflect.Uint8:
+			return 7
+		case reflect.Int32, reflect.Uint32:
+			return 11
+		case reflect.Int64, reflect.Uint64:
+			return 12
+		}
+		return 9
+	case reflect.Map, reflect.Struct:
+		return 10
+	case reflect.Ptr:
+		return defaultTagType(t.Elem(), 0)
+	}
+	return 0
+}
+
+// integerTag makes an integer tag of the given type if i fits in it
+func integerTag(i int64, tagType byte) (Tag, error) {
+	switch tagType {
+	case 1:
+		if i >= math.MinInt8 && i <= math.MaxInt8 {
+			return Byte(i), nil
+		}
+	case 2:
+		if i >= math.MinInt16 && i <= math.MaxInt16 {
+			return Short(i), nil
+		}
+	case 3:
+		if i >= math.MinInt32 && i <= math.MaxInt32 {
+			return Int(i), nil
+		}
+	case 4:
+		return Long(i), nil
+	case 5:
+		return Float(i), nil
+	case 6:
+		return Double(i), nil
+	default:
+		return nil, MarshalError{fmt.Sprintf("An integer can't be tag type %d", tagType), nil}
+	}
+	return nil, MarshalError{fmt.Sprintf("%d is out of range for tag type %d", i, tagType), nil}
+}
+
+// unsignedBits converts an unsigned value to the signed value with the same bits in an NBT integer of the given type, if it fits
+func unsignedBits(u uint64, tagType byte) (int64, error) {
+	var bits uint
+	switch tagType {
+	case 1:
+		bits = 8
+	case 2:
+		bits = 16
+	case 3:
+		bits = 32
+	case 4:
+		return int64(u), nil
+	default:
+		// floats get the plain value
+		if u > math.MaxInt64 {
+			return 0, MarshalError{fmt.Sprintf("%d is out of range for tag type %d", u, tagType), nil}
+		}
+		return int64(u), nil
+	}
+	if u >= 1<<bits {
+		return 0, MarshalError{fmt.Sprintf("%d is out of range for tag type %d", u, tagType), nil}
+	}
+	// sign-extend the top bit
+	shift := 64 - bits
+	return int64(u<<shift) >> shift, nil
+}
+
+// marshalValue converts v to a tag, using hint as the tag type if it isn't 0
+func marshalValue(v reflect.Value, hint byte) (Tag, error) {
+	if !v.IsValid() {
+		return nil, MarshalError{"Can't marshal nil", nil}
+	}
+	if v.Type().Implements(tagInterface) && v.Kind() != reflect.Interface && v.Kind() != reflect.Ptr {
+		tag := v.Interface().(Tag)
+		if hint != 0 && hint != tag.TagType() {
+			return nil, MarshalError{fmt.Sprintf("Tag type %d value can't be tag type %d", tag.TagType(), hint), nil}
+		}
+		return tag, nil
+	}
+	tagType := defaultTagType(v.Type(), hint)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, MarshalError{"Can't marshal nil", nil}
+		}
+		return marshalValue(v.Elem(), hint)
+	case reflect.Bool:
+		var i int64
+		if v.Bool() {
+			i = 1
+		}
+		return integerTag(i, tagType)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return integerTag(v.Int(), tagType)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, err := unsignedBits(v.Uint(), tagType)
+		if err != nil {
+			return nil, err
+		}
+		return integerTag(i, tagType)
+	case reflect.Float32, reflect.Float64:
+		switch tagType {
+		case 5:
+			return Float(v.Float()), nil
+		case 6:
+			return Double(v.Float()), nil
+		}
+		return nil, MarshalError{fmt.Sprintf("A float can't be tag type %d", tagType), nil}
+	case reflect.String:
+		if tagType != 8 {
+			return nil, MarshalError{fmt.Sprintf("A string can't be tag type %d", tagType), nil}
+		}
+		return String(v.String()), nil
+	case reflect.Slice, reflect.Array:
+		return marshalSlice(v, tagType)
+	case reflect.Map:
+		if tagType != 10 || v.Type().Key().Kind() != reflect.String {
+			return nil, MarshalError{fmt.Sprintf("Map type %s can't be tag type %d", v.Type(), tagType), nil}
+		}
+		keys := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		// map order is random, so sort for repeatable output
+		sort.Strings(keys)
+		compound := Compound{}
+		for _, key := range keys {
+			value := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+			if (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) && value.IsNil() {
+				continue
+			}
+			tag, err := marshalValue(value, 0)
+			if err != nil {
+				return nil, MarshalError{fmt.Sprintf("Map key %s", key), err}
+			}
+			compound = append(compound, NamedTag{Name: key, Value: tag})
+		}
+		return compound, nil
+	case reflect.Struct:
+		if tagType != 10 {
+			return nil, MarshalError{fmt.Sprintf("Struct type %s can't be tag type %d", v.Type(), tagType), nil}
+		}
+		fields, err := structFields(v.Type())
+		if err != nil {
+			return nil, err
+		}
+		compound := Compound{}
+		for _, field := range fields {
+			value, ok := fieldByIndex(v, field.index)
+			if !ok {
+				continue
+			}
+			if (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) && value.IsNil() {
+				continue
+			}
+			if field.omitEmpty && isEmptyValue(value) {
+				continue
+			}
+			tag, err := marshalValue(value, field.hint)
+			if err != nil {
+				return nil, MarshalError{fmt.Sprintf("Field %s", field.name), err}
+			}
+			compound = append(compound, NamedTag{Name: field.name, Value: tag})
+		}
+		return compound, nil
+	}
+	return nil, MarshalError{fmt.Sprintf("Type %s is not supported", v.Type()), nil}
+}
+
+// marshalSlice converts a slice or array to an array or list tag
+func marshalSlice(v reflect.Value, tagType byte) (Tag, error) {
+	items := make([]Tag, v.Len())
+	for i := range items {
+		var itemType byte
+		switch tagType {
+		case 7:
+			itemType = 1
+		case 11:
+			itemType = 3
+		case 12:
+			itemType = 4
+		}
+		var err error
+		items[i], err = marshalValue(v.Index(i), itemType)
+		if err != nil {
+			return nil, MarshalError{fmt.Sprintf("Index %d", i), err}
+		}
+	}
+	switch tagType {
+	case 7:
+		byteArray := make(ByteArray, len(items))
+		for i, item := range items {
+			byteArray[i] = int8(item.(Byte))
+		}
+		return byteArray, nil
+	case 11:
+		intArray := make(IntArray, len(items))
+		for i, item := range items {
+			intArray[i] = int32(item.(Int))
+		}
+		return intArray, nil
+	case 12:
+		longArray := make(LongArray, len(items))
+		for i, item := range items {
+			longArray[i] = int64(item.(Long))
+		}
+		return longArray, nil
+	case 9:
+		tagList := List{TagListType: defaultTagType(v.Type().Elem(), 0), Items: items}
+		for i, item := range items {
+			if i == 0 {
+				tagList.TagListType = item.TagType()
+			} else if item.TagType() != tagList.TagListType {
+				return nil, MarshalError{fmt.Sprintf("List item %d is tag type %d but the list is type %d", i, item.TagType(), tagList.TagListType), nil}
+			}
+		}
+		return tagList, nil
+	}
+	return nil, MarshalError{fmt.Sprintf("Type %s can't be tag type %d", v.Type(), tagType), nil}
+}
+
+// tagInteger returns the value of an integer tag and how many bits it has
+func tagInteger(tag Tag) (int64, uint, bool) {
+	switch tag := tag.(type) {
+	case Byte:
+		return int64(tag), 8, true
+	case Short:
+		return int64(tag), 16, true
+	case Int:
+		return int64(tag), 32, true
+	case Long:
+		return int64(tag), 64, true
+	}
+	return 0, 0, false
+}
+
+// unmarshalValue stores tag in v, which must be settable
+func unmarshalValue(tag Tag, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return unmarshalValue(tag, v.Elem())
+	}
+	tagValue := reflect.ValueOf(tag)
+	if v.Kind() == reflect.Interface || v.Type().Implements(tagInterface) {
+		if !tagValue.Type().AssignableTo(v.Type()) {
+			return MarshalError{fmt.Sprintf("Tag type %d can't be stored in %s", tag.TagType(), v.Type()), nil}
+		}
+		v.Set(tagValue)
+		return nil
+	}
+	mismatch := MarshalError{fmt.Sprintf("Tag type %d can't be stored in %s", tag.TagType(), v.Type()), nil}
+	switch v.Kind() {
+	case reflect.Bool:
+		i, _, ok := tagInteger(tag)
+		if !ok {
+			return mismatch
+		}
+		v.SetBool(i != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, _, ok := tagInteger(tag)
+		if !ok {
+			return mismatch
+		}
+		if v.OverflowInt(i) {
+			return MarshalError{fmt.Sprintf("%d overflows %s", i, v.Type()), nil}
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, bits, ok := tagInteger(tag)
+		if !ok {
+			return mismatch
+		}
+		// the reverse of MarshalTag keeping the bits of unsigned values
+		u := uint64(i)
+		if bits < 64 {
+			u &= 1<<bits - 1
+		}
+		if v.OverflowUint(u) {
+			return MarshalError{fmt.Sprintf("%d overflows %s", u, v.Type()), nil}
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch tag := tag.(type) {
+		case Float:
+			f = float64(tag)
+		case Double:
+			f = float64(tag)
+		default:
+			i, _, ok := tagInteger(tag)
+			if !ok {
+				return mismatch
+			}
+			f = float64(i)
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		s, ok := tag.(String)
+		if !ok {
+			return mismatch
+		}
+		v.SetString(string(s))
+	case reflect.Slice, reflect.Array:
+		var items []Tag
+		switch tag := tag.(type) {
+		case ByteArray:
+			for _, i := range tag {
+				items = append(items, Byte(i))
+			}
+		case IntArray:
+			for _, i := range tag {
+				items = append(items, Int(i))
+			}
+		case LongArray:
+			for _, i := range tag {
+				items = append(items, Long(i))
+			}
+		case List:
+			items = tag.Items
+		default:
+			return mismatch
+		}
+		if v.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(v.Type(), len(items), len(items)))
+		} else {
+			v.Set(reflect.Zero(v.Type()))
+		}
+		for i, item := range items {
+			if i >= v.Len() {
+				break
+			}
+			err := unmarshalValue(item, v.Index(i))
+			if err != nil {
+				return MarshalError{fmt.Sprintf("Index %d", i), err}
+			}
+		}
+	case reflect.Map:
+		compound, ok := tag.(Compound)
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return mismatch
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for _, child := range compound {
+			value := reflect.New(v.Type().Elem()).Elem()
+			err := unmarshalValue(child.Value, value)
+			if err != nil {
+				return MarshalError{fmt.Sprintf("Map key %s", child.Name), err}
+			}
+			v.SetMapIndex(reflect.ValueOf(child.Name).Convert(v.Type().Key()), value)
+		}
+	case reflect.Struct:
+		compound, ok := tag.(Compound)
+		if !ok {
+			return mismatch
+		}
+		fields, err := structFields(v.Type())
+		if err != nil {
+			return err
+		}
+		for _, child := range compound {
+			field, ok := findField(fields, child.Name)
+			if !ok {
+				continue
+			}
+			value := v
+			for i, x := range field.index {
+				if i > 0 && value.Kind() == reflect.Ptr {
+					if value.IsNil() {
+						value.Set(reflect.New(value.Type().Elem()))
+					}
+					value = value.Elem()
+				}
+				value = value.Field(x)
+			}
+			err = unmarshalValue(child.Value, value)
+			if err != nil {
+				return MarshalError{fmt.Sprintf("Field %s", field.name), err}
+			}
+		}
+	default:
+		return mismatch
+	}
+	return nil
+}
+
+// findField finds the struct field for a compound child's name, preferring an exact match but accepting any case
+func findField(fields []structField, name string) (structField, bool) {
+	for _, field := range fields {
+		if field.name == name {
+			return field, true
+		}
+	}
+	for _, field := range fields {
+		if strings.EqualFold(field.name, name) {
+			return field, true
+		}
+	}
+	return structField{}, false
+}
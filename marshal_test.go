@@ -0,0 +1,174 @@
+package nbt2json
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type testItem struct {
+	Id    string `nbt:"id"`
+	Count uint8
+	Slot  int8
+}
+
+type testPosition struct {
+	Dimension int32
+}
+
+type testPlayer struct {
+	testPosition
+	Health     float32
+	XpTotal    int
+	Pos        []float64
+	Inventory  []testItem
+	UUID       []int32
+	Path       []int32 `nbt:"Path,list"`
+	Age        int     `nbt:"Age,short"`
+	Seed       uint64
+	OnGround   bool
+	Attributes map[string]float64
+	Extra      Tag     `nbt:",omitempty"`
+	Comment    *string `nbt:"Comment"`
+	Skipped    string  `nbt:"-"`
+	unexported int
+}
+
+// TestMarshal checks Go values against the tags they marshal to and back
+func TestMarshal(t *testing.T) {
+	player := testPlayer{
+		testPosition: testPosition{Dimension: -1},
+		Health:       20,
+		XpTotal:      1234,
+		Pos:          []float64{0.5, 64, -10.25},
+		Inventory:    []testItem{{"minecraft:stone", 200, 0}, {"minecraft:dirt", 1, 8}},
+		UUID:         []int32{1, -2, 3, -4},
+		Path:         []int32{7, 8},
+		Age:          -3,
+		Seed:         0xffffffffffffffff,
+		OnGround:     true,
+		Attributes:   map[string]float64{"speed": 0.1, "armor": 2},
+		Skipped:      "skipped",
+	}
+
+	tag, err := MarshalTag(player)
+	if err != nil {
+		t.Fatal("Error marshalling player:", err.Error())
+	}
+	expected := Compound{
+		{"Dimension", Int(-1)},
+		{"Health", Float(20)},
+		{"XpTotal", Int(1234)},
+		{"Pos", List{6, []Tag{Double(0.5), Double(64), Double(-10.25)}}},
+		{"Inventory", List{10, []Tag{
+			Compound{{"id", String("minecraft:stone")}, {"Count", Byte(-56)}, {"Slot", Byte(0)}},
+			Compound{{"id", String("minecraft:dirt")}, {"Count", Byte(1)}, {"Slot", Byte(8)}},
+		}}},
+		{"UUID", IntArray{1, -2, 3, -4}},
+		{"Path", List{3, []Tag{Int(7), Int(8)}}},
+		{"Age", Short(-3)},
+		{"Seed", Long(-1)},
+		{"OnGround", Byte(1)},
+		{"Attributes", Compound{{"armor", Double(2)}, {"speed", Double(0.1)}}},
+	}
+	if !reflect.DeepEqual(tag, expected) {
+		t.Errorf("Marshalled player expected\n%#v\n, got\n%#v\n", expected, tag)
+	}
+
+	var decoded testPlayer
+	err = UnmarshalTag(tag, &decoded)
+	if err != nil {
+		t.Fatal("Error unmarshalling player:", err.Error())
+	}
+	player.Skipped = ""
+	if !reflect.DeepEqual(decoded, player) {
+		t.Errorf("Unmarshalled player expected\n%#v\n, got\n%#v\n", player, decoded)
+	}
+
+	// Slices of pointers to tags get their tag type from the element type
+	one, two := Int(1), Int(2)
+	tag, err = MarshalTag(struct{ L []*Int }{[]*Int{&one, &two}})
+	if err != nil {
+		t.Fatal("Error marshalling []*Int:", err.Error())
+	}
+	if expected := (Compound{{"L", List{3, []Tag{Int(1), Int(2)}}}}); !reflect.DeepEqual(tag, expected) {
+		t.Errorf("Marshalled []*Int expected %#v, got %#v", expected, tag)
+	}
+	var pointers struct{ L []*Int }
+	if err = UnmarshalTag(tag, &pointers); err != nil || len(pointers.L) != 2 || *pointers.L[1] != 2 {
+		t.Errorf("Unmarshalling Int list to []*Int expected [1 2], got %v, %v", pointers.L, err)
+	}
+	tag, err = MarshalTag(struct{ L []*Int }{[]*Int{}})
+	if err != nil || !reflect.DeepEqual(tag, Compound{{"L", List{3, []Tag{}}}}) {
+		t.Errorf("Marshalled empty []*Int expected an empty Int list, got %#v, %v", tag, err)
+	}
+
+	// Embedded pointers to unexported struct types are skipped, as they can't be set
+	type embedsPointer struct {
+		*testPosition
+		Health float32
+	}
+	tag, err = MarshalTag(embedsPointer{&testPosition{1}, 20})
+	if err != nil || !reflect.DeepEqual(tag, Compound{{"Health", Float(20)}}) {
+		t.Errorf("Marshalled embedded *testPosition expected to be skipped, got %#v, %v", tag, err)
+	}
+	var embedded embedsPointer
+	err = UnmarshalTag(Compound{{"Dimension", Int(-1)}, {"Health", Float(20)}}, &embedded)
+	if err != nil || embedded.testPosition != nil || embedded.Health != 20 {
+		t.Errorf("Unmarshalling into embedded *testPosition expected it to be skipped, got %#v, %v", embedded, err)
+	}
+
+	// NOTE: Tested functions should throw error to pass
+	badValues := []interface{}{
+		struct {
+			B int `nbt:"B,byte"`
+		}{300},
+		struct {
+			S string `nbt:"S,int"`
+		}{"x"},
+		map[int]int{1: 1},
+		[]interface{}{Int(1), String("x")},
+		make(chan int),
+	}
+	for _, v := range badValues {
+		_, err = MarshalTag(v)
+		if err == nil {
+			t.Errorf("Marshalling %#v failed to throw error", v)
+		}
+	}
+	var small struct{ B int8 }
+	if err = UnmarshalTag(Compound{{"B", Int(300)}}, &small); err == nil {
+		t.Error("Unmarshalling out of range Int to int8 failed to throw error")
+	}
+	var str struct{ S string }
+	if err = UnmarshalTag(Compound{{"S", Int(1)}}, &str); err == nil {
+		t.Error("Unmarshalling Int to string failed to throw error")
+	}
+}
+
+// TestMarshalEncoding checks Marshal and Unmarshal use the converter's byte order
+func TestMarshalEncoding(t *testing.T) {
+	value := struct{ I int32 }{1}
+	java := NewConverter()
+	java.UseJavaEncoding()
+	expected := []byte{10, 0, 0, 3, 0, 1, 'I', 0, 0, 0, 1, 0}
+
+	nbtData, err := java.Marshal(value)
+	if err != nil {
+		t.Fatal("Error marshalling:", err.Error())
+	}
+	if !bytes.Equal(nbtData, expected) {
+		t.Error(fmt.Sprintf("Java Marshal expected \n%s\n, got \n%s\n", hex.Dump(expected), hex.Dump(nbtData)))
+	}
+
+	value.I = 0
+	err = java.Unmarshal(nbtData, &value)
+	if err != nil {
+		t.Fatal("Error unmarshalling:", err.Error())
+	}
+	if value.I != 1 {
+		t.Errorf("Java Unmarshal expected 1, got %d", value.I)
+	}
+}
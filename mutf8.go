@@ -0,0 +1,130 @@
+package nbt2json
+
+import (
+	"errors"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Java Edition writes names and strings in Java's modified UTF-8 (MUTF-8). It differs from UTF-8 in two ways:
+// NUL is the two bytes 0xC0 0x80, and characters outside the Basic Multilingual Plane, like emoji, are written as
+// UTF-16 surrogate pairs with each surrogate encoded as three bytes.
+
+var errMalformedMutf8 = errors.New("malformed modified UTF-8")
+
+// decodeMutf8 converts Java modified UTF-8 to a Go string. Like Java, it also accepts a plain 0 byte and overlong
+// two and three byte forms. Unpaired surrogates can't be in a valid Go string, so they are malformed.
+func decodeMutf8(b []byte) (string, error) {
+	// Most strings are plain ASCII
+	ascii := true
+	for _, c := range b {
+		if c >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return string(b), nil
+	}
+
+	units := make([]uint16, 0, len(b))
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case c < 0x80:
+			units = append(units, uint16(c))
+			i++
+		case c&0xe0 == 0xc0:
+			if i+1 >= len(b) || b[i+1]&0xc0 != 0x80 {
+				return "", errMalformedMutf8
+			}
+			units = append(units, uint16(c&0x1f)<<6|uint16(b[i+1]&0x3f))
+			i += 2
+		case c&0xf0 == 0xe0:
+			if i+2 >= len(b) || b[i+1]&0xc0 != 0x80 || b[i+2]&0xc0 != 0x80 {
+				return "", errMalformedMutf8
+			}
+			units = append(units, uint16(c&0x0f)<<12|uint16(b[i+1]&0x3f)<<6|uint16(b[i+2]&0x3f))
+			i += 3
+		default:
+			return "", errMalformedMutf8
+		}
+	}
+
+	runes := make([]rune, 0, len(units))
+	for i := 0; i < len(units); i++ {
+		r := rune(units[i])
+		if utf16.IsSurrogate(r) {
+			if r >= 0xdc00 || i+1 >= len(units) {
+				return "", errMalformedMutf8
+			}
+			r = utf16.DecodeRune(r, rune(units[i+1]))
+			if r == utf8.RuneError {
+				return "", errMalformedMutf8
+			}
+			i++
+		}
+		runes = append(runes, r)
+	}
+	return string(runes), nil
+}
+
+// encodeMutf8 converts a Go string to Java modified UTF-8. Strings that aren't valid UTF-8, such as those kept as raw
+// bytes after failing to decode, are written unchanged so they round trip.
+func encodeMutf8(s string) []byte {
+	if !utf8.ValidString(s) {
+		return []byte(s)
+	}
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == 0:
+			b = append(b, 0xc0, 0x80)
+		case r < 0x80:
+			b = append(b, byte(r))
+		case r < 0x800:
+			b = append(b, 0xc0|byte(r>>6), 0x80|byte(r&0x3f))
+		case r < 0x10000:
+			b = append(b, 0xe0|byte(r>>12), 0x80|byte(r>>6&0x3f), 0x80|byte(r&0x3f))
+		default:
+			r1, r2 := utf16.EncodeRune(r)
+			for _, u := range []rune{r1, r2} {
+				b = append(b, 0xe0|byte(u>>12), 0x80|byte(u>>6&0x3f), 0x80|byte(u&0x3f))
+			}
+		}
+	}
+	return b
+}
+
+// decodeString converts name or string bytes read from NBT. Only Java Edition uses modified UTF-8; Bedrock's bytes are
+// kept as they are.
+func (c *Converter) decodeString(b []byte) (string, error) {
+	if !c.mutf8 {
+		return string(b), nil
+	}
+	s, err := decodeMutf8(b)
+	if err != nil && c.rawStrings {
+		return string(b), nil
+	}
+	return s, err
+}
+
+// encodeString converts a name or string to the bytes written to NBT
+func (c *Converter) encodeString(s string) []byte {
+	if !c.mutf8 {
+		return []byte(s)
+	}
+	return encodeMutf8(s)
+}
+
+// checkStringLength returns an error if s is too long to be written as a name or string
+func (c *Converter) checkStringLength(s string) error {
+	// modified UTF-8 at most doubles the length, so short strings can't be too long
+	if len(s) <= maxStringLength/2 {
+		return nil
+	}
+	if n := len(c.encodeString(s)); n > maxStringLength {
+		return stringLengthError(n)
+	}
+	return nil
+}
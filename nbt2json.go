@@ -1,12 +1,19 @@
 package nbt2json
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/ghodss/yaml"
 )
@@ -17,17 +24,33 @@ type NbtJson struct {
 	Version        string             `json:"version"`
 	Nbt2JsonUrl    string             `json:"nbt2JsonUrl"`
 	ConversionTime string             `json:"conversionTime,omitempty"`
+	Compression    *NbtCompression    `json:"compression,omitempty"`
 	Comment        string             `json:"comment,omitempty"`
+	Header         *NbtHeader         `json:"header,omitempty"`
 	Nbt            []*json.RawMessage `json:"nbt"`
 }
 
 // NbtTag represents one NBT tag for each struct; it is exported for reflect, and client code shouldn't use it
 type NbtTag struct {
 	TagType byte        `json:"tagType"`
-	Name    string      `json:"name"`
+	Name    interface{} `json:"name"`
 	Value   interface{} `json:"value,omitempty"`
 }
 
+// NbtRawString is a name or string that isn't valid UTF-8, such as one kept as raw bytes by UseRawStringFallback, with
+// its bytes in hex. JSON strings can't hold those bytes. It is exported for reflect, and client code shouldn't use it
+type NbtRawString struct {
+	Raw string `json:"raw"`
+}
+
+// jsonString returns a name or string as a JSON string, or as an NbtRawString if it isn't valid UTF-8
+func jsonString(s string) interface{} {
+	if utf8.ValidString(s) {
+		return s
+	}
+	return NbtRawString{hex.EncodeToString([]byte(s))}
+}
+
 // NbtTagList represents an NBT tag list; it is exported for reflect, and client code shouldn't use it
 type NbtTagList struct {
 	TagListType byte          `json:"tagListType"`
@@ -55,240 +78,457 @@ func intPairToLong(nbtLong NbtLong) int64 {
 	return i
 }
 
-// Nbt2Yaml converts uncompressed NBT byte array to YAML byte array
+// Floats and doubles that JSON numbers can't hold are strings: Infinity, -Infinity, NaN for Java's canonical NaN, and
+// other NaNs as their bits like NaN(0xffc00000), so NaN payloads round trip exactly. -0 is the string -0 too, because
+// YAML loses the sign of a -0 number. NaN floats are kept as float32 bits throughout, since converting them to float64
+// and back can change their payload.
+
+// jsonFloat returns a Float or Double as a float32 or float64 JSON number, or as a string if it is special
+func jsonFloat(tag Tag) interface{} {
+	switch tag := tag.(type) {
+	case Float:
+		if isSpecialFloat(float64(tag)) {
+			return specialFloatString(uint64(math.Float32bits(float32(tag))), 32)
+		}
+		return float32(tag)
+	case Double:
+		if isSpecialFloat(float64(tag)) {
+			return specialFloatString(math.Float64bits(float64(tag)), 64)
+		}
+		return float64(tag)
+	}
+	return nil
+}
+
+// isSpecialFloat returns whether f is NaN, infinite or -0
+func isSpecialFloat(f float64) bool {
+	return math.IsNaN(f) || math.IsInf(f, 0) || (f == 0 && math.Signbit(f))
+}
+
+// floatBits returns the bits of positive infinity, Java's canonical NaN and the sign for floats of bitSize 32 or 64
+func floatBits(bitSize int) (infinity uint64, canonicalNaN uint64, sign uint64) {
+	if bitSize == 32 {
+		return 0x7f800000, 0x7fc00000, 1 << 31
+	}
+	return 0x7ff0000000000000, 0x7ff8000000000000, 1 << 63
+}
+
+// specialFloatString returns the string for the bits of an infinity, NaN or -0
+func specialFloatString(bits uint64, bitSize int) string {
+	infinity, canonicalNaN, sign := floatBits(bitSize)
+	switch bits {
+	case sign:
+		return "-0"
+	case infinity:
+		return "Infinity"
+	case sign | infinity:
+		return "-Infinity"
+	case canonicalNaN:
+		return "NaN"
+	}
+	return fmt.Sprintf("NaN(0x%0*x)", bitSize/4, bits)
+}
+
+// specialFloatBits returns the bits of an infinity, NaN or -0 from its string, or false if s isn't one
+func specialFloatBits(s string, bitSize int) (uint64, bool) {
+	infinity, canonicalNaN, sign := floatBits(bitSize)
+	switch s {
+	case "-0":
+		return sign, true
+	case "Infinity", "+Infinity":
+		return infinity, true
+	case "-Infinity":
+		return sign | infinity, true
+	case "NaN":
+		return canonicalNaN, true
+	}
+	if !strings.HasPrefix(s, "NaN(0x") || !strings.HasSuffix(s, ")") {
+		return 0, false
+	}
+	bits, err := strconv.ParseUint(s[len("NaN(0x"):len(s)-1], 16, bitSize)
+	// NaNs have all exponent bits set and a non-zero fraction
+	if err != nil || bits&^sign <= infinity {
+		return 0, false
+	}
+	return bits, true
+}
+
+// Nbt2Yaml converts NBT byte array to YAML byte array using the module's default settings. Compressed NBT is
+// decompressed, and its compression recorded.
 func Nbt2Yaml(b []byte, comment string) ([]byte, error) {
-	jsonOut, err := Nbt2Json(b, comment)
+	return defaultConverter.Nbt2Yaml(b, comment)
+}
+
+// Nbt2Json converts NBT byte array to JSON byte array using the module's default settings. Compressed NBT is
+// decompressed, and its compression recorded.
+func Nbt2Json(b []byte, comment string) ([]byte, error) {
+	return defaultConverter.Nbt2Json(b, comment)
+}
+
+// Nbt2Yaml converts NBT byte array to YAML byte array. Compressed NBT is decompressed, and its compression recorded.
+func (c *Converter) Nbt2Yaml(b []byte, comment string) ([]byte, error) {
+	jsonOut, err := c.Nbt2Json(b, comment)
 	if err != nil {
 		return nil, err
 	}
 	yamlOut, err := yaml.JSONToYAML(jsonOut)
 	if err != nil {
-		return yamlOut, NbtParseError{"Error converting JSON to YAML. Oops. JSON conversion succeeded, so please report this error and use JSON instead.", err}
+		return yamlOut, NbtParseError{s: "Error converting JSON to YAML. Oops. JSON conversion succeeded, so please report this error and use JSON instead.", e: err}
 	}
 	return yamlOut, nil
 }
 
-// Nbt2Json converts uncompressed NBT byte array to JSON byte array
-func Nbt2Json(b []byte, comment string) ([]byte, error) {
-	var nbtJson NbtJson
-	nbtJson.Name = Name
-	nbtJson.Version = Version
-	nbtJson.Nbt2JsonUrl = Nbt2JsonUrl
-	nbtJson.ConversionTime = time.Now().Format(time.RFC3339)
-	nbtJson.Comment = comment
-	buf := bytes.NewReader(b)
-	// var nbtJson.nbt []*json.RawMessage
-	for buf.Len() > 0 {
-		element, err := getTag(buf)
-		if err != nil {
-			return nil, err
-		}
-		myTemp := json.RawMessage(element)
-		nbtJson.Nbt = append(nbtJson.Nbt, &myTemp)
-	}
-	jsonOut, err := json.MarshalIndent(nbtJson, "", "  ")
+// Nbt2Json converts NBT byte array to JSON byte array. Compressed NBT is decompressed, and its compression recorded.
+func (c *Converter) Nbt2Json(b []byte, comment string) ([]byte, error) {
+	var jsonOut bytes.Buffer
+	err := c.NewDecoder(bytes.NewReader(b)).Decode(&jsonOut, comment)
 	if err != nil {
 		return nil, err
 	}
-	return jsonOut, nil
+	return jsonOut.Bytes(), nil
 }
 
-// getTag broken out form Nbt2Json to allow recursion with reader but public input is []byte
-func getTag(r *bytes.Reader) ([]byte, error) {
-	var data NbtTag
-	err := binary.Read(r, byteOrder, &data.TagType)
+// Decoder reads NBT from an input stream and writes it out as a JSON document one tag at a time,
+// so the whole document never has to be held in memory
+type Decoder struct {
+	c           *Converter
+	in          io.Reader
+	r           *tagReader
+	compression *NbtCompression
+}
+
+// NewDecoder returns a Decoder that reads NBT from r using the module's default settings
+func NewDecoder(r io.Reader) *Decoder {
+	return defaultConverter.NewDecoder(r)
+}
+
+// NewDecoder returns a Decoder that reads NBT from r using the converter's settings
+func (c *Converter) NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		c:  c,
+		in: r,
+	}
+}
+
+// RecordCompression records in the document that the NBT was compressed with compression, for callers that
+// decompress it before decoding. header is the gzip header, or nil. Compressed input is recorded without this.
+func (d *Decoder) RecordCompression(compression Compression, header *gzip.Header) {
+	d.compression = recordCompression(compression, header)
+}
+
+// Decode reads NBT tags until the end of the input and writes them to w as a JSON document.
+// The output is the same as Nbt2Json's. Compressed input is decompressed and its compression recorded in the
+// document's compression field. In Bedrock encoding, a level.dat header is recorded in the document's header field.
+func (d *Decoder) Decode(w io.Writer, comment string) error {
+	in, compression, err := NewDecompressReader(d.in)
 	if err != nil {
-		return nil, NbtParseError{"Reading TagType", err}
+		return NbtParseError{s: "Decompressing NBT", e: err}
 	}
-	// do not try to fetch name for TagType 0 which is compound end tag
-	if data.TagType != 0 {
-		var err error
-		var nameLen int16
-		err = binary.Read(r, byteOrder, &nameLen)
+	if compression != NoCompression {
+		var gzipHeader *gzip.Header
+		if zr, ok := in.(*gzip.Reader); ok {
+			gzipHeader = &zr.Header
+		}
+		d.RecordCompression(compression, gzipHeader)
+	}
+	d.r = d.c.newTagReader(bufio.NewReader(in), nil)
+	header, err := d.c.peekHeader(d.r.r)
+	if err != nil {
+		return NbtParseError{s: "Reading level.dat header", e: err}
+	}
+	if header != nil {
+		d.r.offset += headerSize
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString("{\n")
+	writeJsonField(bw, "name", Name)
+	writeJsonField(bw, "version", Version)
+	writeJsonField(bw, "nbt2JsonUrl", Nbt2JsonUrl)
+	writeJsonField(bw, "conversionTime", time.Now().Format(time.RFC3339))
+	if d.compression != nil {
+		c, _ := json.MarshalIndent(d.compression, jsonIndent(1), "  ")
+		fmt.Fprintf(bw, "%s\"compression\": %s,\n", jsonIndent(1), c)
+	}
+	if comment != "" {
+		writeJsonField(bw, "comment", comment)
+	}
+	if header != nil {
+		h, _ := json.MarshalIndent(header, jsonIndent(1), "  ")
+		fmt.Fprintf(bw, "%s\"header\": %s,\n", jsonIndent(1), h)
+	}
+	bw.WriteString(`  "nbt": `)
+	numTags := 0
+	for {
+		start := d.r.offset
+		var tagType byte
+		err := binary.Read(d.r, d.c.byteOrder, &tagType)
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			return nil, NbtParseError{"Reading Name length", err}
+			return nbtErrorAt(NbtParseError{s: "Reading TagType", e: err}, start, "", 0)
+		}
+		if numTags == 0 {
+			bw.WriteString("[\n")
+		} else {
+			bw.WriteString(",\n")
 		}
-		name := make([]byte, nameLen)
-		err = binary.Read(r, byteOrder, &name)
+		bw.WriteString(jsonIndent(2))
+		_, err = d.decodeTag(bw, tagType, 2)
 		if err != nil {
-			return nil, NbtParseError{fmt.Sprintf("Reading Name - is UseJavaEncoding or UseBedrockEncoding set correctly? Name length decoded is %d", nameLen), err}
+			return nbtErrorAt(err, start, "", tagType)
 		}
-		data.Name = string(name[:])
+		numTags++
 	}
-	data.Value, err = getPayload(r, data.TagType)
-	if err != nil {
-		return nil, err
+	if numTags == 0 {
+		bw.WriteString("null")
+	} else {
+		bw.WriteString("\n" + jsonIndent(1) + "]")
 	}
-	outJson, err := json.MarshalIndent(data, "", "  ")
-	return outJson, err
+	bw.WriteString("\n}")
+	return bw.Flush()
 }
 
-// Gets the tag payload. Had to break this out from the main function to allow tag list recursion
-func getPayload(r *bytes.Reader, tagType byte) (interface{}, error) {
-	var output interface{}
+// jsonIndent returns the indentation json.MarshalIndent uses at the given depth, so the streamed output matches it
+func jsonIndent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+// writeJsonField writes one string field of the top-level document
+func writeJsonField(w *bufio.Writer, key string, value string) {
+	v, _ := json.Marshal(value)
+	fmt.Fprintf(w, "%s%q: %s,\n", jsonIndent(1), key, v)
+}
+
+// decodeTag reads the name and payload of a tag whose type has already been read and writes it as a JSON object. It
+// returns the name so errors can give the tag's path.
+func (d *Decoder) decodeTag(w *bufio.Writer, tagType byte, depth int) (string, error) {
+	var name string
 	var err error
-	switch tagType {
-	case 0:
-		// end tag for compound; do nothing further
-	case 1:
-		var i int8
-		err = binary.Read(r, byteOrder, &i)
-		if err != nil {
-			return nil, NbtParseError{"Reading int8", err}
-		}
-		output = i
-	case 2:
-		var i int16
-		err = binary.Read(r, byteOrder, &i)
+	// do not try to fetch name for TagType 0 which is compound end tag
+	if tagType != 0 {
+		name, err = d.c.readName(d.r)
 		if err != nil {
-			return nil, NbtParseError{"Reading int16", err}
+			return name, err
 		}
-		output = i
-	case 3:
-		var i int32
-		err = binary.Read(r, byteOrder, &i)
+	}
+	jsonName, err := json.Marshal(jsonString(name))
+	if err != nil {
+		return name, err
+	}
+	fmt.Fprintf(w, "{\n%s\"tagType\": %d,\n%s\"name\": %s", jsonIndent(depth+1), tagType, jsonIndent(depth+1), jsonName)
+	// end tags have no value, which the json leaves out
+	if tagType != 0 {
+		w.WriteString(",\n" + jsonIndent(depth+1) + `"value": `)
+		err = d.decodePayload(w, tagType, depth+1)
 		if err != nil {
-			return nil, NbtParseError{"Reading int32", err}
+			return name, err
 		}
-		output = i
-	case 4:
-		var i int64
-		err = binary.Read(r, byteOrder, &i)
+	}
+	w.WriteString("\n" + jsonIndent(depth) + "}")
+	return name, nil
+}
+
+// decodePayload streams compounds and lists and writes other payloads as a whole
+func (d *Decoder) decodePayload(w *bufio.Writer, tagType byte, depth int) error {
+	switch tagType {
+	case 9:
+		var tagListType byte
+		err := binary.Read(d.r, d.c.byteOrder, &tagListType)
 		if err != nil {
-			return nil, NbtParseError{"Reading int64", err}
+			return NbtParseError{s: "Reading TagType", e: err}
 		}
-		if longAsString {
-			output = fmt.Sprintf("%d", i)
-		} else {
-			output = longToIntPair(i)
+		numRecords, err := d.c.readInt(d.r)
+		if err == nil {
+			err = checkListLength(numRecords, tagListType, d.r.limits)
 		}
-	case 5:
-		var f float32
-		err = binary.Read(r, byteOrder, &f)
 		if err != nil {
-			return nil, NbtParseError{"Reading float32", err}
+			return NbtParseError{s: "Reading list tag length", e: err}
 		}
-		output = f
-	case 6:
-		var f float64
-		err = binary.Read(r, byteOrder, &f)
+		err = d.r.enter()
 		if err != nil {
-			return nil, NbtParseError{"Reading float64", err}
+			return NbtParseError{s: "Reading list tag", e: err}
 		}
-		if math.IsNaN(f) {
-			output = "NaN"
+		defer d.r.leave()
+		fmt.Fprintf(w, "{\n%s\"tagListType\": %d,\n%s\"list\": ", jsonIndent(depth+1), tagListType, jsonIndent(depth+1))
+		if numRecords <= 0 {
+			w.WriteString("null")
 		} else {
-			output = f
-		}
-	case 7:
-		var byteArray []int8
-		var oneByte int8
-		var numRecords int32
-		err := binary.Read(r, byteOrder, &numRecords)
-		if err != nil {
-			return nil, NbtParseError{"Reading byte array tag length", err}
-		}
-		for i := int32(1); i <= numRecords; i++ {
-			err = binary.Read(r, byteOrder, &oneByte)
-			if err != nil {
-				return nil, NbtParseError{"Reading byte in byte array tag", err}
+			w.WriteString("[\n")
+			for i := int32(0); i < numRecords; i++ {
+				w.WriteString(jsonIndent(depth + 2))
+				start := d.r.offset
+				err = d.decodePayload(w, tagListType, depth+2)
+				if err != nil {
+					return wrapNbtError("Reading list tag item", nbtErrorAt(err, start, fmt.Sprintf("[%d]", i), tagListType))
+				}
+				if i < numRecords-1 {
+					w.WriteString(",")
+				}
+				w.WriteString("\n")
 			}
-			byteArray = append(byteArray, oneByte)
-		}
-		output = byteArray
-	case 8:
-		var strLen int16
-		err := binary.Read(r, byteOrder, &strLen)
-		if err != nil {
-			return nil, NbtParseError{"Reading string tag length", err}
-		}
-		utf8String := make([]byte, strLen)
-		err = binary.Read(r, byteOrder, &utf8String)
-		if err != nil {
-			return nil, NbtParseError{"Reading string tag data", err}
-		}
-		output = string(utf8String[:])
-	case 9:
-		var tagList NbtTagList
-		err = binary.Read(r, byteOrder, &tagList.TagListType)
-		if err != nil {
-			return nil, NbtParseError{"Reading TagType", err}
+			w.WriteString(jsonIndent(depth+1) + "]")
 		}
-		var numRecords int32
-		err := binary.Read(r, byteOrder, &numRecords)
+		w.WriteString("\n" + jsonIndent(depth) + "}")
+	case 10:
+		err := d.r.enter()
 		if err != nil {
-			return nil, NbtParseError{"Reading list tag length", err}
+			return NbtParseError{s: "Reading compound tag", e: err}
 		}
-		for i := int32(1); i <= numRecords; i++ {
-			payload, err := getPayload(r, tagList.TagListType)
+		defer d.r.leave()
+		numTags := 0
+		for {
+			start := d.r.offset
+			var tagType byte
+			err := binary.Read(d.r, d.c.byteOrder, &tagType)
 			if err != nil {
-				return nil, NbtParseError{"Reading list tag item", err}
+				return NbtParseError{s: "compound: reading next tag type", e: err}
 			}
-			tagList.List = append(tagList.List, payload)
-		}
-		output = tagList
-	case 10:
-		var compound []json.RawMessage
-		var tagType byte
-		for err = binary.Read(r, byteOrder, &tagType); tagType != 0; err = binary.Read(r, byteOrder, &tagType) {
-			if err != nil {
-				return nil, NbtParseError{"compound: reading next tag type", err}
+			if tagType == 0 {
+				break
 			}
-			_, err = r.Seek(-1, 1)
-			if err != nil {
-				return nil, NbtParseError{"seeking back one", err}
+			if numTags == 0 {
+				w.WriteString("[\n")
+			} else {
+				w.WriteString(",\n")
 			}
-			tag, err := getTag(r)
+			w.WriteString(jsonIndent(depth + 1))
+			name, err := d.decodeTag(w, tagType, depth+1)
 			if err != nil {
-				return nil, NbtParseError{"compound: reading a child tag", err}
+				return wrapNbtError("compound: reading a child tag", nbtErrorAt(err, start, "/"+name, tagType))
 			}
-			compound = append(compound, json.RawMessage(string(tag)))
+			numTags++
 		}
-		if compound == nil {
+		if numTags == 0 {
 			// Explicitly give empty array else value will be null instead of []
-			output = []int{}
+			w.WriteString("[]")
 		} else {
-			output = compound
+			w.WriteString("\n" + jsonIndent(depth) + "]")
 		}
-	case 11:
-		var intArray []int32
-		var numRecords, oneInt int32
-		err := binary.Read(r, byteOrder, &numRecords)
+	default:
+		payload, err := d.c.readPayload(d.r, tagType)
 		if err != nil {
-			return nil, NbtParseError{"Reading int array tag length", err}
+			return err
 		}
-		for i := int32(1); i <= numRecords; i++ {
-			err := binary.Read(r, byteOrder, &oneInt)
-			if err != nil {
-				return nil, NbtParseError{"Reading int in int array tag", err}
-			}
-			intArray = append(intArray, oneInt)
+		jsonOut, err := json.MarshalIndent(d.c.jsonValue(payload), jsonIndent(depth), "  ")
+		if err != nil {
+			return err
 		}
-		output = intArray
-	case 12:
-		var longArray []NbtLong
-		var longStringArray []string
-		var numRecords, oneInt int64
-		err := binary.Read(r, byteOrder, &numRecords)
+		w.Write(jsonOut)
+	}
+	return nil
+}
+
+// Tags2Yaml converts a tag tree to YAML byte array using the module's default settings
+func Tags2Yaml(tags []NamedTag, comment string) ([]byte, error) {
+	return defaultConverter.Tags2Yaml(tags, comment)
+}
+
+// Tags2Json converts a tag tree to JSON byte array using the module's default settings
+func Tags2Json(tags []NamedTag, comment string) ([]byte, error) {
+	return defaultConverter.Tags2Json(tags, comment)
+}
+
+// Tags2Yaml converts a tag tree to YAML byte array
+func (c *Converter) Tags2Yaml(tags []NamedTag, comment string) ([]byte, error) {
+	jsonOut, err := c.Tags2Json(tags, comment)
+	if err != nil {
+		return nil, err
+	}
+	yamlOut, err := yaml.JSONToYAML(jsonOut)
+	if err != nil {
+		return yamlOut, NbtParseError{s: "Error converting JSON to YAML. Oops. JSON conversion succeeded, so please report this error and use JSON instead.", e: err}
+	}
+	return yamlOut, nil
+}
+
+// Tags2Json converts a tag tree to JSON byte array. The output is the same as Nbt2Json's for the tree's NBT.
+func (c *Converter) Tags2Json(tags []NamedTag, comment string) ([]byte, error) {
+	var nbtJson NbtJson
+	nbtJson.Name = Name
+	nbtJson.Version = Version
+	nbtJson.Nbt2JsonUrl = Nbt2JsonUrl
+	nbtJson.ConversionTime = time.Now().Format(time.RFC3339)
+	nbtJson.Comment = comment
+	for _, tag := range tags {
+		element, err := json.Marshal(c.jsonTag(tag))
 		if err != nil {
-			return nil, NbtParseError{"Reading long array tag length", err}
+			return nil, err
 		}
-		for i := int64(1); i <= numRecords; i++ {
-			err := binary.Read(r, byteOrder, &oneInt)
-			if err != nil {
-				return nil, NbtParseError{"Reading long in long array tag", err}
-			}
-			longArray = append(longArray, longToIntPair(oneInt))
-			longStringArray = append(longStringArray, fmt.Sprintf("%d", oneInt))
+		myTemp := json.RawMessage(element)
+		nbtJson.Nbt = append(nbtJson.Nbt, &myTemp)
+	}
+	jsonOut, err := json.MarshalIndent(nbtJson, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return jsonOut, nil
+}
+
+// jsonTag puts a tag in the json document's tagType/name/value form
+func (c *Converter) jsonTag(tag NamedTag) NbtTag {
+	return NbtTag{
+		TagType: tag.Value.TagType(),
+		Name:    jsonString(tag.Name),
+		Value:   c.jsonValue(tag.Value),
+	}
+}
+
+// jsonValue converts a tag payload to the value json.Marshal should write for it
+func (c *Converter) jsonValue(tag Tag) interface{} {
+	switch tag := tag.(type) {
+	case Byte:
+		return int8(tag)
+	case Short:
+		return int16(tag)
+	case Int:
+		return int32(tag)
+	case Long:
+		if c.longAsString {
+			return fmt.Sprintf("%d", tag)
 		}
-		if longAsString {
-			output = longStringArray
-		} else {
-			output = longArray
+		return longToIntPair(int64(tag))
+	case Float, Double:
+		return jsonFloat(tag)
+	case ByteArray:
+		// Explicitly give empty arrays else value will be null instead of []
+		if tag == nil {
+			return []int8{}
 		}
-
-	default:
-		return nil, NbtParseError{fmt.Sprintf("TagType %d not recognized", tagType), nil}
+		return []int8(tag)
+	case String:
+		return jsonString(string(tag))
+	case List:
+		var tagList NbtTagList
+		tagList.TagListType = tag.TagListType
+		for _, item := range tag.Items {
+			tagList.List = append(tagList.List, c.jsonValue(item))
+		}
+		return tagList
+	case Compound:
+		// Explicitly give empty array else value will be null instead of []
+		compound := []NbtTag{}
+		for _, child := range tag {
+			compound = append(compound, c.jsonTag(child))
+		}
+		return compound
+	case IntArray:
+		if tag == nil {
+			return []int32{}
+		}
+		return []int32(tag)
+	case LongArray:
+		longArray := []NbtLong{}
+		longStringArray := []string{}
+		for _, i := range tag {
+			longArray = append(longArray, longToIntPair(i))
+			longStringArray = append(longStringArray, fmt.Sprintf("%d", i))
+		}
+		if c.longAsString {
+			return longStringArray
+		}
+		return longArray
 	}
-	return output, nil
+	// End tags have no value
+	return nil
 }
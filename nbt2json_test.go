@@ -2,10 +2,16 @@ package nbt2json
 
 import (
 	"bytes"
+	"compress/gzip"
 	"crypto/sha1"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"math"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -173,6 +179,79 @@ func TestRoundTrip(t *testing.T) {
 	if !bytes.Equal(jsonHash, jsonHash2) {
 		t.Fatal("Round trip JSON hashes don't match")
 	}
+
+	// Files in testdata/java and testdata/bedrock convert to JSON and back to the same bytes
+	java := NewConverter()
+	java.UseJavaEncoding()
+	corpus := map[string]*Converter{"java": java, "bedrock": NewConverter()}
+	for dir, c := range corpus {
+		files, err := filepath.Glob(filepath.Join("testdata", dir, "*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) == 0 {
+			t.Errorf("No files in testdata/%s", dir)
+		}
+		for _, file := range files {
+			nbtData, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(nbtData) > 1 && nbtData[0] == 0x1f && nbtData[1] == 0x8b {
+				zr, err := gzip.NewReader(bytes.NewReader(nbtData))
+				if err != nil {
+					t.Fatal(file, err)
+				}
+				nbtData, err = ioutil.ReadAll(zr)
+				if err != nil {
+					t.Fatal(file, err)
+				}
+			}
+			if err := c.RoundTrip(nbtData); err != nil {
+				t.Errorf("%s: %s", file, err.Error())
+			}
+		}
+	}
+}
+
+// TestRoundTripError checks NBT that converts back to different bytes is located
+func TestRoundTripError(t *testing.T) {
+	network := NewConverter()
+	network.UseBedrockNetworkEncoding()
+	nonCanonical := []struct {
+		c       *Converter
+		nbtData []byte
+		offset  int64
+		path    string
+		tagType byte
+	}{
+		// int 0 as an overlong varint
+		{network, []byte{10, 0, 3, 1, 'a', 0x80, 0, 0}, 5, "/a", 3},
+		// level.dat header with the wrong length
+		{NewConverter(), []byte{10, 0, 0, 0, 99, 0, 0, 0, 10, 0, 0, 1, 1, 0, 'a', 1, 0}, 4, "", 0},
+	}
+	for _, n := range nonCanonical {
+		err := n.c.RoundTrip(n.nbtData)
+		var roundTripErr RoundTripError
+		if !errors.As(err, &roundTripErr) {
+			t.Errorf("% x expected a RoundTripError, got %v", n.nbtData, err)
+			continue
+		}
+		if roundTripErr.Offset != n.offset || roundTripErr.Path != n.path || roundTripErr.TagType != n.tagType {
+			t.Errorf("% x expected round trip error at byte %d in %q (tag type %d), got %s", n.nbtData, n.offset, n.path, n.tagType, err.Error())
+		}
+	}
+	// Empty arrays are [] rather than null in JSON, and either converts back
+	if err := RoundTrip([]byte{10, 0, 0, 7, 1, 0, 'a', 0, 0, 0, 0, 11, 1, 0, 'b', 0, 0, 0, 0, 12, 1, 0, 'c', 0, 0, 0, 0, 0}); err != nil {
+		t.Error("Empty arrays round trip:", err.Error())
+	}
+	jsonOut, err := Nbt2Json([]byte{10, 0, 0, 11, 1, 0, 'b', 0, 0, 0, 0, 0}, "")
+	if err != nil || !bytes.Contains(jsonOut, []byte(`"value": []`)) {
+		t.Errorf("Empty array expected to convert to [], got %s, %v", jsonOut, err)
+	}
+	if _, err := Json2Nbt([]byte(`{"nbt":[{"tagType":11,"name":"","value":null}]}`)); err != nil {
+		t.Error("Null array expected to convert to an empty array, got", err.Error())
+	}
 }
 
 // TestValueConversions checks nbt value versus input json value
@@ -368,3 +447,407 @@ func TestOutOfRange(t *testing.T) {
 		}
 	}
 }
+
+// TestFloatBits checks floats and doubles round trip bit for bit through JSON and YAML, including NaN payloads, -0
+// and infinities
+func TestFloatBits(t *testing.T) {
+	floatBits := []uint32{0, 0x80000000, 1, 0x7f7fffff, 0x7f800000, 0xff800000, 0x7fc00000, 0xffc00000, 0x7f800001, 0x7fa00000, 0xffffffff}
+	doubleBits := []uint64{0, 0x8000000000000000, 1, 0x7ff0000000000000, 0xfff0000000000000, 0x7ff8000000000000, 0xfff8000000000000, 0x7ff0000000000001, 0x7ff8000000000001, 0xffffffffffffffff}
+	var list Compound
+	for i, bits := range floatBits {
+		list = append(list, NamedTag{fmt.Sprintf("f%d", i), Float(math.Float32frombits(bits))})
+	}
+	for i, bits := range doubleBits {
+		list = append(list, NamedTag{fmt.Sprintf("d%d", i), Double(math.Float64frombits(bits))})
+	}
+	tags := []NamedTag{{"", list}}
+	var nbtOut bytes.Buffer
+	if err := WriteNBT(&nbtOut, tags); err != nil {
+		t.Fatal("Error writing tags:", err.Error())
+	}
+	nbtData := nbtOut.Bytes()
+
+	jsonData, err := Nbt2Json(nbtData, "")
+	if err != nil {
+		t.Fatal("Error converting NBT to JSON:", err.Error())
+	}
+	for _, s := range []string{`"Infinity"`, `"-Infinity"`, `"NaN"`, `"NaN(0xffc00000)"`, `"NaN(0x7ff0000000000001)"`, `"-0"`} {
+		if !bytes.Contains(jsonData, []byte(s)) {
+			t.Errorf("JSON expected to contain %s", s)
+		}
+	}
+	again, err := Json2Nbt(jsonData)
+	if err != nil {
+		t.Fatal("Error converting JSON to NBT:", err.Error())
+	}
+	if !bytes.Equal(nbtData, again) {
+		t.Errorf("JSON round trip changed NBT\n% x\n% x", nbtData, again)
+	}
+	treeJson, _ := Tags2Json(tags, "")
+	treeTags, err := Json2Tags(treeJson)
+	if err != nil {
+		t.Fatal("Error converting JSON to tags:", err.Error())
+	}
+	if diffs := Diff(tags, treeTags, false); len(diffs) > 0 {
+		t.Errorf("Tree JSON round trip changed tags:\n%s", Diff2Text(diffs))
+	}
+	yamlData, err := Nbt2Yaml(nbtData, "")
+	if err != nil {
+		t.Fatal("Error converting NBT to YAML:", err.Error())
+	}
+	again, err = Yaml2Nbt(yamlData)
+	if err != nil {
+		t.Fatal("Error converting YAML to NBT:", err.Error())
+	}
+	if !bytes.Equal(nbtData, again) {
+		t.Errorf("YAML round trip changed NBT\n% x\n% x", nbtData, again)
+	}
+
+	for _, value := range []string{`"NaN(0x7f800000)"`, `"NaN(0x7fc000000)"`, `"nan"`, `"x"`, `null`} {
+		if _, err := Json2Nbt([]byte(fmt.Sprintf(testNumberRangeJsonTemplate, 5, "", value))); err == nil {
+			t.Errorf("Float value %s expected an error", value)
+		}
+	}
+}
+
+// TestConverter checks that separately-configured converters don't affect each other or the module defaults
+func TestConverter(t *testing.T) {
+	java := NewConverter()
+	java.UseJavaEncoding()
+	bedrock := NewConverter()
+	json := []byte(fmt.Sprintf(testNumberRangeJsonTemplate, 3, "", 1))
+	javaNbt := []byte{3, 0, 0, 0, 0, 0, 1}
+	bedrockNbt := []byte{3, 0, 0, 1, 0, 0, 0}
+
+	done := make(chan error)
+	for i := 0; i < 10; i++ {
+		go func() {
+			nbtData, err := java.Json2Nbt(json)
+			if err == nil && !bytes.Equal(nbtData, javaNbt) {
+				err = fmt.Errorf("Java converter expected \n%s\n, got \n%s\n", hex.Dump(javaNbt), hex.Dump(nbtData))
+			}
+			done <- err
+		}()
+		go func() {
+			nbtData, err := bedrock.Json2Nbt(json)
+			if err == nil && !bytes.Equal(nbtData, bedrockNbt) {
+				err = fmt.Errorf("Bedrock converter expected \n%s\n, got \n%s\n", hex.Dump(bedrockNbt), hex.Dump(nbtData))
+			}
+			done <- err
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}
+
+	// The package-level functions use their own default converter
+	nbtData, err := Json2Nbt(json)
+	if err != nil {
+		t.Fatal("Error converting with default converter:", err.Error())
+	}
+	if !bytes.Equal(nbtData, bedrockNbt) {
+		t.Error(fmt.Sprintf("Default converter expected \n%s\n, got \n%s\n", hex.Dump(bedrockNbt), hex.Dump(nbtData)))
+	}
+}
+
+// TestStreaming checks the Decoder and Encoder against the byte array functions, and that tags
+// with their fields out of the usual order still encode
+func TestStreaming(t *testing.T) {
+	nbtData, err := Json2Nbt([]byte(testJson))
+	if err != nil {
+		t.Fatal("Error converting test json:", err.Error())
+	}
+
+	var jsonOut bytes.Buffer
+	err = NewDecoder(bytes.NewReader(nbtData)).Decode(&jsonOut, "")
+	if err != nil {
+		t.Fatal("Error decoding nbt stream:", err.Error())
+	}
+
+	var nbtOut bytes.Buffer
+	err = NewEncoder(&nbtOut).Encode(&jsonOut)
+	if err != nil {
+		t.Fatal("Error encoding json stream:", err.Error())
+	}
+	if !bytes.Equal(nbtData, nbtOut.Bytes()) {
+		t.Error(fmt.Sprintf("Streamed round trip expected \n%s\n, got \n%s\n", hex.Dump(nbtData), hex.Dump(nbtOut.Bytes())))
+	}
+
+	ordered := `{"nbt": [{"tagType": 10, "name": "", "value": [
+		{"tagType": 9, "name": "l", "value": {"tagListType": 9, "list": [{"tagListType": 3, "list": [1, 2]}]}},
+		{"tagType": 10, "name": "c", "value": [{"tagType": 1, "name": "b", "value": 1}]}
+	]}]}`
+	unordered := `{"nbt": [{"value": [
+		{"value": {"list": [{"list": [1, 2], "tagListType": 3}], "tagListType": 9}, "name": "l", "tagType": 9},
+		{"name": "c", "value": [{"value": 1, "tagType": 1, "name": "b"}], "tagType": 10}
+	], "tagType": 10, "name": ""}]}`
+	orderedNbt, err := Json2Nbt([]byte(ordered))
+	if err != nil {
+		t.Fatal("Error converting ordered json:", err.Error())
+	}
+	unorderedNbt, err := Json2Nbt([]byte(unordered))
+	if err != nil {
+		t.Fatal("Error converting unordered json:", err.Error())
+	}
+	if !bytes.Equal(orderedNbt, unorderedNbt) {
+		t.Error(fmt.Sprintf("Field order changed output, expected \n%s\n, got \n%s\n", hex.Dump(orderedNbt), hex.Dump(unorderedNbt)))
+	}
+}
+
+// TestNetworkEncoding checks Bedrock network NBT's varint ints, longs and lengths
+func TestNetworkEncoding(t *testing.T) {
+	network := NewConverter()
+	network.UseBedrockNetworkEncoding()
+
+	networkJson := `{"nbt": [{"tagType": 10, "name": "", "value": [
+		{"tagType": 3, "name": "i", "value": -1},
+		{"tagType": 4, "name": "l", "value": "300"},
+		{"tagType": 2, "name": "s", "value": 1},
+		{"tagType": 8, "name": "str", "value": "hi"},
+		{"tagType": 9, "name": "list", "value": {"tagListType": 3, "list": [64, -65]}},
+		{"tagType": 11, "name": "ia", "value": [1]},
+		{"tagType": 12, "name": "la", "value": ["-2"]}
+	]}]}`
+	expected := []byte{
+		10, 0,
+		3, 1, 'i', 0x01,
+		4, 1, 'l', 0xd8, 0x04,
+		2, 1, 's', 0x01, 0x00,
+		8, 3, 's', 't', 'r', 2, 'h', 'i',
+		9, 4, 'l', 'i', 's', 't', 3, 0x04, 0x80, 0x01, 0x81, 0x01,
+		11, 2, 'i', 'a', 0x02, 0x02,
+		12, 2, 'l', 'a', 0x02, 0x03,
+		0,
+	}
+	nbtData, err := network.Json2Nbt([]byte(networkJson))
+	if err != nil {
+		t.Fatal("Error converting network json:", err.Error())
+	}
+	if !bytes.Equal(nbtData, expected) {
+		t.Error(fmt.Sprintf("Network encoding expected \n%s\n, got \n%s\n", hex.Dump(expected), hex.Dump(nbtData)))
+	}
+
+	jsonOut, err := network.Nbt2Json(nbtData, "")
+	if err != nil {
+		t.Fatal("Error converting network nbt:", err.Error())
+	}
+	nbtOut, err := network.Json2Nbt(jsonOut)
+	if err != nil {
+		t.Fatal("Error converting network json back:", err.Error())
+	}
+	if !bytes.Equal(nbtOut, expected) {
+		t.Error(fmt.Sprintf("Network round trip expected \n%s\n, got \n%s\n", hex.Dump(expected), hex.Dump(nbtOut)))
+	}
+
+	// NOTE: Tested function should throw error to pass
+	_, err = network.Nbt2Json([]byte{3, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, "")
+	if err == nil {
+		t.Error("Overlong varint failed to throw error")
+	}
+}
+
+// TestModifiedUtf8 checks Java Edition names and strings use modified UTF-8
+func TestModifiedUtf8(t *testing.T) {
+	java := NewConverter()
+	java.UseJavaEncoding()
+	tags := []NamedTag{{"a\x00b", String("sign 😀 é")}}
+	expected := []byte{
+		8, 0, 4, 'a', 0xc0, 0x80, 'b',
+		0, 14, 's', 'i', 'g', 'n', ' ', 0xed, 0xa0, 0xbd, 0xed, 0xb8, 0x80, ' ', 0xc3, 0xa9,
+	}
+	var buf bytes.Buffer
+	if err := java.WriteNBT(&buf, tags); err != nil {
+		t.Fatal("Error writing modified UTF-8:", err.Error())
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Error(fmt.Sprintf("Modified UTF-8 expected \n%s\n, got \n%s\n", hex.Dump(expected), hex.Dump(buf.Bytes())))
+	}
+	tagsOut, err := java.ReadNBT(bytes.NewReader(expected))
+	if err != nil {
+		t.Fatal("Error reading modified UTF-8:", err.Error())
+	}
+	if !reflect.DeepEqual(tagsOut, tags) {
+		t.Errorf("Modified UTF-8 expected %#v, got %#v", tags, tagsOut)
+	}
+
+	// Bedrock strings are plain UTF-8
+	bedrock := NewConverter()
+	buf.Reset()
+	if err := bedrock.WriteNBT(&buf, []NamedTag{{"", String("😀")}}); err != nil {
+		t.Fatal("Error writing UTF-8:", err.Error())
+	}
+	if !bytes.Equal(buf.Bytes(), []byte{8, 0, 0, 4, 0, 0xf0, 0x9f, 0x98, 0x80}) {
+		t.Errorf("Bedrock string expected UTF-8, got \n%s\n", hex.Dump(buf.Bytes()))
+	}
+
+	// Standard UTF-8 emoji and unpaired surrogates aren't valid modified UTF-8. Raw bytes that are valid UTF-8 are
+	// written back as modified UTF-8; the rest are written unchanged.
+	malformedStrings := []struct {
+		raw, written []byte
+	}{
+		{[]byte{0xf0, 0x9f, 0x98, 0x80}, []byte{0xed, 0xa0, 0xbd, 0xed, 0xb8, 0x80}},
+		{[]byte{0xed, 0xa0, 0xbd}, []byte{0xed, 0xa0, 0xbd}},
+		{[]byte{0xc3}, []byte{0xc3}},
+	}
+	raw := NewConverter()
+	raw.UseJavaEncoding()
+	raw.UseRawStringFallback()
+	for _, test := range malformedStrings {
+		nbtData := append([]byte{8, 0, 0, 0, byte(len(test.raw))}, test.raw...)
+		if _, err := java.ReadNBT(bytes.NewReader(nbtData)); err == nil {
+			t.Errorf("Reading % x failed to throw error", test.raw)
+		}
+		tagsOut, err := raw.ReadNBT(bytes.NewReader(nbtData))
+		if err != nil {
+			t.Errorf("Error reading % x with raw string fallback: %s", test.raw, err.Error())
+			continue
+		}
+		expected := append([]byte{8, 0, 0, 0, byte(len(test.written))}, test.written...)
+		buf.Reset()
+		if err := raw.WriteNBT(&buf, tagsOut); err != nil {
+			t.Errorf("Error writing % x raw string: %s", test.raw, err.Error())
+		} else if !bytes.Equal(buf.Bytes(), expected) {
+			t.Errorf("Raw string expected \n%s\n, got \n%s\n", hex.Dump(expected), hex.Dump(buf.Bytes()))
+		}
+		// JSON has the raw bytes in hex, so they convert back the same
+		jsonData, err := raw.Nbt2Json(nbtData, "")
+		if err != nil {
+			t.Errorf("Error converting % x raw string to JSON: %s", test.raw, err.Error())
+			continue
+		}
+		nbtOut, err := raw.Json2Nbt(jsonData)
+		if err != nil {
+			t.Errorf("Error converting % x raw string back from JSON: %s", test.raw, err.Error())
+		} else if !bytes.Equal(nbtOut, expected) {
+			t.Errorf("Raw string from JSON expected \n%s\n, got \n%s\n", hex.Dump(expected), hex.Dump(nbtOut))
+		}
+	}
+
+	// Raw names too, in streamed and tree JSON and in lists
+	nbtData := []byte{10, 0, 1, 0xc3, 8, 0, 2, 0xed, 0xa0, 0, 1, 0xc3, 9, 0, 1, 'l', 8, 0, 0, 0, 1, 0, 1, 0xc3, 0}
+	jsonData, err := raw.Nbt2Json(nbtData, "")
+	if err != nil {
+		t.Fatal("Error converting raw names to JSON:", err.Error())
+	}
+	if !bytes.Contains(jsonData, []byte(`"name": {"raw":"c3"}`)) {
+		t.Errorf("Raw name expected as hex in JSON, got %s", jsonData)
+	}
+	nbtOut, err := raw.Json2Nbt(jsonData)
+	if err != nil || !bytes.Equal(nbtOut, nbtData) {
+		t.Errorf("Raw names from JSON expected \n%s\n, got \n%s\n, %v", hex.Dump(nbtData), hex.Dump(nbtOut), err)
+	}
+	tagsOut, _ = raw.ReadNBT(bytes.NewReader(nbtData))
+	jsonData, err = raw.Tags2Json(tagsOut, "")
+	if err == nil {
+		tagsOut, err = raw.Json2Tags(jsonData)
+	}
+	buf.Reset()
+	if err == nil {
+		err = raw.WriteNBT(&buf, tagsOut)
+	}
+	if err != nil || !bytes.Equal(buf.Bytes(), nbtData) {
+		t.Errorf("Raw names from tree JSON expected \n%s\n, got \n%s\n, %v", hex.Dump(nbtData), hex.Dump(buf.Bytes()), err)
+	}
+}
+
+// TestLevelDatHeader checks Bedrock's level.dat header is recorded in the json and regenerated with the right length
+func TestLevelDatHeader(t *testing.T) {
+	nbtData, err := Json2Nbt([]byte(testJson))
+	if err != nil {
+		t.Fatal("Error converting test json:", err.Error())
+	}
+	levelDat := append([]byte{10, 0, 0, 0, byte(len(nbtData)), byte(len(nbtData) >> 8), 0, 0}, nbtData...)
+	jsonOut, err := Nbt2Json(levelDat, "")
+	if err != nil {
+		t.Fatal("Error converting level.dat:", err.Error())
+	}
+	var nbtJson NbtJson
+	err = json.Unmarshal(jsonOut, &nbtJson)
+	if err != nil {
+		t.Fatal("Error parsing level.dat json:", err.Error())
+	}
+	if nbtJson.Header == nil || nbtJson.Header.StorageVersion != 10 {
+		t.Errorf("level.dat header expected storage version 10, got %#v", nbtJson.Header)
+	}
+	nbtOut, err := Json2Nbt(jsonOut)
+	if err != nil {
+		t.Fatal("Error converting level.dat json:", err.Error())
+	}
+	if !bytes.Equal(nbtOut, levelDat) {
+		t.Error(fmt.Sprintf("level.dat round trip expected \n%s\n, got \n%s\n", hex.Dump(levelDat), hex.Dump(nbtOut)))
+	}
+
+	// Editing the nbt updates the length
+	edited := []byte(`{"header": {"storageVersion": 9}, "nbt": [{"tagType": 10, "name": "", "value": [{"tagType": 1, "name": "a", "value": 1}]}]}`)
+	expected := []byte{9, 0, 0, 0, 9, 0, 0, 0, 10, 0, 0, 1, 1, 0, 'a', 1, 0}
+	nbtOut, err = Json2Nbt(edited)
+	if err != nil {
+		t.Fatal("Error converting edited level.dat json:", err.Error())
+	}
+	if !bytes.Equal(nbtOut, expected) {
+		t.Error(fmt.Sprintf("Edited level.dat expected \n%s\n, got \n%s\n", hex.Dump(expected), hex.Dump(nbtOut)))
+	}
+
+	// Plain NBT, even starting with an empty compound, has no header
+	for _, plain := range [][]byte{nbtData, {10, 0, 0, 0, 1, 1, 0, 'a', 1}} {
+		jsonOut, err = Nbt2Json(plain, "")
+		if err != nil {
+			t.Errorf("Error converting % x: %s", plain, err.Error())
+		} else if bytes.Contains(jsonOut, []byte(`"header"`)) {
+			t.Errorf("Plain NBT % x converted with a header", plain)
+		}
+	}
+}
+
+// TestDetectEncoding checks the byte order is detected for the test json's nbt in both encodings
+func TestDetectEncoding(t *testing.T) {
+	java := NewConverter()
+	java.UseJavaEncoding()
+	bedrock := NewConverter()
+	for _, c := range []*Converter{java, bedrock} {
+		nbtData, err := c.Json2Nbt([]byte(testJson))
+		if err != nil {
+			t.Fatal("Error converting test json:", err.Error())
+		}
+		expected := BedrockEncoding
+		if c == java {
+			expected = JavaEncoding
+		}
+		levelDat := append([]byte{10, 0, 0, 0, byte(len(nbtData)), byte(len(nbtData) >> 8), 0, 0}, nbtData...)
+		samples := map[string][]byte{
+			"nbt":       nbtData,
+			"truncated": nbtData[:len(nbtData)/2],
+		}
+		if c == bedrock {
+			samples["level.dat"] = levelDat
+		}
+		for name, sample := range samples {
+			encoding, confidence := DetectEncoding(sample)
+			if encoding != expected || confidence < 0.75 {
+				t.Errorf("Detecting %s expected %s, got %s with confidence %f", name, expected, encoding, confidence)
+			}
+		}
+	}
+
+	c := NewConverter()
+	for _, encoding := range []Encoding{JavaEncoding, BedrockNetworkEncoding, BedrockEncoding} {
+		c.UseEncoding(encoding)
+		if c.Encoding() != encoding {
+			t.Errorf("Converter set to %s reports %s", encoding, c.Encoding())
+		}
+	}
+
+	// Empty names and single bytes read the same both ways
+	encoding, confidence := DetectEncoding([]byte{10, 0, 0, 1, 0, 0, 1, 0})
+	if encoding == UnknownEncoding || confidence != 0.5 {
+		t.Errorf("Detecting symmetric nbt expected confidence 0.5, got %s with confidence %f", encoding, confidence)
+	}
+
+	for _, notNbt := range [][]byte{{}, []byte("not nbt at all"), {0, 0, 0, 0}} {
+		if encoding, confidence := DetectEncoding(notNbt); encoding != UnknownEncoding || confidence != 0 {
+			t.Errorf("Detecting % x expected unknown, got %s with confidence %f", notNbt, encoding, confidence)
+		}
+	}
+}
@@ -0,0 +1,422 @@
+package nbt2json
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// JSON Patch (RFC 6902) and merge patch (RFC 7386) documents are applied to a name-based view of the tag tree, in
+// which compounds are objects keyed by child name, lists and arrays are arrays, and other tags are plain numbers and
+// strings. A JSON Pointer like /Data/Player/Inventory/3/id then works like the path Data.Player.Inventory[3].id.
+//
+// When the patched view is converted back, each value takes the tag type of the tag that was at its place, so
+// {"op":"replace","path":"/Data/Player/Health","value":20} keeps Health a Float. New list items take the list's type.
+// Other new values are typed from the JSON: whole numbers are Ints (or Longs if too large), other numbers are Doubles,
+// strings are Strings, booleans are Bytes, objects are Compounds and arrays are Lists. To choose a type or change one,
+// give the value in the JSON document's form, e.g. {"tagType":1,"value":5} for a Byte; an object with only tagType and
+// value keys is always read this way.
+
+// JsonPatch applies an RFC 6902 JSON Patch to the name-based view of a tag tree using the module's default settings
+func JsonPatch(tags []NamedTag, patch []byte) ([]NamedTag, error) {
+	return defaultConverter.JsonPatch(tags, patch)
+}
+
+// MergePatch applies an RFC 7386 JSON merge patch to the name-based view of a tag tree using the module's default
+// settings
+func MergePatch(tags []NamedTag, patch []byte) ([]NamedTag, error) {
+	return defaultConverter.MergePatch(tags, patch)
+}
+
+// JsonPatchDocument applies an RFC 6902 JSON Patch to a tag tree's JSON document using the module's default settings
+func JsonPatchDocument(tags []NamedTag, patch []byte) ([]NamedTag, error) {
+	return defaultConverter.JsonPatchDocument(tags, patch)
+}
+
+// JsonPatch applies an RFC 6902 JSON Patch to the name-based view of a tag tree and returns the patched tree. The
+// original tree isn't changed.
+func (c *Converter) JsonPatch(tags []NamedTag, patch []byte) ([]NamedTag, error) {
+	view, err := applyJsonPatch(treeView(tags), patch)
+	if err != nil {
+		return nil, err
+	}
+	return c.viewTree(view, tags)
+}
+
+// MergePatch applies an RFC 7386 JSON merge patch to the name-based view of a tag tree and returns the patched tree.
+// The original tree isn't changed.
+func (c *Converter) MergePatch(tags []NamedTag, patch []byte) ([]NamedTag, error) {
+	decoded, err := decodeJson(patch)
+	if err != nil {
+		return nil, PatchError{"Reading merge patch", err}
+	}
+	return c.viewTree(applyMergePatch(treeView(tags), decoded), tags)
+}
+
+// JsonPatchDocument applies an RFC 6902 JSON Patch to the JSON document Tags2Json makes of a tag tree, so pointers
+// look like /nbt/0/value/2/value and values have tagType, name and value, and returns the patched tree
+func (c *Converter) JsonPatchDocument(tags []NamedTag, patch []byte) ([]NamedTag, error) {
+	jsonOut, err := c.Tags2Json(tags, "")
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeJson(jsonOut)
+	if err != nil {
+		return nil, PatchError{"Reading JSON document", err}
+	}
+	doc, err = applyJsonPatch(doc, patch)
+	if err != nil {
+		return nil, err
+	}
+	jsonOut, err = json.Marshal(doc)
+	if err != nil {
+		return nil, PatchError{"Writing patched JSON document", err}
+	}
+	return c.Json2Tags(jsonOut)
+}
+
+// treeView returns the name-based view of a tree: the view of the top-level tag, or an array of views if there are
+// several
+func treeView(tags []NamedTag) interface{} {
+	if len(tags) == 1 {
+		return tagView(tags[0].Value)
+	}
+	views := []interface{}{}
+	for _, tag := range tags {
+		views = append(views, tagView(tag.Value))
+	}
+	return views
+}
+
+// viewTree converts a patched view back to a tree, using the original tree for tag types and top-level names
+func (c *Converter) viewTree(view interface{}, original []NamedTag) ([]NamedTag, error) {
+	if len(original) == 1 {
+		tag, err := c.viewTag(view, original[0].Value, "")
+		if err != nil {
+			return nil, err
+		}
+		return []NamedTag{{Name: original[0].Name, Value: tag}}, nil
+	}
+	views, ok := view.([]interface{})
+	if !ok {
+		return nil, PatchError{"Data with several top-level tags must stay an array", nil}
+	}
+	tags := make([]NamedTag, len(views))
+	for i, v := range views {
+		var template Tag
+		if i < len(original) {
+			tags[i].Name = original[i].Name
+			template = original[i].Value
+		}
+		tag, err := c.viewTag(v, template, "/"+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		tags[i].Value = tag
+	}
+	return tags, nil
+}
+
+// tagView converts a tag to its name-based view. Numbers and strings stay tags, so values copied or moved by a patch
+// keep their tag types; plainView gives their plain JSON values.
+func tagView(tag Tag) interface{} {
+	switch tag := tag.(type) {
+	case Compound:
+		o := newJsonObject()
+		for _, child := range tag {
+			o.set(child.Name, tagView(child.Value))
+		}
+		return o
+	case List, ByteArray, IntArray, LongArray:
+		views := []interface{}{}
+		for _, item := range tagItems(tag) {
+			views = append(views, tagView(item))
+		}
+		return views
+	case End:
+		return nil
+	}
+	return tag
+}
+
+// plainView returns the plain JSON value of a number or string tag in a view, and other values unchanged
+func plainView(v interface{}) interface{} {
+	switch v := v.(type) {
+	case Byte, Short, Int, Long:
+		n, _, _ := tagInteger(v.(Tag))
+		return json.Number(strconv.FormatInt(n, 10))
+	case Float, Double:
+		return floatView(v.(Tag))
+	case String:
+		return string(v)
+	}
+	return v
+}
+
+// floatView returns a float or double as a JSON number, or as the string Nbt2Json writes for NaN and infinities
+func floatView(tag Tag) interface{} {
+	switch f := jsonFloat(tag).(type) {
+	case float32:
+		return json.Number(strconv.FormatFloat(float64(f), 'g', -1, 32))
+	case float64:
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+	default:
+		return f
+	}
+}
+
+// viewType returns the tag type a view value gets when there's no tag to take the type from
+func viewType(v interface{}) (byte, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			if n < math.MinInt32 || n > math.MaxInt32 {
+				return 4, nil
+			}
+			return 3, nil
+		}
+		return 6, nil
+	case string:
+		return 8, nil
+	case bool:
+		return 1, nil
+	case *jsonObject:
+		return 10, nil
+	case []interface{}:
+		return 9, nil
+	}
+	return 0, fmt.Errorf("null is not a tag value")
+}
+
+// typedView returns the tag type and value of a value in the JSON document's {"tagType":N,"value":...} form
+func typedView(v interface{}) (float64, interface{}, bool) {
+	o, ok := v.(*jsonObject)
+	if !ok || len(o.keys) != 2 {
+		return 0, nil, false
+	}
+	tagType, ok := o.values["tagType"].(json.Number)
+	value, hasValue := o.values["value"]
+	if !ok || !hasValue {
+		return 0, nil, false
+	}
+	t, err := tagType.Float64()
+	if err != nil {
+		return 0, nil, false
+	}
+	// payloadFromJson expects encoding/json's usual types
+	b, err := json.Marshal(value)
+	if err != nil {
+		return 0, nil, false
+	}
+	var plain interface{}
+	if json.Unmarshal(b, &plain) != nil {
+		return 0, nil, false
+	}
+	return t, plain, true
+}
+
+// viewTag converts a view value to a tag of template's type, or of the type viewType gives if template is nil
+func (c *Converter) viewTag(v interface{}, template Tag, pointer string) (Tag, error) {
+	if tag, ok := v.(Tag); ok {
+		if template == nil || template.TagType() == tag.TagType() {
+			return tag, nil
+		}
+		v = plainView(v)
+	}
+	if tagType, value, ok := typedView(v); ok {
+		tag, err := c.payloadFromJson(value, tagType)
+		if err != nil {
+			return nil, PatchError{"At " + pointer, err}
+		}
+		return tag, nil
+	}
+	var tagType byte
+	if template != nil {
+		tagType = template.TagType()
+	} else {
+		var err error
+		tagType, err = viewType(v)
+		if err != nil {
+			return nil, PatchError{fmt.Sprintf("At %s: %s", pointer, err.Error()), nil}
+		}
+	}
+	mismatch := PatchError{fmt.Sprintf("At %s: %v can't be tag type %d; give it as {\"tagType\":N,\"value\":...} to change the type", pointer, v, tagType), nil}
+
+	switch tagType {
+	case 1, 2, 3, 4:
+		var n int64
+		switch v := v.(type) {
+		case json.Number:
+			var err error
+			n, err = strconv.ParseInt(string(v), 10, 64)
+			if err != nil {
+				return nil, mismatch
+			}
+		case bool:
+			if v {
+				n = 1
+			}
+		default:
+			return nil, mismatch
+		}
+		tag, ok := convertNumber(Long(n), tagType)
+		if !ok {
+			return nil, PatchError{fmt.Sprintf("At %s: %d is out of range for tag type %d", pointer, n, tagType), nil}
+		}
+		return tag, nil
+	case 5, 6:
+		var f float64
+		switch v := v.(type) {
+		case json.Number:
+			var err error
+			f, err = strconv.ParseFloat(string(v), 64)
+			if err != nil {
+				return nil, mismatch
+			}
+		case string:
+			if tagType == 5 {
+				bits, ok := specialFloatBits(v, 32)
+				if !ok {
+					return nil, mismatch
+				}
+				return Float(math.Float32frombits(uint32(bits))), nil
+			}
+			bits, ok := specialFloatBits(v, 64)
+			if !ok {
+				return nil, mismatch
+			}
+			return Double(math.Float64frombits(bits)), nil
+		default:
+			return nil, mismatch
+		}
+		if tagType == 5 {
+			return Float(f), nil
+		}
+		return Double(f), nil
+	case 8:
+		if s, ok := v.(string); ok {
+			return String(s), nil
+		}
+		return nil, mismatch
+	case 10:
+		o, ok := v.(*jsonObject)
+		if !ok {
+			return nil, mismatch
+		}
+		templateCompound, _ := template.(Compound)
+		compound := Compound{}
+		for _, key := range o.keys {
+			var childTemplate Tag
+			if templateCompound != nil {
+				childTemplate = templateCompound.Get(key)
+			}
+			child, err := c.viewTag(o.values[key], childTemplate, pointer+"/"+escapePointer(key))
+			if err != nil {
+				return nil, err
+			}
+			compound = append(compound, NamedTag{Name: key, Value: child})
+		}
+		return compound, nil
+	case 7, 9, 11, 12:
+		views, ok := v.([]interface{})
+		if !ok {
+			return nil, mismatch
+		}
+		var templateItems []Tag
+		if template != nil {
+			templateItems = tagItems(template)
+		}
+		items := make([]Tag, len(views))
+		for i, view := range views {
+			// Items take the type of the item they replace, or of the first item
+			var itemTemplate Tag
+			switch {
+			case i < len(templateItems):
+				itemTemplate = templateItems[i]
+			case len(templateItems) > 0:
+				itemTemplate = templateItems[0]
+			case i > 0:
+				itemTemplate = items[0]
+			case tagType == 7:
+				itemTemplate = Byte(0)
+			case tagType == 11:
+				itemTemplate = Int(0)
+			case tagType == 12:
+				itemTemplate = Long(0)
+			}
+			item, err := c.viewTag(view, itemTemplate, pointer+"/"+strconv.Itoa(i))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		// Items given as {"tagType":N,"value":...} can be any type, but arrays only hold their own number type
+		itemMismatch := func(i int, itemType byte) error {
+			return PatchError{fmt.Sprintf("At %s/%d: tag type %d can't be in an array of tag type %d; items must be tag type %d", pointer, i, items[i].TagType(), tagType, itemType), nil}
+		}
+		switch tagType {
+		case 7:
+			array := ByteArray{}
+			for i, item := range items {
+				n, ok := item.(Byte)
+				if !ok {
+					return nil, itemMismatch(i, 1)
+				}
+				array = append(array, int8(n))
+			}
+			return array, nil
+		case 11:
+			array := IntArray{}
+			for i, item := range items {
+				n, ok := item.(Int)
+				if !ok {
+					return nil, itemMismatch(i, 3)
+				}
+				array = append(array, int32(n))
+			}
+			return array, nil
+		case 12:
+			array := LongArray{}
+			for i, item := range items {
+				n, ok := item.(Long)
+				if !ok {
+					return nil, itemMismatch(i, 4)
+				}
+				array = append(array, int64(n))
+			}
+			return array, nil
+		}
+		list := List{}
+		if templateList, ok := template.(List); ok {
+			list.TagListType = templateList.TagListType
+		}
+		if len(items) > 0 {
+			list.TagListType = items[0].TagType()
+		}
+		for i, item := range items {
+			if item.TagType() != list.TagListType {
+				return nil, PatchError{fmt.Sprintf("At %s/%d: tag type %d can't be in a list of tag type %d", pointer, i, item.TagType(), list.TagListType), nil}
+			}
+		}
+		list.Items = items
+		return list, nil
+	}
+	return nil, mismatch
+}
+
+// escapePointer escapes a compound child name for a JSON Pointer
+func escapePointer(s string) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '~':
+			b = append(b, '~', '0')
+		case '/':
+			b = append(b, '~', '1')
+		default:
+			b = append(b, s[i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,112 @@
+package nbt2json
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestJsonPatch checks JSON Patch operations on the name-based view keep tag types
+func TestJsonPatch(t *testing.T) {
+	tags, err := Snbt2Tags([]byte(pathTestSnbt))
+	if err != nil {
+		t.Fatal("Error parsing test SNBT:", err.Error())
+	}
+	original := string(Tags2Snbt(tags))
+	patches := []struct {
+		patch    string
+		path     string
+		expected string
+	}{
+		{`[{"op":"replace","path":"/Data/LevelName","value":"New"}]`, `Data.LevelName`, `"New"`},
+		{`[{"op":"replace","path":"/Data/Player/Inventory/0/Count","value":1}]`, `Data.Player.Inventory[0].Count`, `1b`},
+		{`[{"op":"replace","path":"/Data/Player/Pos/1","value":70}]`, `Data.Player.Pos`, `[1.5d,70.0d,-3.5d]`},
+		{`[{"op":"add","path":"/Data/Player/Pos/-","value":1}]`, `Data.Player.Pos[3]`, `1.0d`},
+		{`[{"op":"add","path":"/Data/Player/odd.name/0","value":0}]`, `Data.Player."odd.name"`, `[I;0,1,2,3]`},
+		{`[{"op":"add","path":"/Data/Player/Health","value":{"tagType":5,"value":20}}]`, `Data.Player.Health`, `20.0f`},
+		{`[{"op":"add","path":"/Data/New","value":{"a":1,"b":5000000000,"c":1.5,"d":"x","e":true,"f":[1,2]}}]`, `Data.New`, `{a:1,b:5000000000L,c:1.5d,d:"x",e:1b,f:[1,2]}`},
+		{`[{"op":"replace","path":"/Data/Player/OnGround","value":{"tagType":3,"value":1}}]`, `Data.Player.OnGround`, `1`},
+		{`[{"op":"remove","path":"/Data/Player/Inventory/1"}]`, `Data.Player.Inventory[].Slot`, `0b 5b`},
+		{`[{"op":"move","from":"/Data/Player/Inventory/0","path":"/Data/Player/Inventory/-"}]`, `Data.Player.Inventory[].Slot`, `3b 5b 0b`},
+		{`[{"op":"copy","from":"/Data/Player/Inventory/2","path":"/Data/Hand"}]`, `Data.Hand`, `{Slot:5b,id:"minecraft:diamond",Count:1b}`},
+		{`[{"op":"test","path":"/Data/Player/Inventory/1/Count","value":2},{"op":"remove","path":"/Data/LevelName"}]`, `Data.LevelName`, ``},
+	}
+	for _, test := range patches {
+		patched, err := JsonPatch(tags, []byte(test.patch))
+		if err != nil {
+			t.Errorf("Error applying %s: %s", test.patch, err.Error())
+			continue
+		}
+		found, err := Query(patched, test.path)
+		if err != nil {
+			t.Fatal("Error querying:", err.Error())
+		}
+		var snbt []string
+		for _, tag := range found {
+			snbt = append(snbt, Snbt(tag.Value))
+		}
+		if got := strings.Join(snbt, " "); got != test.expected {
+			t.Errorf("After %s, %s expected %s, got %s", test.patch, test.path, test.expected, got)
+		}
+	}
+
+	failures := []string{
+		`[{"op":"replace","path":"/Data/LevelName","value":1}]`,
+		`[{"op":"replace","path":"/Data/Player/Inventory/0/Count","value":300}]`,
+		`[{"op":"add","path":"/Data/Player/Pos/0","value":"x"}]`,
+		`[{"op":"remove","path":"/Data/Missing"}]`,
+		`[{"op":"replace","path":"/Data/LevelName","value":"New"},{"op":"test","path":"/Data/Player/OnGround","value":0}]`,
+		`[{"op":"move","from":"/Data/Player","path":"/Data/Player/Inside"}]`,
+		`[{"op":"add","path":"/Data/New","value":null}]`,
+		`[{"op":"nope","path":"/Data"}]`,
+		`{"op":"remove","path":"/Data"}`,
+		`[{"op":"replace","path":"/Data/Player/odd.name/0","value":{"tagType":8,"value":"x"}}]`,
+	}
+	for _, patch := range failures {
+		if _, err := JsonPatch(tags, []byte(patch)); err == nil {
+			t.Errorf("Applying %s expected an error", patch)
+		} else if _, ok := err.(PatchError); !ok {
+			t.Errorf("Applying %s expected a PatchError, got %T", patch, err)
+		}
+	}
+	if string(Tags2Snbt(tags)) != original {
+		t.Error("Patching changed the original tree")
+	}
+
+	// Array items given another tag type are reported with their path and the array's item type
+	array := []NamedTag{{"", Compound{{"a", ByteArray{1, 2}}}}}
+	_, err = JsonPatch(array, []byte(`[{"op":"replace","path":"/a/0","value":{"tagType":8,"value":"x"}}]`))
+	if _, ok := err.(PatchError); !ok || !strings.Contains(err.Error(), "/a/0") || !strings.Contains(err.Error(), "must be tag type 1") {
+		t.Errorf("String in a byte array expected a PatchError at /a/0 naming tag type 1, got %v", err)
+	}
+}
+
+// TestMergePatch checks merge patches add, replace and remove compound children
+func TestMergePatch(t *testing.T) {
+	tags, _ := Snbt2Tags([]byte(pathTestSnbt))
+	patched, err := MergePatch(tags, []byte(`{"Data":{"LevelName":"Merged","Player":{"Inventory":null,"Pos":[0,1,2],"Health":20}}}`))
+	if err != nil {
+		t.Fatal("Error applying merge patch:", err.Error())
+	}
+	expected := `{Data:{LevelName:"Merged",Player:{OnGround:1b,Pos:[0.0d,1.0d,2.0d],odd.name:[I;1,2,3],Health:20}}}`
+	if got := Snbt(patched[0].Value); got != expected {
+		t.Errorf("Merge patch expected\n%s\n, got\n%s", expected, got)
+	}
+}
+
+// TestJsonPatchDocument checks patches addressed to the JSON document
+func TestJsonPatchDocument(t *testing.T) {
+	tags, _ := Snbt2Tags([]byte(`{a:1b,b:[I;1,2]}`))
+	patched, err := JsonPatchDocument(tags, []byte(`[
+		{"op":"replace","path":"/nbt/0/value/0/value","value":5},
+		{"op":"add","path":"/nbt/0/value/-","value":{"tagType":2,"name":"c","value":7}}
+	]`))
+	if err != nil {
+		t.Fatal("Error applying document patch:", err.Error())
+	}
+	if got := Snbt(patched[0].Value); got != `{a:5b,b:[I;1,2],c:7s}` {
+		t.Errorf("Document patch expected {a:5b,b:[I;1,2],c:7s}, got %s", got)
+	}
+	if _, err := JsonPatchDocument(tags, []byte(`[{"op":"replace","path":"/nbt/0/value/0/value","value":"x"}]`)); err == nil {
+		t.Error("Document patch with a bad value expected an error")
+	}
+}
@@ -0,0 +1,503 @@
+package nbt2json
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// NBT paths select tags in a tag tree, much like the paths of Java Edition's /data command. A path starts inside the
+// top-level compound, since top-level tags are usually unnamed, and is made of these parts:
+//   Data.Player          child tags of compounds by name; quote names with special characters like "a.b"
+//   Data.*               every child of a compound
+//   Inventory[3]         a list or array item; negative indexes count from the end
+//   Inventory[]          every list or array item, also written [*]
+//   Inventory[{id:"minecraft:diamond"}]  list items which are compounds containing the SNBT compound
+//   Player{OnGround:1b}  the tag itself, if it's a compound containing the SNBT compound
+// A tag contains a filter if it has every tag in the filter. Nested compounds are compared the same way, lists contain
+// a filter list if every filter item matches some item, and integers match integers of any type with the same value.
+
+type pathNodeKind int
+
+const (
+	pathChild pathNodeKind = iota
+	pathChildren
+	pathMatchCompound
+	pathIndex
+	pathItems
+	pathMatchItems
+)
+
+type pathNode struct {
+	kind   pathNodeKind
+	name   string
+	index  int
+	filter Compound
+}
+
+// Path is a parsed NBT path
+type Path struct {
+	s     string
+	nodes []pathNode
+}
+
+// pathMatch is a tag found by a path and the concrete path to it
+type pathMatch struct {
+	path string
+	tag  Tag
+	// set replaces the tag in the tree
+	set func(Tag)
+	// parent is the compound, list or array the tag is in, at index. It's nil for top-level tags.
+	parent *pathMatch
+	index  int
+}
+
+// ParsePath parses an NBT path expression
+func ParsePath(s string) (*Path, error) {
+	p := snbtParser{s: s, maxDepth: DefaultLimits.MaxDepth}
+	path := &Path{s: s}
+	if p.peek() == 0 {
+		return path, nil
+	}
+	for {
+		switch c := p.peek(); {
+		case c == '{':
+			// Only the first part of a path can be a filter without a name
+			if len(path.nodes) > 0 {
+				return nil, PathError{fmt.Sprintf("at position %d: expected a name", p.pos), nil}
+			}
+		case c == '"' || c == '\'':
+			name, err := p.quotedString()
+			if err != nil {
+				return nil, PathError{"Reading quoted name", err}
+			}
+			path.nodes = append(path.nodes, pathNode{kind: pathChild, name: name})
+		default:
+			name := p.pathName()
+			switch name {
+			case "":
+				return nil, PathError{fmt.Sprintf("at position %d: expected a name", p.pos), nil}
+			case "*":
+				path.nodes = append(path.nodes, pathNode{kind: pathChildren})
+			default:
+				path.nodes = append(path.nodes, pathNode{kind: pathChild, name: name})
+			}
+		}
+		if p.peek() == '{' {
+			filter, err := p.compound()
+			if err != nil {
+				return nil, PathError{"Reading compound filter", err}
+			}
+			path.nodes = append(path.nodes, pathNode{kind: pathMatchCompound, filter: filter.(Compound)})
+		}
+		for p.peek() == '[' {
+			p.pos++
+			node, err := p.pathBracket()
+			if err != nil {
+				return nil, err
+			}
+			path.nodes = append(path.nodes, node)
+		}
+		switch p.peek() {
+		case 0:
+			return path, nil
+		case '.':
+			p.pos++
+		default:
+			return nil, PathError{fmt.Sprintf("at position %d: expected '.', '[' or the end of the path", p.pos), nil}
+		}
+	}
+}
+
+// isPathNameChar reports whether c can be part of an unquoted name in a path. Unlike SNBT, names like
+// minecraft:custom_data don't need quotes.
+func isPathNameChar(c byte) bool {
+	return strings.IndexByte(" \t\r\n.[]{}\"'", c) < 0
+}
+
+func (p *snbtParser) pathName() string {
+	start := p.pos
+	for p.pos < len(p.s) && isPathNameChar(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// pathBracket reads what's in square brackets after the opening bracket
+func (p *snbtParser) pathBracket() (pathNode, error) {
+	var node pathNode
+	switch p.peek() {
+	case ']', '*':
+		node.kind = pathItems
+		if p.s[p.pos] == '*' {
+			p.pos++
+		}
+	case '{':
+		filter, err := p.compound()
+		if err != nil {
+			return node, PathError{"Reading list item filter", err}
+		}
+		node.kind = pathMatchItems
+		node.filter = filter.(Compound)
+	default:
+		start := p.pos
+		if p.pos < len(p.s) && p.s[p.pos] == '-' {
+			p.pos++
+		}
+		for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+			p.pos++
+		}
+		index, err := strconv.Atoi(p.s[start:p.pos])
+		if err != nil {
+			p.pos = start
+			return node, PathError{fmt.Sprintf("at position %d: expected an index, '*' or a compound filter in brackets", p.pos), nil}
+		}
+		node.kind = pathIndex
+		node.index = index
+	}
+	if p.peek() != ']' {
+		return node, PathError{fmt.Sprintf("at position %d: expected ']'", p.pos), nil}
+	}
+	p.pos++
+	return node, nil
+}
+
+// String returns the path as it was parsed
+func (p *Path) String() string {
+	return p.s
+}
+
+// Find returns the tags the path selects, each named with the concrete path to it, e.g. Inventory[2].id for
+// Inventory[{Slot:5b}].id
+func (p *Path) Find(tags []NamedTag) []NamedTag {
+	matches := p.find(tags)
+	found := make([]NamedTag, len(matches))
+	for i, m := range matches {
+		found[i] = NamedTag{Name: m.path, Value: m.tag}
+	}
+	return found
+}
+
+func (p *Path) find(tags []NamedTag) []pathMatch {
+	matches := make([]pathMatch, len(tags))
+	for i := range tags {
+		i := i
+		matches[i] = pathMatch{path: "", tag: tags[i].Value, set: func(t Tag) { tags[i].Value = t }}
+	}
+	for _, node := range p.nodes {
+		var next []pathMatch
+		for _, m := range matches {
+			node.apply(m, func(found pathMatch) {
+				next = append(next, found)
+			})
+		}
+		matches = next
+	}
+	return matches
+}
+
+// Set replaces every tag the path selects with value and returns how many were replaced. The value must be the same
+// tag type as the tag it replaces, except that numbers are converted to the replaced tag's number type if they fit,
+// so Int 20 can replace a Float or Int 1 a Byte. Nothing is changed if any tag can't be replaced.
+func (p *Path) Set(tags []NamedTag, value Tag) (int, error) {
+	matches := p.find(tags)
+	values := make([]Tag, len(matches))
+	for i, m := range matches {
+		v, ok := convertNumber(value, m.tag.TagType())
+		if !ok || v.TagType() != m.tag.TagType() {
+			return 0, PathError{fmt.Sprintf("%s is tag type %d, which can't be set to tag type %d", m.path, m.tag.TagType(), value.TagType()), nil}
+		}
+		values[i] = v
+	}
+	for i, m := range matches {
+		m.set(values[i])
+	}
+	return len(matches), nil
+}
+
+// Delete removes every tag the path selects from its compound, list or array and returns how many were removed.
+// Top-level tags can't be deleted.
+func (p *Path) Delete(tags []NamedTag) (int, error) {
+	matches := p.find(tags)
+	// Every match is at the same depth, so no match is inside another and each parent can be rebuilt once
+	var parents []*pathMatch
+	indexes := make(map[*pathMatch][]int)
+	for _, m := range matches {
+		if m.parent == nil {
+			return 0, PathError{"Top-level tags can't be deleted", nil}
+		}
+		if _, ok := indexes[m.parent]; !ok {
+			parents = append(parents, m.parent)
+		}
+		indexes[m.parent] = append(indexes[m.parent], m.index)
+	}
+	for _, parent := range parents {
+		parent.set(removeItems(parent.tag, indexes[parent]))
+	}
+	return len(matches), nil
+}
+
+// SetPath replaces the tags selected by the NBT path expression path with value. See Path.Set.
+func SetPath(tags []NamedTag, path string, value Tag) (int, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return 0, err
+	}
+	return p.Set(tags, value)
+}
+
+// DeletePath removes the tags selected by the NBT path expression path. See Path.Delete.
+func DeletePath(tags []NamedTag, path string) (int, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return 0, err
+	}
+	return p.Delete(tags)
+}
+
+// Query returns the tags selected by the NBT path expression path
+func Query(tags []NamedTag, path string) ([]NamedTag, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.Find(tags), nil
+}
+
+// apply calls found for each tag the node selects from m
+func (n pathNode) apply(m pathMatch, found func(pathMatch)) {
+	switch n.kind {
+	case pathChild, pathChildren:
+		compound, ok := m.tag.(Compound)
+		if !ok {
+			return
+		}
+		for i, child := range compound {
+			if n.kind == pathChildren || child.Name == n.name {
+				i := i
+				found(pathMatch{joinPath(m.path, child.Name), child.Value, func(t Tag) { compound[i].Value = t }, &m, i})
+			}
+		}
+	case pathMatchCompound:
+		if tagContains(m.tag, n.filter) {
+			found(m)
+		}
+	default:
+		items := tagItems(m.tag)
+		if n.kind == pathIndex {
+			i := n.index
+			if i < 0 {
+				i += len(items)
+			}
+			if i >= 0 && i < len(items) {
+				found(pathMatch{fmt.Sprintf("%s[%d]", m.path, i), items[i], itemSetter(m.tag, i), &m, i})
+			}
+			return
+		}
+		for i, item := range items {
+			if n.kind == pathItems || tagContains(item, n.filter) {
+				found(pathMatch{fmt.Sprintf("%s[%d]", m.path, i), item, itemSetter(m.tag, i), &m, i})
+			}
+		}
+	}
+}
+
+// itemSetter returns a function which replaces item i of a list or array. Array items must be set to the array's
+// item type.
+func itemSetter(tag Tag, i int) func(Tag) {
+	return func(t Tag) {
+		switch tag := tag.(type) {
+		case List:
+			tag.Items[i] = t
+		case ByteArray:
+			tag[i] = int8(t.(Byte))
+		case IntArray:
+			tag[i] = int32(t.(Int))
+		case LongArray:
+			tag[i] = int64(t.(Long))
+		}
+	}
+}
+
+// removeItems returns a compound, list or array without the children or items at the ascending indexes
+func removeItems(tag Tag, indexes []int) Tag {
+	keep := func(i int) bool {
+		for len(indexes) > 0 && indexes[0] < i {
+			indexes = indexes[1:]
+		}
+		return len(indexes) == 0 || indexes[0] != i
+	}
+	switch tag := tag.(type) {
+	case Compound:
+		kept := Compound{}
+		for i, child := range tag {
+			if keep(i) {
+				kept = append(kept, child)
+			}
+		}
+		return kept
+	case List:
+		kept := List{TagListType: tag.TagListType}
+		for i, item := range tag.Items {
+			if keep(i) {
+				kept.Items = append(kept.Items, item)
+			}
+		}
+		return kept
+	case ByteArray:
+		kept := ByteArray{}
+		for i, v := range tag {
+			if keep(i) {
+				kept = append(kept, v)
+			}
+		}
+		return kept
+	case IntArray:
+		kept := IntArray{}
+		for i, v := range tag {
+			if keep(i) {
+				kept = append(kept, v)
+			}
+		}
+		return kept
+	case LongArray:
+		kept := LongArray{}
+		for i, v := range tag {
+			if keep(i) {
+				kept = append(kept, v)
+			}
+		}
+		return kept
+	}
+	return tag
+}
+
+// convertNumber converts a number tag to another number tag type if its value fits. Other tags are returned unchanged.
+func convertNumber(value Tag, tagType byte) (Tag, bool) {
+	if value.TagType() == tagType {
+		return value, true
+	}
+	if n, _, ok := tagInteger(value); ok {
+		switch tagType {
+		case 1:
+			return Byte(n), n >= math.MinInt8 && n <= math.MaxInt8
+		case 2:
+			return Short(n), n >= math.MinInt16 && n <= math.MaxInt16
+		case 3:
+			return Int(n), n >= math.MinInt32 && n <= math.MaxInt32
+		case 4:
+			return Long(n), true
+		case 5:
+			return Float(n), true
+		case 6:
+			return Double(n), true
+		}
+	}
+	switch value.(type) {
+	case Float, Double:
+		switch tagType {
+		case 5:
+			return Float(tagFloat(value)), true
+		case 6:
+			return Double(tagFloat(value)), true
+		}
+	}
+	return value, true
+}
+
+// joinPath appends a child name to a path, quoting it if needed
+func joinPath(path, name string) string {
+	quote := name == "" || name == "*"
+	for i := 0; i < len(name); i++ {
+		if !isPathNameChar(name[i]) {
+			quote = true
+		}
+	}
+	if quote {
+		name = snbtQuote(name)
+	}
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// tagItems returns the items of a list or array, or nil for other tags
+func tagItems(tag Tag) []Tag {
+	var items []Tag
+	switch tag := tag.(type) {
+	case List:
+		return tag.Items
+	case ByteArray:
+		for _, v := range tag {
+			items = append(items, Byte(v))
+		}
+	case IntArray:
+		for _, v := range tag {
+			items = append(items, Int(v))
+		}
+	case LongArray:
+		for _, v := range tag {
+			items = append(items, Long(v))
+		}
+	}
+	return items
+}
+
+// tagContains reports whether tag has everything in filter
+func tagContains(tag, filter Tag) bool {
+	if n, _, ok := tagInteger(filter); ok {
+		m, _, ok := tagInteger(tag)
+		return ok && m == n
+	}
+	switch filter := filter.(type) {
+	case Float, Double:
+		return tagFloat(tag) == tagFloat(filter) && (tag.TagType() == 5 || tag.TagType() == 6)
+	case Compound:
+		compound, ok := tag.(Compound)
+		if !ok {
+			return false
+		}
+		for _, child := range filter {
+			value := compound.Get(child.Name)
+			if value == nil || !tagContains(value, child.Value) {
+				return false
+			}
+		}
+		return true
+	case List, ByteArray, IntArray, LongArray:
+		if tag.TagType() != filter.TagType() {
+			return false
+		}
+		items, filterItems := tagItems(tag), tagItems(filter)
+		if len(filterItems) == 0 {
+			return len(items) == 0
+		}
+		for _, filterItem := range filterItems {
+			matched := false
+			for _, item := range items {
+				if tagContains(item, filterItem) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return false
+			}
+		}
+		return true
+	}
+	return tag == filter
+}
+
+// tagFloat returns the value of a Float or Double
+func tagFloat(tag Tag) float64 {
+	switch tag := tag.(type) {
+	case Float:
+		return float64(tag)
+	case Double:
+		return float64(tag)
+	}
+	return 0
+}
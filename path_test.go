@@ -0,0 +1,185 @@
+package nbt2json
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+// pathTestSnbt is a cut-down Java Edition level.dat
+const pathTestSnbt = `{Data:{LevelName:"Test",Player:{OnGround:1b,Pos:[1.5d,64.0d,-3.5d],Inventory:[
+	{Slot:0b,id:"minecraft:stone",Count:64b},
+	{Slot:3b,id:"minecraft:diamond",Count:2b,tag:{Damage:0}},
+	{Slot:5b,id:"minecraft:diamond",Count:1b}
+],"odd.name":[I;1,2,3]}}}`
+
+// TestPath checks paths find the expected tags and name them with concrete paths
+func TestPath(t *testing.T) {
+	tags, err := Snbt2Tags([]byte(pathTestSnbt))
+	if err != nil {
+		t.Fatal("Error parsing test SNBT:", err.Error())
+	}
+	paths := []struct {
+		path     string
+		expected []string
+		snbt     []string
+	}{
+		{`Data.LevelName`, []string{`Data.LevelName`}, []string{`"Test"`}},
+		{`Data.Player.Inventory[1].id`, []string{`Data.Player.Inventory[1].id`}, []string{`"minecraft:diamond"`}},
+		{`Data.Player.Inventory[-1].Count`, []string{`Data.Player.Inventory[2].Count`}, []string{`1b`}},
+		{`Data.Player.Inventory[].Slot`, []string{`Data.Player.Inventory[0].Slot`, `Data.Player.Inventory[1].Slot`, `Data.Player.Inventory[2].Slot`}, []string{`0b`, `3b`, `5b`}},
+		{`Data.Player.Inventory[{id:"minecraft:diamond"}].Slot`, []string{`Data.Player.Inventory[1].Slot`, `Data.Player.Inventory[2].Slot`}, []string{`3b`, `5b`}},
+		{`Data.Player.Inventory[{Slot:5}].Count`, []string{`Data.Player.Inventory[2].Count`}, []string{`1b`}},
+		{`Data.Player.Inventory[{tag:{}}].Slot`, []string{`Data.Player.Inventory[1].Slot`}, []string{`3b`}},
+		{`Data.Player{OnGround:1b}.Pos[1]`, []string{`Data.Player.Pos[1]`}, []string{`64.0d`}},
+		{`Data.Player{OnGround:0b}.Pos[1]`, nil, nil},
+		{`Data.Player{Pos:[64.0d]}.Pos[*]`, []string{`Data.Player.Pos[0]`, `Data.Player.Pos[1]`, `Data.Player.Pos[2]`}, []string{`1.5d`, `64.0d`, `-3.5d`}},
+		{`Data.Player."odd.name"[2]`, []string{`Data.Player."odd.name"[2]`}, []string{`3`}},
+		{`Data.*`, []string{`Data.LevelName`, `Data.Player`}, nil},
+		{`{Data:{LevelName:"Test"}}.Data.LevelName`, []string{`Data.LevelName`}, []string{`"Test"`}},
+		{`Data.Missing`, nil, nil},
+		{`Data.LevelName[0]`, nil, nil},
+		{`Data.Player.Inventory[3]`, nil, nil},
+	}
+	for _, test := range paths {
+		found, err := Query(tags, test.path)
+		if err != nil {
+			t.Errorf("Error querying %s: %s", test.path, err.Error())
+			continue
+		}
+		if len(found) != len(test.expected) {
+			t.Errorf("Querying %s expected %d tags, got %d", test.path, len(test.expected), len(found))
+			continue
+		}
+		for i, tag := range found {
+			if tag.Name != test.expected[i] {
+				t.Errorf("Querying %s expected tag %d at %s, got %s", test.path, i, test.expected[i], tag.Name)
+			}
+			if test.snbt != nil && Snbt(tag.Value) != test.snbt[i] {
+				t.Errorf("Querying %s expected tag %d to be %s, got %s", test.path, i, test.snbt[i], Snbt(tag.Value))
+			}
+			// Concrete paths find the same tag
+			again, err := Query(tags, tag.Name)
+			if err != nil || len(again) != 1 || Snbt(again[0].Value) != Snbt(tag.Value) {
+				t.Errorf("Querying concrete path %s didn't find the same tag", tag.Name)
+			}
+		}
+	}
+
+	if found, err := Query(tags, ""); err != nil || len(found) != 1 {
+		t.Errorf("Empty path expected the top-level tag, got %v %v", found, err)
+	}
+	for _, bad := range []string{`Data.`, `Data[x]`, `Data[1`, `Data..Player`, `Data.{a:1b}`, `Data[{a:}]`, `[0]`, `"Data`} {
+		if _, err := ParsePath(bad); err == nil {
+			t.Errorf("Parsing path %s failed to throw error", bad)
+		}
+	}
+}
+
+// TestPathEdit checks setting and deleting tags by path
+func TestPathEdit(t *testing.T) {
+	edits := []struct {
+		path     string
+		value    string
+		count    int
+		expected string
+	}{
+		{`Data.LevelName`, `"Edited"`, 1, `{Data:{LevelName:"Edited",Player:{Pos:[1.5d,64.0d],Inventory:[{Slot:0b,Count:64b},{Slot:3b,Count:2b}],Ids:[I;1,2,3]}}}`},
+		{`Data.Player.Inventory[].Count`, `1`, 2, `{Data:{LevelName:"Test",Player:{Pos:[1.5d,64.0d],Inventory:[{Slot:0b,Count:1b},{Slot:3b,Count:1b}],Ids:[I;1,2,3]}}}`},
+		{`Data.Player.Pos[0]`, `10`, 1, `{Data:{LevelName:"Test",Player:{Pos:[10.0d,64.0d],Inventory:[{Slot:0b,Count:64b},{Slot:3b,Count:2b}],Ids:[I;1,2,3]}}}`},
+		{`Data.Player.Ids[-1]`, `7b`, 1, `{Data:{LevelName:"Test",Player:{Pos:[1.5d,64.0d],Inventory:[{Slot:0b,Count:64b},{Slot:3b,Count:2b}],Ids:[I;1,2,7]}}}`},
+		{`Data.Player.Inventory[{Slot:3b}]`, `{Slot:4b}`, 1, `{Data:{LevelName:"Test",Player:{Pos:[1.5d,64.0d],Inventory:[{Slot:0b,Count:64b},{Slot:4b}],Ids:[I;1,2,3]}}}`},
+		{`Data.Missing`, `1b`, 0, `{Data:{LevelName:"Test",Player:{Pos:[1.5d,64.0d],Inventory:[{Slot:0b,Count:64b},{Slot:3b,Count:2b}],Ids:[I;1,2,3]}}}`},
+	}
+	const original = `{Data:{LevelName:"Test",Player:{Pos:[1.5d,64.0d],Inventory:[{Slot:0b,Count:64b},{Slot:3b,Count:2b}],Ids:[I;1,2,3]}}}`
+	for _, test := range edits {
+		tags, _ := Snbt2Tags([]byte(original))
+		value, err := Snbt2Tags([]byte(test.value))
+		if err != nil {
+			t.Fatal("Error parsing value:", err.Error())
+		}
+		count, err := SetPath(tags, test.path, value[0].Value)
+		if err != nil {
+			t.Errorf("Error setting %s: %s", test.path, err.Error())
+			continue
+		}
+		if count != test.count {
+			t.Errorf("Setting %s expected %d tags set, got %d", test.path, test.count, count)
+		}
+		if snbt := Snbt(tags[0].Value); snbt != test.expected {
+			t.Errorf("Setting %s expected\n%s\n, got\n%s", test.path, test.expected, snbt)
+		}
+	}
+	for _, bad := range []struct{ path, value string }{
+		{`Data.LevelName`, `1b`},
+		{`Data.Player.Inventory[0].Count`, `300`},
+		{`Data.Player.Pos[0]`, `"x"`},
+		{`Data.Player.Ids[0]`, `1.5d`},
+	} {
+		tags, _ := Snbt2Tags([]byte(original))
+		value, _ := Snbt2Tags([]byte(bad.value))
+		if _, err := SetPath(tags, bad.path, value[0].Value); err == nil {
+			t.Errorf("Setting %s to %s failed to throw error", bad.path, bad.value)
+		}
+		if snbt := Snbt(tags[0].Value); snbt != original {
+			t.Errorf("Failed set of %s changed the tree to %s", bad.path, snbt)
+		}
+	}
+
+	deletes := []struct {
+		path     string
+		count    int
+		expected string
+	}{
+		{`Data.LevelName`, 1, `{Data:{Player:{Pos:[1.5d,64.0d],Inventory:[{Slot:0b,Count:64b},{Slot:3b,Count:2b}],Ids:[I;1,2,3]}}}`},
+		{`Data.Player.Inventory[{Slot:0b}]`, 1, `{Data:{LevelName:"Test",Player:{Pos:[1.5d,64.0d],Inventory:[{Slot:3b,Count:2b}],Ids:[I;1,2,3]}}}`},
+		{`Data.Player.Inventory[].Count`, 2, `{Data:{LevelName:"Test",Player:{Pos:[1.5d,64.0d],Inventory:[{Slot:0b},{Slot:3b}],Ids:[I;1,2,3]}}}`},
+		{`Data.Player.Inventory[]`, 2, `{Data:{LevelName:"Test",Player:{Pos:[1.5d,64.0d],Inventory:[],Ids:[I;1,2,3]}}}`},
+		{`Data.Player.Ids[1]`, 1, `{Data:{LevelName:"Test",Player:{Pos:[1.5d,64.0d],Inventory:[{Slot:0b,Count:64b},{Slot:3b,Count:2b}],Ids:[I;1,3]}}}`},
+		{`Data.Player.*`, 3, `{Data:{LevelName:"Test",Player:{}}}`},
+	}
+	for _, test := range deletes {
+		tags, _ := Snbt2Tags([]byte(original))
+		count, err := DeletePath(tags, test.path)
+		if err != nil {
+			t.Errorf("Error deleting %s: %s", test.path, err.Error())
+			continue
+		}
+		if count != test.count {
+			t.Errorf("Deleting %s expected %d tags deleted, got %d", test.path, test.count, count)
+		}
+		if snbt := Snbt(tags[0].Value); snbt != test.expected {
+			t.Errorf("Deleting %s expected\n%s\n, got\n%s", test.path, test.expected, snbt)
+		}
+	}
+	tags, _ := Snbt2Tags([]byte(original))
+	if _, err := DeletePath(tags, ``); err == nil {
+		t.Error("Deleting top-level tag failed to throw error")
+	}
+}
+
+// TestEditNBT checks edits keep a level.dat header with the new length
+func TestEditNBT(t *testing.T) {
+	c := NewConverter()
+	tags, _ := Snbt2Tags([]byte(`{LevelName:"Test"}`))
+	var body, nbtData bytes.Buffer
+	c.WriteNBT(&body, tags)
+	writeHeader(&nbtData, &NbtHeader{StorageVersion: 9}, body.Len())
+	body.WriteTo(&nbtData)
+
+	edited, err := c.EditNBT(nbtData.Bytes(), func(tags []NamedTag) ([]NamedTag, error) {
+		_, err := SetPath(tags, "LevelName", String("A longer name"))
+		return tags, err
+	})
+	if err != nil {
+		t.Fatal("Error editing:", err.Error())
+	}
+	if !hasHeader(edited) || int(binary.LittleEndian.Uint32(edited[4:])) != len(edited)-headerSize || binary.LittleEndian.Uint32(edited) != 9 {
+		t.Errorf("Edited NBT doesn't have the right header:\n%s", hex.Dump(edited))
+	}
+	found, err := c.ReadNBT(bytes.NewReader(edited))
+	if err != nil || Snbt(found[0].Value) != `{LevelName:"A longer name"}` {
+		t.Errorf("Reading edited NBT expected the new name, got %v %v", found, err)
+	}
+}
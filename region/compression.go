@@ -0,0 +1,84 @@
+package region
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/midnightfreddie/nbt2json"
+)
+
+// Compression is the compression type of a chunk, stored in the byte before its data
+type Compression byte
+
+// Chunk compression types. Custom (127) compression is not supported.
+const (
+	Gzip Compression = 1
+	Zlib Compression = 2
+	None Compression = 3
+	LZ4  Compression = 4
+)
+
+// externalFlag is set on the compression type of chunks too large for the region file, which are stored in .mcc files
+const externalFlag = 0x80
+
+func (c Compression) String() string {
+	switch c {
+	case Gzip:
+		return "gzip"
+	case Zlib:
+		return "zlib"
+	case None:
+		return "none"
+	case LZ4:
+		return "lz4"
+	}
+	return fmt.Sprintf("unknown(%d)", byte(c))
+}
+
+// ParseCompression returns the compression with the given name: gzip, zlib, none or lz4
+func ParseCompression(s string) (Compression, error) {
+	for _, c := range []Compression{Gzip, Zlib, None, LZ4} {
+		if strings.EqualFold(s, c.String()) {
+			return c, nil
+		}
+	}
+	return 0, RegionError{fmt.Sprintf("Compression %q not recognized, use gzip, zlib, none or lz4", s), nil}
+}
+
+// nbtCompression returns the nbt2json compression of a chunk compression type
+func (c Compression) nbtCompression() (nbt2json.Compression, error) {
+	switch c {
+	case Gzip:
+		return nbt2json.GzipCompression, nil
+	case Zlib:
+		return nbt2json.ZlibCompression, nil
+	case None:
+		return nbt2json.NoCompression, nil
+	case LZ4:
+		return nbt2json.LZ4Compression, nil
+	}
+	return 0, fmt.Errorf("compression type %d not supported", byte(c))
+}
+
+func compress(c Compression, data []byte) ([]byte, error) {
+	compression, err := c.nbtCompression()
+	if err != nil {
+		return nil, err
+	}
+	return nbt2json.Compress(data, compression)
+}
+
+func decompress(c Compression, data []byte) ([]byte, error) {
+	compression, err := c.nbtCompression()
+	if err != nil {
+		return nil, err
+	}
+	if compression == nbt2json.NoCompression {
+		return data, nil
+	}
+	if detected := nbt2json.DetectCompression(data); detected != compression {
+		return nil, fmt.Errorf("chunk data is not %s compressed", c)
+	}
+	data, _, err = nbt2json.Decompress(data)
+	return data, err
+}
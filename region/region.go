@@ -0,0 +1,350 @@
+// Package region reads and writes the region files of Minecraft Java Edition worlds: .mca (Anvil) files and the
+// older .mcr files. A region file holds up to 32x32 chunks. It starts with a 4 KiB table of chunk locations and a
+// 4 KiB table of chunk timestamps, followed by the compressed NBT of each chunk in 4 KiB sectors.
+//
+// Chunk data is uncompressed Java Edition (big endian) NBT; use nbt2json's UseJavaEncoding to convert it.
+package region
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// SectorSize is the size of a region file sector in bytes
+const SectorSize = 4096
+
+const (
+	chunksPerRegion = 32 * 32
+	headerSectors   = 2
+	// The chunk location's sector count is one byte and its offset three bytes
+	maxChunkSectors = 255
+	maxSector       = 1<<24 - 1
+	// Each chunk starts with a four byte length and a compression type byte
+	chunkHeaderSize = 5
+)
+
+// ErrChunkNotFound is returned when reading a chunk that isn't in the region
+var ErrChunkNotFound = errors.New("chunk not found in region")
+
+// RegionError is when a region file or chunk can't be read or written. Pass it message string and downstream error
+type RegionError struct {
+	s string
+	e error
+}
+
+func (e RegionError) Error() string {
+	var s string
+	if e.e != nil {
+		s = fmt.Sprintf(": %s", e.e.Error())
+	}
+	return fmt.Sprintf("Error in region file: %s%s", e.s, s)
+}
+
+// ChunkInfo describes a chunk present in a region file
+type ChunkInfo struct {
+	// X and Z are the chunk's position in the region, 0 to 31
+	X, Z int
+	// Sector and Sectors are the chunk's location in the file in 4 KiB sectors
+	Sector, Sectors int
+	// Length is the size of the compressed chunk data in bytes
+	Length int
+	// Compression is how the chunk data is compressed
+	Compression Compression
+	// External is true when the chunk is too large for the region and its data is in a separate .mcc file
+	External bool
+	// Timestamp is when the chunk was last written
+	Timestamp time.Time
+}
+
+// Region is an open region file. A Region is not safe for concurrent use.
+type Region struct {
+	f          io.ReaderAt
+	closer     io.Closer
+	path       string
+	locations  [chunksPerRegion]uint32
+	timestamps [chunksPerRegion]uint32
+}
+
+var regionFileRegexp = regexp.MustCompile(`^r\.(-?\d+)\.(-?\d+)\.mc[ar]$`)
+
+// New reads the region header from f. An empty f is an empty region. WriteChunk needs f to also be an io.WriterAt.
+func New(f io.ReaderAt) (*Region, error) {
+	r := &Region{f: f}
+	header := make([]byte, headerSectors*SectorSize)
+	n, err := f.ReadAt(header, 0)
+	if n == 0 && err == io.EOF {
+		return r, nil
+	}
+	if err != nil {
+		return nil, RegionError{"Reading header", err}
+	}
+	for i := range r.locations {
+		r.locations[i] = binary.BigEndian.Uint32(header[i*4:])
+		r.timestamps[i] = binary.BigEndian.Uint32(header[SectorSize+i*4:])
+	}
+	return r, nil
+}
+
+// Open opens a region file for reading
+func Open(name string) (*Region, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return newFile(f, name)
+}
+
+// OpenFile opens a region file for reading and writing, creating it if it doesn't exist
+func OpenFile(name string) (*Region, error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return newFile(f, name)
+}
+
+func newFile(f *os.File, name string) (*Region, error) {
+	r, err := New(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.closer = f
+	r.path = name
+	return r, nil
+}
+
+// Close closes the region file if it was opened by Open or OpenFile
+func (r *Region) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// index returns the header index of a chunk. x and z can be world chunk coordinates or positions in the region.
+func index(x, z int) int {
+	return (x & 31) + (z&31)*32
+}
+
+// Chunks lists the chunks present in the region
+func (r *Region) Chunks() ([]ChunkInfo, error) {
+	var chunks []ChunkInfo
+	for i, location := range r.locations {
+		if location == 0 {
+			continue
+		}
+		info, err := r.info(i)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, info)
+	}
+	return chunks, nil
+}
+
+func (r *Region) info(i int) (ChunkInfo, error) {
+	info := ChunkInfo{
+		X:         i % 32,
+		Z:         i / 32,
+		Sector:    int(r.locations[i] >> 8),
+		Sectors:   int(r.locations[i] & 0xff),
+		Timestamp: time.Unix(int64(r.timestamps[i]), 0),
+	}
+	var header [chunkHeaderSize]byte
+	_, err := r.f.ReadAt(header[:], int64(info.Sector)*SectorSize)
+	if err != nil {
+		return info, RegionError{fmt.Sprintf("Reading header of chunk %d,%d at sector %d", info.X, info.Z, info.Sector), err}
+	}
+	info.Length = int(binary.BigEndian.Uint32(header[:])) - 1
+	info.Compression = Compression(header[4] &^ externalFlag)
+	info.External = header[4]&externalFlag != 0
+	if info.Length < 0 || info.Length > info.Sectors*SectorSize-chunkHeaderSize {
+		return info, RegionError{fmt.Sprintf("Chunk %d,%d length %d does not fit in %d sectors", info.X, info.Z, info.Length, info.Sectors), nil}
+	}
+	return info, nil
+}
+
+// ReadChunk returns the uncompressed NBT of the chunk at x, z, or ErrChunkNotFound if the chunk isn't present
+func (r *Region) ReadChunk(x, z int) ([]byte, error) {
+	i := index(x, z)
+	if r.locations[i] == 0 {
+		return nil, ErrChunkNotFound
+	}
+	info, err := r.info(i)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	if info.External {
+		name, err := r.externalName(i)
+		if err != nil {
+			return nil, err
+		}
+		data, err = ioutil.ReadFile(name)
+		if err != nil {
+			return nil, RegionError{fmt.Sprintf("Reading external chunk %d,%d", info.X, info.Z), err}
+		}
+	} else {
+		data = make([]byte, info.Length)
+		_, err = r.f.ReadAt(data, int64(info.Sector)*SectorSize+chunkHeaderSize)
+		if err != nil {
+			return nil, RegionError{fmt.Sprintf("Reading chunk %d,%d", info.X, info.Z), err}
+		}
+	}
+	nbtData, err := decompress(info.Compression, data)
+	if err != nil {
+		return nil, RegionError{fmt.Sprintf("Decompressing %s chunk %d,%d", info.Compression, info.X, info.Z), err}
+	}
+	return nbtData, nil
+}
+
+// WriteChunk compresses and writes the uncompressed NBT of the chunk at x, z and sets its timestamp to now. The chunk
+// is written to free sectors before the header is updated, so the old chunk data is intact if writing fails.
+func (r *Region) WriteChunk(x, z int, nbtData []byte, compression Compression) error {
+	w, ok := r.f.(io.WriterAt)
+	if !ok {
+		return RegionError{"Region is not writable", nil}
+	}
+	i := index(x, z)
+	oldExternal := false
+	if r.locations[i] != 0 {
+		if info, err := r.info(i); err == nil {
+			oldExternal = info.External
+		}
+	}
+	data, err := compress(compression, nbtData)
+	if err != nil {
+		return RegionError{fmt.Sprintf("Compressing %s chunk %d,%d", compression, i%32, i/32), err}
+	}
+
+	external := false
+	sectors := (chunkHeaderSize + len(data) + SectorSize - 1) / SectorSize
+	if sectors > maxChunkSectors {
+		// Too large for the region, so like Minecraft write it to a .mcc file and leave only the header in the region
+		name, err := r.externalName(i)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(name, data, 0666)
+		if err != nil {
+			return RegionError{fmt.Sprintf("Writing external chunk %d,%d", i%32, i/32), err}
+		}
+		external = true
+		data = nil
+		sectors = 1
+	}
+
+	sector := r.allocate(sectors)
+	if sector+sectors > maxSector {
+		return RegionError{"Region file is full", nil}
+	}
+	buf := make([]byte, sectors*SectorSize)
+	binary.BigEndian.PutUint32(buf, uint32(len(data)+1))
+	buf[4] = byte(compression)
+	if external {
+		buf[4] |= externalFlag
+	}
+	copy(buf[chunkHeaderSize:], data)
+	_, err = w.WriteAt(buf, int64(sector)*SectorSize)
+	if err != nil {
+		return RegionError{fmt.Sprintf("Writing chunk %d,%d", i%32, i/32), err}
+	}
+
+	r.locations[i] = uint32(sector)<<8 | uint32(sectors)
+	r.timestamps[i] = uint32(time.Now().Unix())
+	err = r.writeHeader(w, i)
+	if err != nil {
+		return err
+	}
+	if oldExternal && !external {
+		if name, err := r.externalName(i); err == nil {
+			os.Remove(name)
+		}
+	}
+	return nil
+}
+
+// DeleteChunk removes the chunk at x, z from the region. Its sectors are reused by later writes.
+func (r *Region) DeleteChunk(x, z int) error {
+	w, ok := r.f.(io.WriterAt)
+	if !ok {
+		return RegionError{"Region is not writable", nil}
+	}
+	i := index(x, z)
+	if r.locations[i] == 0 {
+		return ErrChunkNotFound
+	}
+	r.locations[i] = 0
+	r.timestamps[i] = 0
+	return r.writeHeader(w, i)
+}
+
+func (r *Region) writeHeader(w io.WriterAt, i int) error {
+	var entry [4]byte
+	binary.BigEndian.PutUint32(entry[:], r.locations[i])
+	_, err := w.WriteAt(entry[:], int64(i*4))
+	if err != nil {
+		return RegionError{"Writing chunk location", err}
+	}
+	binary.BigEndian.PutUint32(entry[:], r.timestamps[i])
+	_, err = w.WriteAt(entry[:], int64(SectorSize+i*4))
+	if err != nil {
+		return RegionError{"Writing chunk timestamp", err}
+	}
+	return nil
+}
+
+// allocate returns the first run of sectors free in the current header, or the end of the file. Sectors of a chunk
+// being replaced are still in use, so its old data isn't overwritten.
+func (r *Region) allocate(sectors int) int {
+	end := headerSectors
+	var used []bool
+	for _, location := range r.locations {
+		start, count := int(location>>8), int(location&0xff)
+		if location == 0 || start < headerSectors {
+			continue
+		}
+		if start+count > end {
+			end = start + count
+		}
+		for len(used) < start+count {
+			used = append(used, false)
+		}
+		for s := start; s < start+count; s++ {
+			used[s] = true
+		}
+	}
+	free := 0
+	for s := headerSectors; s < end; s++ {
+		if used[s] {
+			free = 0
+			continue
+		}
+		free++
+		if free == sectors {
+			return s - sectors + 1
+		}
+	}
+	return end - free
+}
+
+// externalName returns the .mcc file name of an external chunk, which uses the chunk's world coordinates. This needs
+// the region's coordinates from its r.x.z.mca file name.
+func (r *Region) externalName(i int) (string, error) {
+	match := regionFileRegexp.FindStringSubmatch(filepath.Base(r.path))
+	if match == nil {
+		return "", RegionError{fmt.Sprintf("Chunk %d,%d is too large for the region, and external chunks need a region opened from an r.x.z.mca file", i%32, i/32), nil}
+	}
+	regionX, _ := strconv.Atoi(match[1])
+	regionZ, _ := strconv.Atoi(match[2])
+	return filepath.Join(filepath.Dir(r.path), fmt.Sprintf("c.%d.%d.mcc", regionX*32+i%32, regionZ*32+i/32)), nil
+}
@@ -0,0 +1,153 @@
+package region
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestCompression checks each compression type round trips, including LZ4 data spanning several blocks
+func TestCompression(t *testing.T) {
+	random := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(random)
+	inputs := [][]byte{
+		{},
+		[]byte("hello"),
+		bytes.Repeat([]byte("minecraft:stone "), 20000),
+		random,
+	}
+	for _, compression := range []Compression{Gzip, Zlib, None, LZ4} {
+		for _, input := range inputs {
+			data, err := compress(compression, input)
+			if err != nil {
+				t.Errorf("Error compressing %d bytes with %s: %s", len(input), compression, err.Error())
+				continue
+			}
+			output, err := decompress(compression, data)
+			if err != nil {
+				t.Errorf("Error decompressing %d bytes with %s: %s", len(input), compression, err.Error())
+			} else if !bytes.Equal(input, output) {
+				t.Errorf("%s round trip of %d bytes returned %d different bytes", compression, len(input), len(output))
+			}
+		}
+	}
+}
+
+// TestRegion writes chunks to a new region file and checks they read back with correct locations and timestamps
+func TestRegion(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "r.-1.2.mca")
+
+	r, err := OpenFile(name)
+	if err != nil {
+		t.Fatal("Error creating region:", err.Error())
+	}
+	start := time.Now().Add(-time.Second)
+	small := []byte("small chunk")
+	random := make([]byte, 3*SectorSize)
+	rand.New(rand.NewSource(1)).Read(random)
+	chunks := []struct {
+		x, z        int
+		data        []byte
+		compression Compression
+	}{
+		{0, 0, small, Zlib},
+		{31, 31, random, None},
+		// World chunk coordinates -32, 64 are 0, 0 in region -1, 2
+		{-32, 64, small, LZ4},
+		{5, 3, small, Gzip},
+	}
+	for _, chunk := range chunks {
+		if err := r.WriteChunk(chunk.x, chunk.z, chunk.data, chunk.compression); err != nil {
+			t.Fatalf("Error writing chunk %d,%d: %s", chunk.x, chunk.z, err.Error())
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = OpenFile(name)
+	if err != nil {
+		t.Fatal("Error opening region:", err.Error())
+	}
+	defer r.Close()
+	for _, chunk := range chunks {
+		data, err := r.ReadChunk(chunk.x, chunk.z)
+		if err != nil {
+			t.Errorf("Error reading chunk %d,%d: %s", chunk.x, chunk.z, err.Error())
+		} else if !bytes.Equal(data, chunk.data) {
+			t.Errorf("Chunk %d,%d expected %d bytes, got %d different bytes", chunk.x, chunk.z, len(chunk.data), len(data))
+		}
+	}
+	if _, err := r.ReadChunk(2, 2); err != ErrChunkNotFound {
+		t.Errorf("Reading missing chunk expected ErrChunkNotFound, got %v", err)
+	}
+
+	infos, err := r.Chunks()
+	if err != nil {
+		t.Fatal("Error listing chunks:", err.Error())
+	}
+	expected := []ChunkInfo{
+		{X: 0, Z: 0, Compression: LZ4},
+		{X: 5, Z: 3, Compression: Gzip},
+		{X: 31, Z: 31, Compression: None},
+	}
+	if len(infos) != 3 {
+		t.Fatalf("Expected 3 chunks, got %+v", infos)
+	}
+	for n, info := range infos {
+		if info.X != expected[n].X || info.Z != expected[n].Z || info.Compression != expected[n].Compression || info.External {
+			t.Errorf("Chunk info expected %+v, got %+v", expected[n], info)
+		}
+		if info.Timestamp.Before(start) || info.Timestamp.After(time.Now()) {
+			t.Errorf("Chunk %d,%d timestamp %s not when it was written", info.X, info.Z, info.Timestamp)
+		}
+	}
+	// Overwriting chunk 0,0 allocated a new sector and freed sector 2, which chunk 5,3 then used
+	if infos[0].Sector != 2+1+4 || infos[1].Sector != 2 || infos[2].Sector != 3 {
+		t.Errorf("Unexpected sector allocation %+v", infos)
+	}
+
+	// Deleted chunks' sectors are reused
+	if err := r.DeleteChunk(31, 31); err != nil {
+		t.Fatal("Error deleting chunk:", err.Error())
+	}
+	if err := r.WriteChunk(10, 10, random[:2*SectorSize], None); err != nil {
+		t.Fatal("Error writing chunk:", err.Error())
+	}
+	infos, _ = r.Chunks()
+	for _, info := range infos {
+		if info.X == 10 && (info.Sector != 3 || info.Sectors != 3) {
+			t.Errorf("Chunk 10,10 expected sectors 3-5, got %+v", info)
+		}
+	}
+	if fi, err := os.Stat(name); err != nil || fi.Size()%SectorSize != 0 {
+		t.Errorf("Region file size is not a multiple of the sector size")
+	}
+
+	// Chunks too large for the region go in an external .mcc file named with world chunk coordinates
+	huge := make([]byte, 256*SectorSize)
+	rand.New(rand.NewSource(2)).Read(huge)
+	if err := r.WriteChunk(1, 2, huge, None); err != nil {
+		t.Fatal("Error writing external chunk:", err.Error())
+	}
+	external := filepath.Join(dir, "c.-31.66.mcc")
+	if _, err := os.Stat(external); err != nil {
+		t.Errorf("External chunk file not written: %s", err.Error())
+	}
+	data, err := r.ReadChunk(1, 2)
+	if err != nil {
+		t.Errorf("Error reading external chunk: %s", err.Error())
+	} else if !bytes.Equal(data, huge) {
+		t.Error("External chunk did not round trip")
+	}
+	if err := r.WriteChunk(1, 2, small, Zlib); err != nil {
+		t.Fatal("Error replacing external chunk:", err.Error())
+	}
+	if _, err := os.Stat(external); !os.IsNotExist(err) {
+		t.Error("External chunk file not removed after chunk was replaced")
+	}
+}
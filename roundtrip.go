@@ -0,0 +1,59 @@
+package nbt2json
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// RoundTrip converts NBT to JSON and back using the module's default settings and checks the result is the same
+// bytes. Compressed NBT is decompressed first.
+func RoundTrip(b []byte) error {
+	return defaultConverter.RoundTrip(b)
+}
+
+// RoundTrip converts NBT to JSON and back and checks the result is the same bytes. Compressed NBT is decompressed
+// first, since compressing it again needn't give the same bytes. It returns the conversions' errors, or a
+// RoundTripError locating the first byte that changed in the decompressed NBT.
+func (c *Converter) RoundTrip(b []byte) error {
+	b, _, err := Decompress(b)
+	if err != nil {
+		return NbtParseError{s: "Decompressing NBT", e: err}
+	}
+	jsonData, err := c.Nbt2Json(b, "")
+	if err != nil {
+		return err
+	}
+	again, err := c.Json2Nbt(jsonData)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(b, again) {
+		return nil
+	}
+	offset := 0
+	for offset < len(b) && offset < len(again) && b[offset] == again[offset] {
+		offset++
+	}
+	path, tagType := c.tagAt(b, offset)
+	return RoundTripError{Offset: int64(offset), Path: path, TagType: tagType}
+}
+
+// tagAt returns the path and type of the innermost tag containing byte offset of b. Reading b up to the offset fails
+// there, and the error has the location of the tag that was being read.
+func (c *Converter) tagAt(b []byte, offset int) (string, byte) {
+	if offset <= headerSize && c.byteOrder == binary.LittleEndian && !c.varint && hasHeader(b) {
+		// in the level.dat header, or at the start of the tags after it
+		return "", 0
+	}
+	_, err := c.ReadNBT(bytes.NewReader(b[:offset]))
+	var nbtErr NbtParseError
+	if errors.As(err, &nbtErr) && nbtErr.located {
+		return nbtErr.Path, nbtErr.TagType
+	}
+	// offset is the start of a top-level tag, or past the end of b
+	if offset < len(b) {
+		return "", b[offset]
+	}
+	return "", 0
+}
@@ -0,0 +1,523 @@
+package nbt2json
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SNBT is the stringified NBT used by Minecraft Java Edition commands and datapacks, e.g.
+//   {Health:20.0f,Inventory:[{id:"minecraft:stone",Count:1b}]}
+// It has no tag names at the top level, and empty lists have no list type, so those don't survive a round trip.
+
+// Patterns for unquoted SNBT values, the same as Minecraft's parser uses
+var (
+	snbtUnquotedRegexp = regexp.MustCompile(`^[0-9A-Za-z_\-.+]+$`)
+	snbtByteRegexp     = regexp.MustCompile(`(?i)^[-+]?(?:0|[1-9][0-9]*)b$`)
+	snbtShortRegexp    = regexp.MustCompile(`(?i)^[-+]?(?:0|[1-9][0-9]*)s$`)
+	snbtIntRegexp      = regexp.MustCompile(`^[-+]?(?:0|[1-9][0-9]*)$`)
+	snbtLongRegexp     = regexp.MustCompile(`(?i)^[-+]?(?:0|[1-9][0-9]*)l$`)
+	snbtFloatRegexp    = regexp.MustCompile(`(?i)^[-+]?(?:[0-9]+[.]?|[0-9]*[.][0-9]+)(?:e[-+]?[0-9]+)?f$`)
+	snbtDoubleRegexp   = regexp.MustCompile(`(?i)^[-+]?(?:[0-9]+[.]?|[0-9]*[.][0-9]+)(?:e[-+]?[0-9]+)?d$`)
+	snbtDotRegexp      = regexp.MustCompile(`(?i)^[-+]?(?:[0-9]+[.]|[0-9]*[.][0-9]+)(?:e[-+]?[0-9]+)?$`)
+	// Minecraft writes non-finite floats this way even though its parser reads them as strings
+	snbtNonFiniteRegexp = regexp.MustCompile(`^([-+]?(?:NaN|Infinity))([fFdD])$`)
+)
+
+// Nbt2Snbt converts uncompressed NBT byte array to SNBT text using the module's default settings
+func Nbt2Snbt(b []byte) ([]byte, error) {
+	return defaultConverter.Nbt2Snbt(b)
+}
+
+// Snbt2Nbt converts SNBT text to uncompressed NBT byte array using the module's default settings
+func Snbt2Nbt(b []byte) ([]byte, error) {
+	return defaultConverter.Snbt2Nbt(b)
+}
+
+// Nbt2Snbt converts uncompressed NBT byte array to SNBT text, one line per top-level tag
+func (c *Converter) Nbt2Snbt(b []byte) ([]byte, error) {
+	tags, err := c.ReadNBT(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	return Tags2Snbt(tags), nil
+}
+
+// Snbt2Nbt converts SNBT text to uncompressed NBT byte array. Each top-level value becomes an unnamed tag.
+func (c *Converter) Snbt2Nbt(b []byte) ([]byte, error) {
+	tags, err := c.Snbt2Tags(b)
+	if err != nil {
+		return nil, err
+	}
+	var nbtOut bytes.Buffer
+	err = c.WriteNBT(&nbtOut, tags)
+	if err != nil {
+		return nil, err
+	}
+	return nbtOut.Bytes(), nil
+}
+
+// Tags2Snbt converts a tag tree to SNBT text, one line per top-level tag. Top-level names and end tags are left out.
+func Tags2Snbt(tags []NamedTag) []byte {
+	var snbt strings.Builder
+	for _, tag := range tags {
+		if _, ok := tag.Value.(End); ok {
+			continue
+		}
+		snbt.WriteString(Snbt(tag.Value))
+		snbt.WriteString("\n")
+	}
+	return []byte(snbt.String())
+}
+
+// Snbt2Tags parses SNBT text to a tag tree using the module's default settings. The text may have several whitespace-separated values, each of which becomes an unnamed top-level tag.
+func Snbt2Tags(b []byte) ([]NamedTag, error) {
+	return defaultConverter.Snbt2Tags(b)
+}
+
+// Snbt2Tags parses SNBT text to a tag tree. The text may have several whitespace-separated values, each of which
+// becomes an unnamed top-level tag. Compounds and lists can't be nested deeper than the converter's MaxDepth.
+func (c *Converter) Snbt2Tags(b []byte) ([]NamedTag, error) {
+	p := snbtParser{s: string(b), maxDepth: c.limits.MaxDepth}
+	var tags []NamedTag
+	for {
+		p.skipWhitespace()
+		if p.pos >= len(p.s) {
+			break
+		}
+		tag, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, NamedTag{Name: "", Value: tag})
+	}
+	if len(tags) == 0 {
+		return nil, SnbtParseError{"SNBT input has no values", nil}
+	}
+	return tags, nil
+}
+
+// Snbt returns the SNBT text of a tag
+func Snbt(tag Tag) string {
+	var sb strings.Builder
+	writeSnbt(&sb, tag)
+	return sb.String()
+}
+
+// snbtQuote quotes a string like Minecraft does, with single quotes if that avoids escaping double quotes
+func snbtQuote(s string) string {
+	quote := byte('"')
+	if strings.Contains(s, `"`) && !strings.Contains(s, "'") {
+		quote = '\''
+	}
+	var sb strings.Builder
+	sb.WriteByte(quote)
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' || s[i] == quote {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+	sb.WriteByte(quote)
+	return sb.String()
+}
+
+// snbtFloat formats a float so it always reads back as a float, e.g. 20.0 instead of 20
+func snbtFloat(f float64, bitSize int) string {
+	s := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if math.IsInf(f, 1) {
+		return "Infinity"
+	}
+	if math.IsInf(f, -1) {
+		return "-Infinity"
+	}
+	if !strings.ContainsAny(s, ".eN") {
+		s += ".0"
+	}
+	return s
+}
+
+func writeSnbt(sb *strings.Builder, tag Tag) {
+	switch tag := tag.(type) {
+	case Byte:
+		fmt.Fprintf(sb, "%db", tag)
+	case Short:
+		fmt.Fprintf(sb, "%ds", tag)
+	case Int:
+		fmt.Fprintf(sb, "%d", tag)
+	case Long:
+		fmt.Fprintf(sb, "%dL", tag)
+	case Float:
+		sb.WriteString(snbtFloat(float64(tag), 32) + "f")
+	case Double:
+		sb.WriteString(snbtFloat(float64(tag), 64) + "d")
+	case ByteArray:
+		sb.WriteString("[B;")
+		for i, b := range tag {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			fmt.Fprintf(sb, "%db", b)
+		}
+		sb.WriteByte(']')
+	case String:
+		sb.WriteString(snbtQuote(string(tag)))
+	case List:
+		sb.WriteByte('[')
+		for i, item := range tag.Items {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeSnbt(sb, item)
+		}
+		sb.WriteByte(']')
+	case Compound:
+		sb.WriteByte('{')
+		for i, child := range tag {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			if snbtUnquotedRegexp.MatchString(child.Name) {
+				sb.WriteString(child.Name)
+			} else {
+				sb.WriteString(snbtQuote(child.Name))
+			}
+			sb.WriteByte(':')
+			writeSnbt(sb, child.Value)
+		}
+		sb.WriteByte('}')
+	case IntArray:
+		sb.WriteString("[I;")
+		for i, n := range tag {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			fmt.Fprintf(sb, "%d", n)
+		}
+		sb.WriteByte(']')
+	case LongArray:
+		sb.WriteString("[L;")
+		for i, n := range tag {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			fmt.Fprintf(sb, "%dL", n)
+		}
+		sb.WriteByte(']')
+	}
+}
+
+// snbtParser is a recursive descent parser over SNBT text. Compounds and lists can be nested up to maxDepth, or
+// without limit if it is 0.
+type snbtParser struct {
+	s        string
+	pos      int
+	depth    int
+	maxDepth int
+}
+
+func (p *snbtParser) errorf(format string, a ...interface{}) error {
+	return SnbtParseError{fmt.Sprintf("at position %d: %s", p.pos, fmt.Sprintf(format, a...)), nil}
+}
+
+// enter is called before reading a compound or list's contents, and leave after
+func (p *snbtParser) enter() error {
+	p.depth++
+	if p.maxDepth > 0 && p.depth > p.maxDepth {
+		return SnbtParseError{fmt.Sprintf("at position %d", p.pos), LimitError{"MaxDepth", int64(p.depth), int64(p.maxDepth)}}
+	}
+	return nil
+}
+
+func (p *snbtParser) leave() {
+	p.depth--
+}
+
+func (p *snbtParser) skipWhitespace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// expect skips whitespace and then the given character
+func (p *snbtParser) expect(c byte) error {
+	p.skipWhitespace()
+	if p.pos >= len(p.s) || p.s[p.pos] != c {
+		return p.errorf("expected '%c'", c)
+	}
+	p.pos++
+	return nil
+}
+
+// peek skips whitespace and returns the next character, or 0 at the end of input
+func (p *snbtParser) peek() byte {
+	p.skipWhitespace()
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *snbtParser) value() (Tag, error) {
+	switch p.peek() {
+	case '{':
+		return p.compound()
+	case '[':
+		if p.pos+2 < len(p.s) && p.s[p.pos+2] == ';' && strings.IndexByte("BIL", p.s[p.pos+1]) >= 0 {
+			return p.array()
+		}
+		return p.list()
+	case '"', '\'':
+		s, err := p.quotedString()
+		if err != nil {
+			return nil, err
+		}
+		return String(s), nil
+	case 0:
+		return nil, p.errorf("expected a value but reached the end of input")
+	}
+	token := p.unquotedString()
+	if token == "" {
+		return nil, p.errorf("expected a value")
+	}
+	return snbtScalar(token), nil
+}
+
+// snbtScalar converts an unquoted value to a number tag or, like Minecraft does, to a String if it isn't a number in range
+func snbtScalar(token string) Tag {
+	lower := strings.ToLower(token)
+	if lower == "true" {
+		return Byte(1)
+	}
+	if lower == "false" {
+		return Byte(0)
+	}
+	digits := token
+	if len(token) > 1 {
+		digits = token[:len(token)-1]
+	}
+	switch {
+	case snbtByteRegexp.MatchString(token):
+		if i, err := strconv.ParseInt(digits, 10, 8); err == nil {
+			return Byte(i)
+		}
+	case snbtShortRegexp.MatchString(token):
+		if i, err := strconv.ParseInt(digits, 10, 16); err == nil {
+			return Short(i)
+		}
+	case snbtIntRegexp.MatchString(token):
+		if i, err := strconv.ParseInt(token, 10, 32); err == nil {
+			return Int(i)
+		}
+	case snbtLongRegexp.MatchString(token):
+		if i, err := strconv.ParseInt(digits, 10, 64); err == nil {
+			return Long(i)
+		}
+	case snbtFloatRegexp.MatchString(token):
+		if f, err := strconv.ParseFloat(digits, 32); err == nil {
+			return Float(f)
+		}
+	case snbtDoubleRegexp.MatchString(token):
+		if f, err := strconv.ParseFloat(digits, 64); err == nil {
+			return Double(f)
+		}
+	case snbtDotRegexp.MatchString(token):
+		if f, err := strconv.ParseFloat(token, 64); err == nil {
+			return Double(f)
+		}
+	case snbtNonFiniteRegexp.MatchString(token):
+		match := snbtNonFiniteRegexp.FindStringSubmatch(token)
+		f, _ := strconv.ParseFloat(match[1], 64)
+		if strings.EqualFold(match[2], "f") {
+			return Float(f)
+		}
+		return Double(f)
+	}
+	return String(token)
+}
+
+// isUnquotedChar reports whether c can be part of an unquoted key or value
+func isUnquotedChar(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || strings.IndexByte("_-.+", c) >= 0
+}
+
+func (p *snbtParser) unquotedString() string {
+	start := p.pos
+	for p.pos < len(p.s) && isUnquotedChar(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *snbtParser) quotedString() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch c {
+		case '\\':
+			if p.pos >= len(p.s) {
+				return "", p.errorf("unterminated escape")
+			}
+			escaped := p.s[p.pos]
+			if escaped != '\\' && escaped != '"' && escaped != '\'' {
+				return "", p.errorf("invalid escape '\\%c'", escaped)
+			}
+			sb.WriteByte(escaped)
+			p.pos++
+		case quote:
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// key reads a compound key, which may be quoted or unquoted
+func (p *snbtParser) key() (string, error) {
+	switch p.peek() {
+	case '"', '\'':
+		return p.quotedString()
+	}
+	key := p.unquotedString()
+	if key == "" {
+		return "", p.errorf("expected a compound key")
+	}
+	return key, nil
+}
+
+func (p *snbtParser) compound() (Tag, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	p.pos++
+	compound := Compound{}
+	if p.peek() == '}' {
+		p.pos++
+		return compound, nil
+	}
+	for {
+		name, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		err = p.expect(':')
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		compound = append(compound, NamedTag{Name: name, Value: value})
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return compound, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in compound")
+		}
+	}
+}
+
+// items reads comma-separated values up to the closing bracket
+func (p *snbtParser) items() ([]Tag, error) {
+	var items []Tag
+	if p.peek() == ']' {
+		p.pos++
+		return items, nil
+	}
+	for {
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return items, nil
+		default:
+			return nil, p.errorf("expected ',' or ']' in list")
+		}
+	}
+}
+
+func (p *snbtParser) list() (Tag, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	start := p.pos
+	p.pos++
+	items, err := p.items()
+	if err != nil {
+		return nil, err
+	}
+	var tagList List
+	for i, item := range items {
+		if i == 0 {
+			tagList.TagListType = item.TagType()
+		} else if item.TagType() != tagList.TagListType {
+			p.pos = start
+			return nil, p.errorf("list item %d is tag type %d but the list is type %d", i, item.TagType(), tagList.TagListType)
+		}
+	}
+	tagList.Items = items
+	return tagList, nil
+}
+
+// array reads a [B;...], [I;...] or [L;...] typed array
+func (p *snbtParser) array() (Tag, error) {
+	start := p.pos
+	arrayType := p.s[p.pos+1]
+	p.pos += 3
+	items, err := p.items()
+	if err != nil {
+		return nil, err
+	}
+	var byteArray ByteArray
+	var intArray IntArray
+	var longArray LongArray
+	for i, item := range items {
+		n, _, ok := tagInteger(item)
+		if !ok {
+			p.pos = start
+			return nil, p.errorf("array item %d is tag type %d, not a number", i, item.TagType())
+		}
+		switch arrayType {
+		case 'B':
+			if n < math.MinInt8 || n > math.MaxInt8 {
+				p.pos = start
+				return nil, p.errorf("array item %d is out of range for a byte array", i)
+			}
+			byteArray = append(byteArray, int8(n))
+		case 'I':
+			if n < math.MinInt32 || n > math.MaxInt32 {
+				p.pos = start
+				return nil, p.errorf("array item %d is out of range for an int array", i)
+			}
+			intArray = append(intArray, int32(n))
+		case 'L':
+			longArray = append(longArray, n)
+		}
+	}
+	switch arrayType {
+	case 'B':
+		return byteArray, nil
+	case 'I':
+		return intArray, nil
+	}
+	return longArray, nil
+}
@@ -0,0 +1,127 @@
+package nbt2json
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestSnbt checks SNBT text against the tags it parses to and back
+func TestSnbt(t *testing.T) {
+	snbtTags := []struct {
+		snbt string
+		tag  Tag
+	}{
+		{`127b`, Byte(127)},
+		{`-128b`, Byte(-128)},
+		{`32767s`, Short(32767)},
+		{`2147483647`, Int(2147483647)},
+		{`-9223372036854775808L`, Long(math.MinInt64)},
+		{`20.0f`, Float(20)},
+		{`1.234567e+38f`, Float(1.234567e+38)},
+		{`-0.5d`, Double(-0.5)},
+		{`NaNd`, Double(math.NaN())},
+		{`"minecraft:stone"`, String("minecraft:stone")},
+		{`'say "hi"'`, String(`say "hi"`)},
+		{`"back\\slash"`, String(`back\slash`)},
+		{`[B;0b,-128b,127b]`, ByteArray{0, -128, 127}},
+		{`[I;0,2147483647,-2147483648]`, IntArray{0, 2147483647, -2147483648}},
+		{`[L;1L,-1L]`, LongArray{1, -1}},
+		{`[1,2,3]`, List{3, []Tag{Int(1), Int(2), Int(3)}}},
+		{`[]`, List{0, nil}},
+		{`{}`, Compound{}},
+		{`{Health:20.0f,Inventory:[{id:"minecraft:stone",Count:1b}],"odd key":"x"}`, Compound{
+			{"Health", Float(20)},
+			{"Inventory", List{10, []Tag{Compound{{"id", String("minecraft:stone")}, {"Count", Byte(1)}}}}},
+			{"odd key", String("x")},
+		}},
+	}
+	for _, test := range snbtTags {
+		tags, err := Snbt2Tags([]byte(test.snbt))
+		if err != nil {
+			t.Errorf("Error parsing %s: %s", test.snbt, err.Error())
+			continue
+		}
+		if len(tags) != 1 {
+			t.Errorf("Parsing %s expected 1 tag, got %d", test.snbt, len(tags))
+			continue
+		}
+		if f, ok := test.tag.(Double); ok && math.IsNaN(float64(f)) {
+			if d, ok := tags[0].Value.(Double); !ok || !math.IsNaN(float64(d)) {
+				t.Errorf("Parsing %s expected NaN double, got %#v", test.snbt, tags[0].Value)
+			}
+		} else if !reflect.DeepEqual(tags[0].Value, test.tag) {
+			t.Errorf("Parsing %s expected %#v, got %#v", test.snbt, test.tag, tags[0].Value)
+		}
+		if snbt := Snbt(tags[0].Value); snbt != test.snbt {
+			t.Errorf("Writing %#v expected %s, got %s", test.tag, test.snbt, snbt)
+		}
+	}
+
+	// Input Minecraft accepts that isn't written the same way
+	lenientTags := []struct {
+		snbt string
+		tag  Tag
+	}{
+		{` { a : 1b , b : true , c : 1.5 , d : 3D , e : 1e3F } `, Compound{
+			{"a", Byte(1)}, {"b", Byte(1)}, {"c", Double(1.5)}, {"d", Double(3)}, {"e", Float(1000)},
+		}},
+		{`unquoted`, String("unquoted")},
+		{`128b`, String("128b")},
+	}
+	for _, test := range lenientTags {
+		tags, err := Snbt2Tags([]byte(test.snbt))
+		if err != nil {
+			t.Errorf("Error parsing %s: %s", test.snbt, err.Error())
+		} else if !reflect.DeepEqual(tags[0].Value, test.tag) {
+			t.Errorf("Parsing %s expected %#v, got %#v", test.snbt, test.tag, tags[0].Value)
+		}
+	}
+
+	// NOTE: Tested function should throw error to pass
+	badSnbt := []string{``, `{a:1`, `{a 1}`, `[1,2b]`, `[B;1,"x"]`, `[B;300]`, `"unterminated`, `{a:1}}`}
+	for _, snbt := range badSnbt {
+		if _, err := Snbt2Tags([]byte(snbt)); err == nil {
+			t.Errorf("Parsing %q failed to throw error", snbt)
+		}
+	}
+
+	// Nesting is limited like it is in binary NBT
+	deep := strings.Repeat("[", 513) + strings.Repeat("]", 513)
+	var limitErr LimitError
+	if _, err := Snbt2Tags([]byte(deep)); !errors.As(err, &limitErr) || limitErr.Limit != "MaxDepth" {
+		t.Errorf("Parsing lists nested 513 deep expected a MaxDepth LimitError, got %v", err)
+	}
+	if _, err := Snbt2Tags([]byte(deep[1 : len(deep)-1])); err != nil {
+		t.Error("Error parsing lists nested 512 deep:", err.Error())
+	}
+	shallow := NewConverter()
+	shallow.UseLimits(Limits{MaxDepth: 3})
+	if _, err := shallow.Snbt2Tags([]byte(`{a:{b:[{}]}}`)); !errors.As(err, &limitErr) {
+		t.Errorf("Parsing SNBT nested 4 deep with MaxDepth 3 expected a LimitError, got %v", err)
+	}
+}
+
+// TestSnbtRoundTrip checks the test json's nbt survives a trip through SNBT
+func TestSnbtRoundTrip(t *testing.T) {
+	nbtData, err := Json2Nbt([]byte(testJson))
+	if err != nil {
+		t.Fatal("Error converting test json:", err.Error())
+	}
+	snbt, err := Nbt2Snbt(nbtData)
+	if err != nil {
+		t.Fatal("Error converting nbt to snbt:", err.Error())
+	}
+	nbtOut, err := Snbt2Nbt(snbt)
+	if err != nil {
+		t.Fatal("Error converting snbt to nbt:", err.Error())
+	}
+	if !bytes.Equal(nbtData, nbtOut) {
+		t.Error(fmt.Sprintf("SNBT round trip expected \n%s\n, got \n%s\n", hex.Dump(nbtData), hex.Dump(nbtOut)))
+	}
+}
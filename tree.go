@@ -0,0 +1,664 @@
+package nbt2json
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Tag is the payload of one NBT tag. Its concrete type is one of End, Byte, Short, Int, Long, Float, Double,
+// ByteArray, String, List, Compound, IntArray or LongArray.
+type Tag interface {
+	// TagType returns the NBT tag type number, e.g. 10 for Compound
+	TagType() byte
+}
+
+// NamedTag is a tag and its name, as found at the top level of NBT data and inside compounds
+type NamedTag struct {
+	Name  string
+	Value Tag
+}
+
+// End is tag type 0 which ends a compound. It only appears in a tree for lists of type 0 or top-level end tags.
+type End struct{}
+
+// Byte is tag type 1
+type Byte int8
+
+// Short is tag type 2
+type Short int16
+
+// Int is tag type 3
+type Int int32
+
+// Long is tag type 4
+type Long int64
+
+// Float is tag type 5
+type Float float32
+
+// Double is tag type 6
+type Double float64
+
+// ByteArray is tag type 7
+type ByteArray []int8
+
+// String is tag type 8
+type String string
+
+// List is tag type 9. All items must be of type TagListType, which is kept for empty lists.
+type List struct {
+	TagListType byte
+	Items       []Tag
+}
+
+// Compound is tag type 10. Child tags keep their NBT order.
+type Compound []NamedTag
+
+// IntArray is tag type 11
+type IntArray []int32
+
+// LongArray is tag type 12
+type LongArray []int64
+
+func (End) TagType() byte       { return 0 }
+func (Byte) TagType() byte      { return 1 }
+func (Short) TagType() byte     { return 2 }
+func (Int) TagType() byte       { return 3 }
+func (Long) TagType() byte      { return 4 }
+func (Float) TagType() byte     { return 5 }
+func (Double) TagType() byte    { return 6 }
+func (ByteArray) TagType() byte { return 7 }
+func (String) TagType() byte    { return 8 }
+func (List) TagType() byte      { return 9 }
+func (Compound) TagType() byte  { return 10 }
+func (IntArray) TagType() byte  { return 11 }
+func (LongArray) TagType() byte { return 12 }
+
+// Get returns the value of the child tag with the given name, or nil if there isn't one
+func (c Compound) Get(name string) Tag {
+	for _, tag := range c {
+		if tag.Name == name {
+			return tag.Value
+		}
+	}
+	return nil
+}
+
+// Set replaces the value of the child tag with the given name, or appends a new child tag if there isn't one
+func (c *Compound) Set(name string, value Tag) {
+	for i := range *c {
+		if (*c)[i].Name == name {
+			(*c)[i].Value = value
+			return
+		}
+	}
+	*c = append(*c, NamedTag{Name: name, Value: value})
+}
+
+// Delete removes the child tag with the given name and reports whether there was one
+func (c *Compound) Delete(name string) bool {
+	for i := range *c {
+		if (*c)[i].Name == name {
+			*c = append((*c)[:i], (*c)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ReadNBT reads NBT tags from r until the end of input using the module's default settings. Compressed NBT is
+// decompressed.
+func ReadNBT(r io.Reader) ([]NamedTag, error) {
+	return defaultConverter.ReadNBT(r)
+}
+
+// WriteNBT writes tags to w as uncompressed NBT using the module's default settings
+func WriteNBT(w io.Writer, tags []NamedTag) error {
+	return defaultConverter.WriteNBT(w, tags)
+}
+
+// ReadNBT reads NBT tags from r until the end of input. Compressed NBT is decompressed. A Bedrock level.dat header
+// is skipped; trees don't keep it.
+func (c *Converter) ReadNBT(r io.Reader) ([]NamedTag, error) {
+	in, _, err := NewDecompressReader(r)
+	if err != nil {
+		return nil, NbtParseError{s: "Decompressing NBT", e: err}
+	}
+	br := bufio.NewReader(in)
+	header, err := c.peekHeader(br)
+	if err != nil {
+		return nil, NbtParseError{s: "Reading level.dat header", e: err}
+	}
+	return c.readTags(c.newTagReader(br, header))
+}
+
+// EditNBT reads uncompressed NBT as a tree, calls edit to change it, and returns the edited NBT using the module's
+// default settings
+func EditNBT(b []byte, edit func(tags []NamedTag) ([]NamedTag, error)) ([]byte, error) {
+	return defaultConverter.EditNBT(b, edit)
+}
+
+// EditNBT reads uncompressed NBT as a tree, calls edit to change it, and returns the edited NBT. edit returns the
+// tree to write, which can be the tree it was given. A Bedrock level.dat header is kept and its length updated.
+func (c *Converter) EditNBT(b []byte, edit func(tags []NamedTag) ([]NamedTag, error)) ([]byte, error) {
+	br := bufio.NewReader(bytes.NewReader(b))
+	header, err := c.peekHeader(br)
+	if err != nil {
+		return nil, NbtParseError{s: "Reading level.dat header", e: err}
+	}
+	tags, err := c.readTags(c.newTagReader(br, header))
+	if err != nil {
+		return nil, err
+	}
+	tags, err = edit(tags)
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	err = c.WriteNBT(&body, tags)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return body.Bytes(), nil
+	}
+	var nbtOut bytes.Buffer
+	err = writeHeader(&nbtOut, header, body.Len())
+	if err != nil {
+		return nil, err
+	}
+	_, err = body.WriteTo(&nbtOut)
+	return nbtOut.Bytes(), err
+}
+
+// readTags reads tags until the end of input
+func (c *Converter) readTags(r *tagReader) ([]NamedTag, error) {
+	var tags []NamedTag
+	for {
+		start := r.offset
+		var tagType byte
+		err := binary.Read(r, c.byteOrder, &tagType)
+		if err == io.EOF {
+			return tags, nil
+		}
+		if err != nil {
+			return nil, nbtErrorAt(NbtParseError{s: "Reading TagType", e: err}, start, "", 0)
+		}
+		tag, err := c.readTag(r, tagType)
+		if err != nil {
+			return nil, nbtErrorAt(err, start, "", tagType)
+		}
+		tags = append(tags, tag)
+	}
+}
+
+// tagReader reads NBT for readTags and Decoder. It counts the bytes read and how deeply tags are nested, so errors can
+// give their offset and the converter's limits can be enforced.
+type tagReader struct {
+	r      *bufio.Reader
+	limits Limits
+	offset int64
+	depth  int
+}
+
+// newTagReader returns a tagReader for NBT read after header, which is nil if there wasn't one
+func (c *Converter) newTagReader(r *bufio.Reader, header *NbtHeader) *tagReader {
+	tr := &tagReader{r: r, limits: c.limits}
+	if header != nil {
+		tr.offset = headerSize
+	}
+	return tr
+}
+
+// bytesLeft returns how many more bytes can be read under MaxBytes, or an error if none can and there are more
+func (r *tagReader) bytesLeft(want int) (int, error) {
+	if r.limits.MaxBytes <= 0 || r.offset+int64(want) <= r.limits.MaxBytes {
+		return want, nil
+	}
+	left := int(r.limits.MaxBytes - r.offset)
+	if left > 0 {
+		return left, nil
+	}
+	if _, err := r.r.Peek(1); err != nil {
+		return 0, err
+	}
+	return 0, LimitError{"MaxBytes", r.offset + int64(want), r.limits.MaxBytes}
+}
+
+func (r *tagReader) Read(p []byte) (int, error) {
+	left, err := r.bytesLeft(len(p))
+	if err != nil {
+		return 0, err
+	}
+	n, err := r.r.Read(p[:left])
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *tagReader) ReadByte() (byte, error) {
+	if _, err := r.bytesLeft(1); err != nil {
+		return 0, err
+	}
+	b, err := r.r.ReadByte()
+	if err == nil {
+		r.offset++
+	}
+	return b, err
+}
+
+// enter is called before reading a compound or list's contents, and leave after
+func (r *tagReader) enter() error {
+	r.depth++
+	if r.limits.MaxDepth > 0 && r.depth > r.limits.MaxDepth {
+		return LimitError{"MaxDepth", int64(r.depth), int64(r.limits.MaxDepth)}
+	}
+	return nil
+}
+
+func (r *tagReader) leave() {
+	r.depth--
+}
+
+// checkListLength checks a list's length like checkLength. End tags have no payload, so a list of them could be
+// long without any more input; like Minecraft, only empty lists can have type End.
+func checkListLength(n int32, tagListType byte, limits Limits) error {
+	if tagListType == 0 && n > 0 {
+		return fmt.Errorf("list of tag type 0 has %d items", n)
+	}
+	return checkLength(int64(n), limits.MaxArrayLength, "MaxArrayLength")
+}
+
+// checkLength checks a length read from the input isn't negative or over max, the value of the Limits field named limit
+func checkLength(n int64, max int, limit string) error {
+	if n < 0 {
+		return fmt.Errorf("length %d is negative", n)
+	}
+	if max > 0 && n > int64(max) {
+		return LimitError{limit, n, int64(max)}
+	}
+	return nil
+}
+
+// WriteNBT writes tags to w as uncompressed NBT
+func (c *Converter) WriteNBT(w io.Writer, tags []NamedTag) error {
+	bw := bufio.NewWriter(w)
+	for _, tag := range tags {
+		err := c.writeTag(bw, tag)
+		if err != nil {
+			return jsonErrorAt(err, -1, "", 0)
+		}
+	}
+	return bw.Flush()
+}
+
+// readName reads a tag name, which is encoded the same as a string payload
+func (c *Converter) readName(r *tagReader) (string, error) {
+	nameLen, err := c.readStringLength(r)
+	if err == nil {
+		err = checkLength(int64(nameLen), r.limits.MaxStringLength, "MaxStringLength")
+	}
+	if err != nil {
+		return "", NbtParseError{s: "Reading Name length", e: err}
+	}
+	name := make([]byte, nameLen)
+	_, err = io.ReadFull(r, name)
+	if err != nil {
+		return "", NbtParseError{s: fmt.Sprintf("Reading Name - is UseJavaEncoding or UseBedrockEncoding set correctly? DetectEncoding can guess. Name length decoded is %d", nameLen), e: err}
+	}
+	s, err := c.decodeString(name)
+	if err != nil {
+		return "", NbtParseError{s: fmt.Sprintf("Decoding Name %q - try UseRawStringFallback", name), e: err}
+	}
+	return s, nil
+}
+
+// readTag reads the name and payload of a tag whose type has already been read
+func (c *Converter) readTag(r *tagReader, tagType byte) (NamedTag, error) {
+	var tag NamedTag
+	var err error
+	// do not try to fetch name for TagType 0 which is compound end tag
+	if tagType != 0 {
+		tag.Name, err = c.readName(r)
+		if err != nil {
+			return tag, err
+		}
+	}
+	tag.Value, err = c.readPayload(r, tagType)
+	return tag, err
+}
+
+// readPayload reads the payload of a tag of the given type
+func (c *Converter) readPayload(r *tagReader, tagType byte) (Tag, error) {
+	var err error
+	switch tagType {
+	case 0:
+		// end tag for compound; do nothing further
+		return End{}, nil
+	case 1:
+		var i int8
+		err = binary.Read(r, c.byteOrder, &i)
+		if err != nil {
+			return nil, NbtParseError{s: "Reading int8", e: err}
+		}
+		return Byte(i), nil
+	case 2:
+		var i int16
+		err = binary.Read(r, c.byteOrder, &i)
+		if err != nil {
+			return nil, NbtParseError{s: "Reading int16", e: err}
+		}
+		return Short(i), nil
+	case 3:
+		i, err := c.readInt(r)
+		if err != nil {
+			return nil, NbtParseError{s: "Reading int32", e: err}
+		}
+		return Int(i), nil
+	case 4:
+		i, err := c.readLong(r)
+		if err != nil {
+			return nil, NbtParseError{s: "Reading int64", e: err}
+		}
+		return Long(i), nil
+	case 5:
+		var f float32
+		err = binary.Read(r, c.byteOrder, &f)
+		if err != nil {
+			return nil, NbtParseError{s: "Reading float32", e: err}
+		}
+		return Float(f), nil
+	case 6:
+		var f float64
+		err = binary.Read(r, c.byteOrder, &f)
+		if err != nil {
+			return nil, NbtParseError{s: "Reading float64", e: err}
+		}
+		return Double(f), nil
+	case 7:
+		var byteArray ByteArray
+		var oneByte int8
+		numRecords, err := c.readInt(r)
+		if err == nil {
+			err = checkLength(int64(numRecords), r.limits.MaxArrayLength, "MaxArrayLength")
+		}
+		if err != nil {
+			return nil, NbtParseError{s: "Reading byte array tag length", e: err}
+		}
+		for i := int32(1); i <= numRecords; i++ {
+			err = binary.Read(r, c.byteOrder, &oneByte)
+			if err != nil {
+				return nil, NbtParseError{s: "Reading byte in byte array tag", e: err}
+			}
+			byteArray = append(byteArray, oneByte)
+		}
+		return byteArray, nil
+	case 8:
+		strLen, err := c.readStringLength(r)
+		if err == nil {
+			err = checkLength(int64(strLen), r.limits.MaxStringLength, "MaxStringLength")
+		}
+		if err != nil {
+			return nil, NbtParseError{s: "Reading string tag length", e: err}
+		}
+		utf8String := make([]byte, strLen)
+		_, err = io.ReadFull(r, utf8String)
+		if err != nil {
+			return nil, NbtParseError{s: "Reading string tag data", e: err}
+		}
+		s, err := c.decodeString(utf8String)
+		if err != nil {
+			return nil, NbtParseError{s: fmt.Sprintf("Decoding string tag data %q - try UseRawStringFallback", utf8String), e: err}
+		}
+		return String(s), nil
+	case 9:
+		var tagList List
+		err = binary.Read(r, c.byteOrder, &tagList.TagListType)
+		if err != nil {
+			return nil, NbtParseError{s: "Reading TagType", e: err}
+		}
+		numRecords, err := c.readInt(r)
+		if err == nil {
+			err = checkListLength(numRecords, tagList.TagListType, r.limits)
+		}
+		if err != nil {
+			return nil, NbtParseError{s: "Reading list tag length", e: err}
+		}
+		err = r.enter()
+		if err != nil {
+			return nil, NbtParseError{s: "Reading list tag", e: err}
+		}
+		defer r.leave()
+		for i := int32(0); i < numRecords; i++ {
+			start := r.offset
+			payload, err := c.readPayload(r, tagList.TagListType)
+			if err != nil {
+				return nil, wrapNbtError("Reading list tag item", nbtErrorAt(err, start, fmt.Sprintf("[%d]", i), tagList.TagListType))
+			}
+			tagList.Items = append(tagList.Items, payload)
+		}
+		return tagList, nil
+	case 10:
+		err = r.enter()
+		if err != nil {
+			return nil, NbtParseError{s: "Reading compound tag", e: err}
+		}
+		defer r.leave()
+		compound := Compound{}
+		for {
+			start := r.offset
+			var tagType byte
+			err = binary.Read(r, c.byteOrder, &tagType)
+			if err != nil {
+				return nil, NbtParseError{s: "compound: reading next tag type", e: err}
+			}
+			if tagType == 0 {
+				return compound, nil
+			}
+			tag, err := c.readTag(r, tagType)
+			if err != nil {
+				return nil, wrapNbtError("compound: reading a child tag", nbtErrorAt(err, start, "/"+tag.Name, tagType))
+			}
+			compound = append(compound, tag)
+		}
+	case 11:
+		var intArray IntArray
+		numRecords, err := c.readInt(r)
+		if err == nil {
+			err = checkLength(int64(numRecords), r.limits.MaxArrayLength, "MaxArrayLength")
+		}
+		if err != nil {
+			return nil, NbtParseError{s: "Reading int array tag length", e: err}
+		}
+		for i := int32(1); i <= numRecords; i++ {
+			oneInt, err := c.readInt(r)
+			if err != nil {
+				return nil, NbtParseError{s: "Reading int in int array tag", e: err}
+			}
+			intArray = append(intArray, oneInt)
+		}
+		return intArray, nil
+	case 12:
+		var longArray LongArray
+		numRecords, err := c.readInt(r)
+		if err == nil {
+			err = checkLength(int64(numRecords), r.limits.MaxArrayLength, "MaxArrayLength")
+		}
+		if err != nil {
+			return nil, NbtParseError{s: "Reading long array tag length", e: err}
+		}
+		for i := int32(1); i <= numRecords; i++ {
+			oneInt, err := c.readLong(r)
+			if err != nil {
+				return nil, NbtParseError{s: "Reading long in long array tag", e: err}
+			}
+			longArray = append(longArray, oneInt)
+		}
+		return longArray, nil
+	default:
+		return nil, NbtParseError{s: fmt.Sprintf("TagType %d not recognized", tagType)}
+	}
+}
+
+// writeTagHeader writes the tag type and name that come before a tag's payload
+func (c *Converter) writeTagHeader(w io.Writer, tagType byte, name string) error {
+	err := binary.Write(w, c.byteOrder, tagType)
+	if err != nil {
+		return JsonParseError{s: "Error writing tagType" + string(tagType), e: err}
+	}
+	nameBytes := c.encodeString(name)
+	err = c.writeStringLength(w, len(nameBytes))
+	if err != nil {
+		return JsonParseError{s: "Error writing name length", e: err}
+	}
+	err = binary.Write(w, c.byteOrder, nameBytes)
+	if err != nil {
+		return JsonParseError{s: "Error converting name", e: err}
+	}
+	return nil
+}
+
+// writeTag writes a tag's type, name and payload
+func (c *Converter) writeTag(w io.Writer, tag NamedTag) error {
+	if tag.Value == nil {
+		return JsonParseError{s: fmt.Sprintf("tag '%s' has no value", tag.Name)}
+	}
+	if _, ok := tag.Value.(End); ok {
+		// end tags have no name or payload
+		return binary.Write(w, c.byteOrder, byte(0))
+	}
+	err := c.writeTagHeader(w, tag.Value.TagType(), tag.Name)
+	if err == nil {
+		err = c.writePayload(w, tag.Value)
+	}
+	if err != nil {
+		return jsonErrorAt(err, -1, "", tag.Value.TagType())
+	}
+	return nil
+}
+
+// writePayload writes the payload of a tag
+func (c *Converter) writePayload(w io.Writer, tag Tag) error {
+	var err error
+	switch tag := tag.(type) {
+	case End:
+		// nothing to write
+	case Byte, Short:
+		err = binary.Write(w, c.byteOrder, tag)
+		if err != nil {
+			return JsonParseError{s: fmt.Sprintf("Error writing tag %d payload", tag.TagType()), e: err}
+		}
+	case Float:
+		// binary.Write would convert a Float to float64 and back, which can change a NaN's payload
+		err = binary.Write(w, c.byteOrder, math.Float32bits(float32(tag)))
+		if err != nil {
+			return JsonParseError{s: "Error writing tag 5 payload", e: err}
+		}
+	case Double:
+		err = binary.Write(w, c.byteOrder, math.Float64bits(float64(tag)))
+		if err != nil {
+			return JsonParseError{s: "Error writing tag 6 payload", e: err}
+		}
+	case Int:
+		err = c.writeInt(w, int32(tag))
+		if err != nil {
+			return JsonParseError{s: "Error writing int32 payload", e: err}
+		}
+	case Long:
+		err = c.writeLong(w, int64(tag))
+		if err != nil {
+			return JsonParseError{s: "Error writing int64 payload", e: err}
+		}
+	case ByteArray:
+		err = c.writeInt(w, int32(len(tag)))
+		if err != nil {
+			return JsonParseError{s: "Error writing byte array length", e: err}
+		}
+		err = binary.Write(w, c.byteOrder, []int8(tag))
+		if err != nil {
+			return JsonParseError{s: "Error writing byte array", e: err}
+		}
+	case String:
+		strBytes := c.encodeString(string(tag))
+		err = c.writeStringLength(w, len(strBytes))
+		if err != nil {
+			return JsonParseError{s: "Error writing string length", e: err}
+		}
+		err = binary.Write(w, c.byteOrder, strBytes)
+		if err != nil {
+			return JsonParseError{s: "Error writing string payload", e: err}
+		}
+	case List:
+		err = binary.Write(w, c.byteOrder, tag.TagListType)
+		if err != nil {
+			return JsonParseError{s: "While writing tag 9 list type", e: err}
+		}
+		if tag.TagListType == 0 && len(tag.Items) > 0 {
+			return JsonParseError{s: "A list of tag type 0 can't have items"}
+		}
+		err = c.writeInt(w, int32(len(tag.Items)))
+		if err != nil {
+			return JsonParseError{s: "While writing tag 9 list size", e: err}
+		}
+		for i, item := range tag.Items {
+			itemType := tag.TagListType
+			if item == nil || item.TagType() != tag.TagListType {
+				err = JsonParseError{s: fmt.Sprintf("List item %#v is not of list type %d", item, tag.TagListType)}
+				if item != nil {
+					itemType = item.TagType()
+				}
+			} else {
+				err = c.writePayload(w, item)
+			}
+			if err != nil {
+				err = jsonErrorAt(err, -1, fmt.Sprintf("[%d]", i), itemType)
+				return wrapJsonError(fmt.Sprintf("While writing tag 9 list of type %d", tag.TagListType), err)
+			}
+		}
+	case Compound:
+		for _, child := range tag {
+			if _, ok := child.Value.(End); ok {
+				// an End tag here would end the compound early
+				err = JsonParseError{s: "End tags can only be at the top level, not in a compound"}
+				return wrapJsonError("While writing Compound tags", jsonErrorAt(err, -1, "/"+child.Name, 0))
+			}
+			err = c.writeTag(w, child)
+			if err != nil {
+				return wrapJsonError("While writing Compound tags", jsonErrorAt(err, -1, "/"+child.Name, 0))
+			}
+		}
+		// write the end tag which is just a single byte 0
+		err = binary.Write(w, c.byteOrder, byte(0))
+		if err != nil {
+			return JsonParseError{s: "Writing End tag", e: err}
+		}
+	case IntArray:
+		err = c.writeInt(w, int32(len(tag)))
+		if err != nil {
+			return JsonParseError{s: "Error writing int32 array length", e: err}
+		}
+		for _, i := range tag {
+			err = c.writeInt(w, i)
+			if err != nil {
+				return JsonParseError{s: "Error writing element of int32 array", e: err}
+			}
+		}
+	case LongArray:
+		err = c.writeInt(w, int32(len(tag)))
+		if err != nil {
+			return JsonParseError{s: "Error writing int64 array length", e: err}
+		}
+		for _, i := range tag {
+			err = c.writeLong(w, i)
+			if err != nil {
+				return JsonParseError{s: "Error writing element of int64 array", e: err}
+			}
+		}
+	default:
+		return JsonParseError{s: fmt.Sprintf("Tag %#v is not a recognized type", tag)}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package nbt2json
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// conversionTime changes between conversions, so it's removed before comparing json documents
+var conversionTimeRegexp = regexp.MustCompile(`(?m)^\s*"conversionTime": .*$`)
+
+// TestTreeRoundTrip checks that the tag tree reads and writes the same nbt and json as the byte array functions
+func TestTreeRoundTrip(t *testing.T) {
+	nbtData, err := Json2Nbt([]byte(testJson))
+	if err != nil {
+		t.Fatal("Error converting test json:", err.Error())
+	}
+
+	tags, err := ReadNBT(bytes.NewReader(nbtData))
+	if err != nil {
+		t.Fatal("Error reading nbt to tree:", err.Error())
+	}
+	var nbtOut bytes.Buffer
+	err = WriteNBT(&nbtOut, tags)
+	if err != nil {
+		t.Fatal("Error writing tree to nbt:", err.Error())
+	}
+	if !bytes.Equal(nbtData, nbtOut.Bytes()) {
+		t.Error(fmt.Sprintf("Tree round trip expected \n%s\n, got \n%s\n", hex.Dump(nbtData), hex.Dump(nbtOut.Bytes())))
+	}
+
+	treeJson, err := Tags2Json(tags, "")
+	if err != nil {
+		t.Fatal("Error converting tree to json:", err.Error())
+	}
+	streamJson, err := Nbt2Json(nbtData, "")
+	if err != nil {
+		t.Fatal("Error converting nbt to json:", err.Error())
+	}
+	if !bytes.Equal(conversionTimeRegexp.ReplaceAll(treeJson, nil), conversionTimeRegexp.ReplaceAll(streamJson, nil)) {
+		t.Error(fmt.Sprintf("Tree json doesn't match Nbt2Json, expected \n%s\n, got \n%s\n", streamJson, treeJson))
+	}
+
+	jsonTags, err := Json2Tags([]byte(testJson))
+	if err != nil {
+		t.Fatal("Error converting json to tree:", err.Error())
+	}
+	nbtOut.Reset()
+	err = WriteNBT(&nbtOut, jsonTags)
+	if err != nil {
+		t.Fatal("Error writing tree from json to nbt:", err.Error())
+	}
+	if !bytes.Equal(nbtData, nbtOut.Bytes()) {
+		t.Error(fmt.Sprintf("Json2Tags expected \n%s\n, got \n%s\n", hex.Dump(nbtData), hex.Dump(nbtOut.Bytes())))
+	}
+}
+
+// TestTreeEdit builds and edits a tree in Go
+func TestTreeEdit(t *testing.T) {
+	root := Compound{
+		{"Health", Float(20)},
+		{"Inventory", List{10, []Tag{
+			Compound{{"id", String("minecraft:stone")}, {"Count", Byte(1)}},
+		}}},
+	}
+	root.Set("Health", Float(10))
+	root.Set("XpLevel", Int(3))
+	if !root.Delete("Inventory") {
+		t.Error("Delete didn't find Inventory")
+	}
+	if root.Delete("Inventory") {
+		t.Error("Delete found Inventory twice")
+	}
+	if root.Get("Health") != Float(10) || root.Get("XpLevel") != Int(3) || root.Get("Inventory") != nil {
+		t.Errorf("Unexpected compound after edits: %#v", root)
+	}
+
+	var nbtOut bytes.Buffer
+	err := WriteNBT(&nbtOut, []NamedTag{{"", root}})
+	if err != nil {
+		t.Fatal("Error writing edited tree:", err.Error())
+	}
+	expected := []byte{
+		10, 0, 0,
+		5, 6, 0, 'H', 'e', 'a', 'l', 't', 'h', 0, 0, 0x20, 0x41,
+		3, 7, 0, 'X', 'p', 'L', 'e', 'v', 'e', 'l', 3, 0, 0, 0,
+		0,
+	}
+	if !bytes.Equal(nbtOut.Bytes(), expected) {
+		t.Error(fmt.Sprintf("Edited tree expected \n%s\n, got \n%s\n", hex.Dump(expected), hex.Dump(nbtOut.Bytes())))
+	}
+
+	// NOTE: Tested function should throw error to pass
+	err = WriteNBT(&nbtOut, []NamedTag{{"", List{3, []Tag{Int(1), Short(2)}}}})
+	if err == nil {
+		t.Error("List with mismatched item type failed to throw error")
+	}
+
+	// An End tag in a compound would end it early, so it's only allowed at the top level
+	nbtOut.Reset()
+	var jsonErr JsonParseError
+	err = WriteNBT(&nbtOut, []NamedTag{{"", Compound{{"x", End{}}, {"y", Byte(1)}}}})
+	if !errors.As(err, &jsonErr) || jsonErr.Path != "/x" {
+		t.Errorf("End tag in a compound expected an error at /x, got %v", err)
+	}
+	err = WriteNBT(&nbtOut, []NamedTag{{"", List{0, []Tag{End{}}}}})
+	if err == nil {
+		t.Error("List of End tags failed to throw error")
+	}
+	nbtOut.Reset()
+	err = WriteNBT(&nbtOut, []NamedTag{{"", Compound{}}, {"", End{}}})
+	if err != nil || !bytes.Equal(nbtOut.Bytes(), []byte{10, 0, 0, 0, 0}) {
+		t.Errorf("Top-level End tag expected to be written, got % x, %v", nbtOut.Bytes(), err)
+	}
+}
+
+// TestErrorLocation checks errors give the offset, path and tag type of the tag that failed
+func TestErrorLocation(t *testing.T) {
+	c := NewConverter()
+	c.UseJavaEncoding()
+	tags, _ := Snbt2Tags([]byte(`{Level:{Sections:[{Y:0b},{Y:1b,BlockStates:[L;1L,2L,3L]}]}}`))
+	var nbtOut bytes.Buffer
+	c.WriteNBT(&nbtOut, tags)
+	// Cut off the last long and the end tags
+	truncated := nbtOut.Bytes()[:nbtOut.Len()-12]
+	blockStatesOffset := int64(bytes.Index(truncated, []byte("\x0c\x00\x0bBlockStates")))
+
+	var nbtErr NbtParseError
+	_, err := c.ReadNBT(bytes.NewReader(truncated))
+	if !errors.As(err, &nbtErr) || nbtErr.Offset != blockStatesOffset || nbtErr.Path != "/Level/Sections[1]/BlockStates" || nbtErr.TagType != 12 {
+		t.Errorf("ReadNBT error expected BlockStates at %d, got %v", blockStatesOffset, err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ReadNBT error expected to wrap io.ErrUnexpectedEOF, got %v", err)
+	}
+	_, err = c.Nbt2Json(truncated, "")
+	if !errors.As(err, &nbtErr) || nbtErr.Offset != blockStatesOffset || nbtErr.Path != "/Level/Sections[1]/BlockStates" || nbtErr.TagType != 12 {
+		t.Errorf("Nbt2Json error expected BlockStates at %d, got %v", blockStatesOffset, err)
+	}
+
+	jsonData, _ := c.Tags2Json(tags, "")
+	jsonData = bytes.Replace(jsonData, []byte(`"valueLeast": 3`), []byte(`"valueLeast": "3"`), 1)
+	var jsonErr JsonParseError
+	_, err = c.Json2Nbt(jsonData)
+	if !errors.As(err, &jsonErr) || jsonErr.Path != "/Level/Sections[1]/BlockStates" || jsonErr.TagType != 12 {
+		t.Errorf("Json2Nbt error expected BlockStates, got %v", err)
+	} else if !bytes.HasPrefix(bytes.TrimLeft(jsonData[jsonErr.Offset:], "{ \n"), []byte(`"tagType": 12`)) {
+		t.Errorf("Json2Nbt error offset %d isn't the BlockStates tag: %s", jsonErr.Offset, jsonData[jsonErr.Offset:])
+	}
+	_, err = c.Json2Tags(jsonData)
+	if !errors.As(err, &jsonErr) || jsonErr.Offset != -1 || jsonErr.Path != "/Level/Sections[1]/BlockStates" || jsonErr.TagType != 12 {
+		t.Errorf("Json2Tags error expected BlockStates, got %v", err)
+	}
+
+	tags[0].Value.(Compound)[0].Value.(Compound)[0].Value.(List).Items[1] = Byte(1)
+	err = c.WriteNBT(&nbtOut, tags)
+	if !errors.As(err, &jsonErr) || jsonErr.Path != "/Level/Sections[1]" || jsonErr.TagType != 1 {
+		t.Errorf("WriteNBT error expected Sections[1], got %v", err)
+	}
+}
+
+// TestLimits checks corrupt and oversized NBT is an error instead of a panic or a huge allocation
+func TestLimits(t *testing.T) {
+	deep := append([]byte{9, 0, 0}, bytes.Repeat([]byte{9, 1, 0, 0, 0}, 600)...)
+	inputs := []struct {
+		name   string
+		nbt    []byte
+		limits Limits
+		limit  string
+	}{
+		{"name length past the end", []byte{10, 0xff, 0xff, 0}, DefaultLimits, ""},
+		{"string length past the end", []byte{8, 0, 0, 0xfe, 0xff}, DefaultLimits, ""},
+		{"negative array length", []byte{11, 0, 0, 0xff, 0xff, 0xff, 0xff}, DefaultLimits, ""},
+		{"negative list length", []byte{9, 0, 0, 1, 0xff, 0xff, 0xff, 0xff}, DefaultLimits, ""},
+		{"long list of end tags", []byte{9, 0, 0, 0, 0xff, 0xff, 0xff, 0x7f}, DefaultLimits, ""},
+		{"deep nesting", deep, DefaultLimits, "MaxDepth"},
+		{"depth", []byte{10, 0, 0, 10, 1, 0, 'a', 0, 0}, Limits{MaxDepth: 1}, "MaxDepth"},
+		{"bytes", []byte{8, 0, 0, 5, 0, 'h', 'e', 'l', 'l', 'o'}, Limits{MaxBytes: 9}, "MaxBytes"},
+		{"array length", []byte{7, 0, 0, 0xff, 0xff, 0xff, 0x7f}, Limits{MaxArrayLength: 1000}, "MaxArrayLength"},
+		{"list length", []byte{9, 0, 0, 1, 2, 0, 0, 0, 1, 2}, Limits{MaxArrayLength: 1}, "MaxArrayLength"},
+		{"string length", []byte{8, 0, 0, 5, 0, 'h', 'e', 'l', 'l', 'o'}, Limits{MaxStringLength: 4}, "MaxStringLength"},
+		{"name length", []byte{1, 5, 0, 'h', 'e', 'l', 'l', 'o', 1}, Limits{MaxStringLength: 4}, "MaxStringLength"},
+	}
+	for _, input := range inputs {
+		c := NewConverter()
+		c.UseLimits(input.limits)
+		_, treeErr := c.ReadNBT(bytes.NewReader(input.nbt))
+		_, streamErr := c.Nbt2Json(input.nbt, "")
+		for _, err := range []error{treeErr, streamErr} {
+			var nbtErr NbtParseError
+			var limitErr LimitError
+			if !errors.As(err, &nbtErr) {
+				t.Errorf("%s expected an NbtParseError, got %v", input.name, err)
+			} else if input.limit != "" && (!errors.As(err, &limitErr) || limitErr.Limit != input.limit) {
+				t.Errorf("%s expected a %s LimitError, got %v", input.name, input.limit, err)
+			}
+		}
+	}
+
+	// Input that fits exactly is fine
+	c := NewConverter()
+	c.UseLimits(Limits{MaxBytes: 10, MaxDepth: 1, MaxArrayLength: 2, MaxStringLength: 5})
+	for _, nbtData := range [][]byte{{8, 0, 0, 5, 0, 'h', 'e', 'l', 'l', 'o'}, {9, 0, 0, 1, 2, 0, 0, 0, 1, 2}} {
+		if _, err := c.ReadNBT(bytes.NewReader(nbtData)); err != nil {
+			t.Errorf("Reading %v within the limits: %s", nbtData, err.Error())
+		}
+	}
+
+	// Name and string lengths are unsigned shorts, so up to 65535 bytes read back
+	java, network := NewConverter(), NewConverter()
+	java.UseJavaEncoding()
+	network.UseBedrockNetworkEncoding()
+	for _, c := range []*Converter{NewConverter(), java, network} {
+		for _, n := range []int{40000, 65535} {
+			long := strings.Repeat("a", n)
+			tags := []NamedTag{{long, String(long)}}
+			var nbtOut bytes.Buffer
+			err := c.WriteNBT(&nbtOut, tags)
+			if err != nil {
+				t.Fatalf("Error writing a %d byte string: %s", n, err.Error())
+			}
+			read, err := c.ReadNBT(bytes.NewReader(nbtOut.Bytes()))
+			if err != nil || !reflect.DeepEqual(read, tags) {
+				t.Errorf("%d byte name and string didn't read back: %v", n, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package nbt2json
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+)
+
+// ValidateJson checks a JSON document like Json2Nbt's input using the module's default settings
+func ValidateJson(b []byte) error {
+	return defaultConverter.ValidateJson(b)
+}
+
+// ValidateYaml checks a YAML document like Yaml2Nbt's input using the module's default settings
+func ValidateYaml(b []byte) error {
+	return defaultConverter.ValidateYaml(b)
+}
+
+// ValidateYaml checks a YAML document like Yaml2Nbt's input the same as ValidateJson
+func (c *Converter) ValidateYaml(b []byte) error {
+	myJson, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return ValidationError{[]JsonParseError{{s: "Error converting YAML to JSON", e: err}}}
+	}
+	return c.ValidateJson(myJson)
+}
+
+// ValidateJson checks a JSON document like Json2Nbt's input without converting it, and returns a ValidationError
+// listing every problem that would stop the conversion instead of only the first. Each problem has the path and
+// tag type of the tag it is in; its offset isn't known. With UseStrictJson, fractions in integer tags and unknown
+// fields are problems too.
+func (c *Converter) ValidateJson(b []byte) error {
+	var document interface{}
+	err := json.Unmarshal(b, &document)
+	if err != nil {
+		return ValidationError{[]JsonParseError{{s: "Error parsing JSON input. Is input JSON-formatted?", e: err}}}
+	}
+	v := jsonValidator{c: c}
+	v.document(document)
+	if len(v.problems) > 0 {
+		return ValidationError{v.problems}
+	}
+	return nil
+}
+
+// jsonValidator collects the problems in a JSON document
+type jsonValidator struct {
+	c        *Converter
+	problems []JsonParseError
+}
+
+// add records a problem in the tag at path
+func (v *jsonValidator) add(err error, path string, tagType byte) {
+	e, ok := err.(JsonParseError)
+	if !ok {
+		e = JsonParseError{s: "Converting tag", e: err}
+	}
+	e.Offset, e.Path, e.TagType, e.located = -1, path, tagType, true
+	v.problems = append(v.problems, e)
+}
+
+// document checks the top-level object and its tags
+func (v *jsonValidator) document(document interface{}) {
+	m, ok := document.(map[string]interface{})
+	if !ok {
+		v.problems = append(v.problems, JsonParseError{s: "Error parsing JSON input. Is input JSON-formatted?"})
+		return
+	}
+	if err := v.c.checkJsonFields(m, documentFields...); err != nil {
+		v.problems = append(v.problems, err.(JsonParseError))
+	}
+	if m["header"] != nil {
+		b, _ := json.Marshal(m["header"])
+		var header NbtHeader
+		if err := json.Unmarshal(b, &header); err != nil {
+			v.problems = append(v.problems, JsonParseError{s: "Error parsing top-level value header", e: err})
+		}
+	}
+	if m["compression"] != nil {
+		b, _ := json.Marshal(m["compression"])
+		var compression NbtCompression
+		if err := json.Unmarshal(b, &compression); err != nil {
+			v.problems = append(v.problems, JsonParseError{s: "Error parsing top-level value compression", e: err})
+		} else if _, err := compression.compression(); err != nil {
+			v.problems = append(v.problems, JsonParseError{s: "Error in top-level value compression", e: err})
+		}
+	}
+	tags, ok := m["nbt"].([]interface{})
+	if m["nbt"] != nil && !ok {
+		v.problems = append(v.problems, JsonParseError{s: fmt.Sprintf("nbt: value '%v' is not an array", m["nbt"])})
+		return
+	}
+	if len(tags) == 0 {
+		v.problems = append(v.problems, JsonParseError{s: "JSON input has no top-level value named nbt. JSON-encoded nbt data should be in an array { \"nbt\": [ <HERE> ] }"})
+	}
+	for _, tag := range tags {
+		v.tag(tag, "", false)
+	}
+}
+
+// tag checks a tagType/name/value object. Child tags add their name to parentPath.
+func (v *jsonValidator) tag(value interface{}, parentPath string, child bool) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		v.add(JsonParseError{s: fmt.Sprintf("tag '%v' is not an object", value)}, parentPath, 0)
+		return
+	}
+	name, nameOk := stringFromJson(m["name"])
+	path := parentPath
+	if child {
+		path += "/" + name
+	}
+	tagType, err := jsonTagType(m["tagType"])
+	if err != nil {
+		v.add(err, path, 0)
+		return
+	}
+	if tagType == 0 {
+		return
+	}
+	if !nameOk {
+		v.add(JsonParseError{s: fmt.Sprintf("name field '%v' not a string", m["name"])}, path, tagType)
+	} else if err := v.c.checkStringLength(name); err != nil {
+		v.add(JsonParseError{s: "name is too long", e: err}, path, tagType)
+	}
+	if err := v.c.checkJsonFields(m, tagFields...); err != nil {
+		v.add(err, path, tagType)
+	}
+	v.payload(m["value"], tagType, path)
+}
+
+// payload checks a tag's value. Lists and compounds are checked item by item so all of their problems are found.
+func (v *jsonValidator) payload(value interface{}, tagType byte, path string) {
+	switch tagType {
+	case 9:
+		tagListType, values, err := v.c.jsonList(value)
+		if err != nil {
+			v.add(err, path, tagType)
+		}
+		for i, item := range values {
+			v.payload(item, tagListType, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case 10:
+		values, ok := value.([]interface{})
+		if !ok {
+			v.add(JsonParseError{s: fmt.Sprintf("Tag 10 Compound value field '%v' not an array", value)}, path, tagType)
+			return
+		}
+		for _, child := range values {
+			v.tag(child, path, true)
+		}
+	default:
+		if _, err := v.c.payloadFromJson(value, float64(tagType)); err != nil {
+			v.add(err, path, tagType)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package nbt2json
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+const invalidJson = `{"nbt":[{"tagType":10,"name":"","value":[
+	{"tagType":"1","name":"a","value":1},
+	{"tagType":1,"name":"b","value":1.5},
+	{"tagType":9,"name":"c","value":{"list":[1,2]}},
+	{"tagType":9,"name":"d","value":{"tagListType":1,"list":[1,"x",300]}},
+	{"tagType":9,"name":"e","value":{"tagListType":13,"list":[]}},
+	{"tagType":3,"name":"f","value":1,"extra":true},
+	{"tagType":10,"name":"g","value":[{"tagType":4,"name":"h","value":{"valueLeast":-5000000000,"valueMost":0}}]}
+]}],"bogus":1}`
+
+// TestValidateJson checks every problem in a document is reported with its path
+func TestValidateJson(t *testing.T) {
+	c := NewConverter()
+	if err := c.ValidateJson([]byte(testJson)); err != nil {
+		t.Error("Validating test json:", err.Error())
+	}
+	modes := []struct {
+		use      func()
+		expected []string
+	}{
+		{c.UseLenientJson, []string{"/a", "/c", "/d[1]", "/d[2]", "/e", "/g/h"}},
+		{c.UseStrictJson, []string{"", "/a", "/b", "/c", "/d[1]", "/d[2]", "/e", "/f", "/g/h"}},
+	}
+	for _, mode := range modes {
+		mode.use()
+		expected := mode.expected
+		err := c.ValidateJson([]byte(invalidJson))
+		var validationErr ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("Expected a ValidationError, got %v", err)
+		}
+		var paths []string
+		for _, problem := range validationErr.Problems {
+			paths = append(paths, problem.Path)
+		}
+		if len(paths) != len(expected) {
+			t.Fatalf("Expected problems in %q, got %q", expected, paths)
+		}
+		for i := range paths {
+			if paths[i] != expected[i] {
+				t.Errorf("Expected problems in %q, got %q", expected, paths)
+				break
+			}
+		}
+		var jsonErr JsonParseError
+		if !errors.As(err, &jsonErr) || jsonErr.Path != expected[0] {
+			t.Errorf("ValidationError expected to unwrap to the first problem, got %v", jsonErr)
+		}
+	}
+	if err := c.ValidateJson([]byte(testJson)); err != nil {
+		t.Error("Validating test json in strict mode:", err.Error())
+	}
+
+	// Converting reports every problem too, and writes nothing
+	var nbtOut bytes.Buffer
+	err := c.NewEncoder(&nbtOut).Encode(strings.NewReader(invalidJson))
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Problems) != len(modes[1].expected) {
+		t.Errorf("Encode expected %d problems, got %v", len(modes[1].expected), err)
+	} else if nbtOut.Len() != 0 {
+		t.Errorf("Encode wrote %d bytes of an invalid document", nbtOut.Len())
+	}
+
+	// Names and strings can't be longer than their 16-bit length allows once encoded. Java Edition writes NULs as two
+	// bytes, so 40000 of them are too long there but not in Bedrock Edition.
+	long, nuls := strings.Repeat("a", 70000), strings.Repeat(`\u0000`, 40000)
+	java := NewConverter()
+	java.UseJavaEncoding()
+	tooLong := []struct {
+		c        *Converter
+		json     string
+		expected []string
+	}{
+		{NewConverter(), `{"nbt":[{"tagType":10,"name":"","value":[{"tagType":8,"name":"s","value":"` + long + `"},{"tagType":1,"name":"` + long + `","value":1}]}]}`, []string{"/s", "/" + long}},
+		{NewConverter(), `{"nbt":[{"tagType":9,"name":"","value":{"tagListType":8,"list":["a","` + long + `"]}}]}`, []string{"[1]"}},
+		{java, `{"nbt":[{"tagType":8,"name":"","value":"` + nuls + `"}]}`, []string{""}},
+	}
+	for _, test := range tooLong {
+		err := test.c.ValidateJson([]byte(test.json))
+		var validationErr ValidationError
+		if !errors.As(err, &validationErr) || len(validationErr.Problems) != len(test.expected) {
+			t.Errorf("Too long names and strings expected problems in %d tags, got %v", len(test.expected), err)
+			continue
+		}
+		for i, problem := range validationErr.Problems {
+			if problem.Path != test.expected[i] {
+				t.Errorf("Too long name or string expected at %.20q, got %.20q", test.expected[i], problem.Path)
+			}
+		}
+		if _, err := test.c.Json2Nbt([]byte(test.json)); err == nil {
+			t.Error("Converting a too long name or string failed to throw error")
+		}
+	}
+	if err := NewConverter().ValidateJson([]byte(`{"nbt":[{"tagType":8,"name":"","value":"` + nuls + `"}]}`)); err != nil {
+		t.Error("40000 NULs expected to fit in a Bedrock string:", err.Error())
+	}
+	if err := WriteNBT(ioutil.Discard, []NamedTag{{"", String(long)}}); err == nil {
+		t.Error("Writing a 70000 byte string failed to throw error")
+	}
+}
+
+// TestJsonStructure checks the streaming and tree conversions both reject malformed tags, and that strict mode
+// rejects fractions and unknown fields which are otherwise allowed
+func TestJsonStructure(t *testing.T) {
+	tags := []struct {
+		json   string
+		strict bool
+	}{
+		{`{"tagType":"1","name":"","value":1}`, false},
+		{`{"tagType":null,"name":"","value":1}`, false},
+		{`{"tagType":1.5,"name":"","value":1}`, false},
+		{`{"tagType":13,"name":"","value":1}`, false},
+		{`{"tagType":9,"name":"","value":{"list":[1]}}`, false},
+		{`{"tagType":9,"name":"","value":{"tagListType":0,"list":[1]}}`, false},
+		{`{"tagType":9,"name":"","value":{"tagListType":13,"list":[]}}`, false},
+		{`{"tagType":9,"name":"","value":{"list":[1],"tagListType":"1"}}`, false},
+		{`{"tagType":4,"name":"","value":{"valueLeast":0,"valueMost":4294967296}}`, false},
+		{`{"tagType":1,"name":"","value":1.5}`, true},
+		{`{"tagType":11,"name":"","value":[1,2.5]}`, true},
+		{`{"tagType":4,"name":"","value":{"valueLeast":0.5,"valueMost":0}}`, true},
+		{`{"tagType":1,"name":"","value":1,"extra":1}`, true},
+		{`{"tagType":9,"name":"","value":{"tagListType":1,"list":[],"extra":1}}`, true},
+		{`{"tagType":4,"name":"","value":{"valueLeast":0,"valueMost":0,"extra":1}}`, true},
+	}
+	for _, tag := range tags {
+		doc := []byte(`{"nbt":[` + tag.json + `]}`)
+		c := NewConverter()
+		for _, strict := range []bool{false, true} {
+			if strict {
+				c.UseStrictJson()
+			}
+			_, streamErr := c.Json2Nbt(doc)
+			_, treeErr := c.Json2Tags(doc)
+			validateErr := c.ValidateJson(doc)
+			expectErr := strict || !tag.strict
+			if (streamErr != nil) != expectErr || (treeErr != nil) != expectErr || (validateErr != nil) != expectErr {
+				t.Errorf("%s in strict mode %v expected error %v, got %v, %v and %v", tag.json, strict, expectErr, streamErr, treeErr, validateErr)
+			}
+		}
+	}
+
+	tags2, err := Json2Tags([]byte(`{"nbt":[{"tagType":1,"name":"","value":1.5}],"extra":1}`))
+	if err != nil || tags2[0].Value != Byte(1) {
+		t.Errorf("Lenient conversion expected to truncate 1.5 to 1b, got %v, %v", tags2, err)
+	}
+}